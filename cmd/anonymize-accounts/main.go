@@ -0,0 +1,53 @@
+package main
+
+import (
+	"evernos-api2/database"
+	"evernos-api2/repositories"
+	"evernos-api2/search"
+	"evernos-api2/services"
+	"evernos-api2/storage"
+	"fmt"
+	"log"
+
+	"github.com/joho/godotenv"
+)
+
+// Command untuk menganonimkan akun yang masa tenggang penghapusannya sudah lewat.
+// Jalankan secara berkala, misalnya lewat cron harian.
+func main() {
+	// Muat variabel dari file .env
+	if err := godotenv.Load(); err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	database.ConnectDB()
+	if err := storage.Init(); err != nil {
+		log.Fatal("Failed to initialize storage: ", err)
+	}
+
+	// Produk toko dihapus lewat ProductService agar foto di storage dan index pencarian ikut dibersihkan.
+	// Index driver "memory" milik server dibangun ulang saat start; produk terhapus tidak pernah ditampilkan.
+	productRepo := repositories.NewProductRepository(database.DB)
+	fotoProdukRepo := repositories.NewFotoProdukRepository(database.DB)
+	blobRepo := repositories.NewBlobRepository(database.DB)
+	imageProcessor := services.NewImageProcessor(fotoProdukRepo, blobRepo, storage.Default)
+	fotoProdukService := services.NewFotoProdukService(fotoProdukRepo, productRepo, blobRepo, imageProcessor, storage.Default)
+	notifikasiService := services.NewNotifikasiService(repositories.NewNotifikasiRepository(database.DB))
+	wishlistService := services.NewWishlistService(repositories.NewWishlistRepository(database.DB), productRepo, notifikasiService, services.NewEmailService())
+	searchEngine, err := search.NewFromEnv(database.DB)
+	if err != nil {
+		log.Fatal("Failed to initialize search engine: ", err)
+	}
+	productService := services.NewProductService(productRepo, repositories.NewMutasiStokRepository(database.DB), fotoProdukService, wishlistService, searchEngine)
+
+	profileRepo := repositories.NewProfileRepository(database.DB)
+	sessionService := services.NewSessionService(repositories.NewSessionRepository(database.DB))
+	profileService := services.NewProfileService(profileRepo, sessionService, productService)
+
+	anonymized, err := profileService.AnonymizeDueAccounts()
+	if err != nil {
+		log.Fatalf("❌ Anonymization stopped after %d account(s): %v", anonymized, err)
+	}
+
+	fmt.Printf("✅ %d account(s) anonymized\n", anonymized)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"evernos-api2/database"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/joho/godotenv"
+)
+
+// Command untuk mengonversi harga lama (varchar/INT rupiah) ke BIGINT sen. Migrasi yang sama juga
+// dijalankan otomatis saat server start; jalankan dengan -dry-run sebelum deploy untuk melihat
+// nilai harga yang tidak bisa dikonversi (akan diisi 0) agar bisa diperbaiki lebih dulu.
+func main() {
+	dryRun := flag.Bool("dry-run", false, "hanya tampilkan report tanpa mengubah data")
+	flag.Parse()
+
+	// Muat variabel dari file .env
+	if err := godotenv.Load(); err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	database.ConnectDB()
+
+	report, err := database.MigrateMoney(database.DB, *dryRun)
+	if err != nil {
+		log.Fatal("❌ Price migration failed: ", err)
+	}
+
+	for _, invalid := range report.Invalid {
+		fmt.Printf("⚠️  %s.%s id=%d: %q\n", invalid.Table, invalid.Column, invalid.ID, invalid.Value)
+	}
+	if *dryRun {
+		fmt.Printf("🔍 %d row(s) to convert, %d invalid value(s) (dry run)\n", report.Converted, len(report.Invalid))
+		return
+	}
+	fmt.Printf("✅ %d row(s) converted, %d invalid value(s) set to 0\n", report.Converted, len(report.Invalid))
+}
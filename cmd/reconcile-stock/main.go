@@ -0,0 +1,55 @@
+package main
+
+import (
+	"evernos-api2/database"
+	"evernos-api2/repositories"
+	"evernos-api2/services"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+// Command untuk mengecek apakah stok produk dan varian sama dengan jumlah mutasi di ledger stok.
+// Keluar dengan status 1 jika ada selisih sehingga bisa dipakai di cron/monitoring. Gunakan -fix
+// untuk menyamakan stok dengan ledger.
+func main() {
+	fix := flag.Bool("fix", false, "samakan stok dengan jumlah ledger")
+	flag.Parse()
+
+	// Muat variabel dari file .env
+	if err := godotenv.Load(); err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	database.ConnectDB()
+
+	mutasiStokRepo := repositories.NewMutasiStokRepository(database.DB)
+	productRepo := repositories.NewProductRepository(database.DB)
+	stokService := services.NewStokService(mutasiStokRepo, productRepo)
+
+	discrepancies, err := stokService.Reconcile(*fix)
+	if err != nil {
+		log.Fatal("❌ Stock reconciliation failed: ", err)
+	}
+
+	for _, d := range discrepancies {
+		if d.IdVarian != nil {
+			fmt.Printf("⚠️  produk %d varian %d: stok %d, ledger %d\n", d.IdProduk, *d.IdVarian, d.Stok, d.Ledger)
+		} else {
+			fmt.Printf("⚠️  produk %d: stok %d, ledger %d\n", d.IdProduk, d.Stok, d.Ledger)
+		}
+	}
+
+	switch {
+	case len(discrepancies) == 0:
+		fmt.Println("✅ Stok sesuai dengan ledger")
+	case *fix:
+		fmt.Printf("✅ %d selisih stok diperbaiki sesuai ledger\n", len(discrepancies))
+	default:
+		fmt.Printf("❌ %d selisih stok ditemukan (jalankan dengan -fix untuk memperbaiki)\n", len(discrepancies))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"evernos-api2/database"
+	"evernos-api2/repositories"
+	"evernos-api2/services"
+	"evernos-api2/storage"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/joho/godotenv"
+)
+
+// Command untuk menghapus file foto di storage yang tidak lagi dirujuk database.
+// Jalankan secara berkala, misalnya lewat cron harian. Gunakan -dry-run untuk melihat
+// jumlah file yatim tanpa menghapusnya.
+func main() {
+	dryRun := flag.Bool("dry-run", false, "hanya hitung file yatim tanpa menghapus")
+	flag.Parse()
+
+	// Muat variabel dari file .env
+	if err := godotenv.Load(); err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	database.ConnectDB()
+	if err := storage.Init(); err != nil {
+		log.Fatal("Failed to initialize storage: ", err)
+	}
+
+	fotoProdukRepo := repositories.NewFotoProdukRepository(database.DB)
+	blobRepo := repositories.NewBlobRepository(database.DB)
+	gcService := services.NewStorageGCService(fotoProdukRepo, blobRepo, storage.Default)
+
+	result, err := gcService.CollectOrphans(context.Background(), *dryRun)
+	if err != nil {
+		log.Fatalf("❌ Storage GC stopped after deleting %d file(s): %v", result.Deleted, err)
+	}
+
+	if *dryRun {
+		fmt.Printf("🔍 %d file(s) scanned, %d orphan(s) found (dry run)\n", result.Scanned, result.Orphans)
+		return
+	}
+	fmt.Printf("✅ %d file(s) scanned, %d orphan(s) deleted, %d unused blob(s) removed\n", result.Scanned, result.Deleted, result.BlobsRemoved)
+}
@@ -28,16 +28,56 @@ func ConnectDB() {
 }
 
 func MigrateDB() {
-	err := DB.AutoMigrate(
+	// Harga lama (varchar/INT rupiah) dikonversi ke sen sebelum AutoMigrate mengubah tipe kolomnya
+	report, err := MigrateMoney(DB, false)
+	if err != nil {
+		log.Fatal("Failed to migrate prices!", err)
+	}
+	for _, invalid := range report.Invalid {
+		log.Printf("⚠️  Harga tidak valid diisi 0: %s.%s id=%d nilai=%q", invalid.Table, invalid.Column, invalid.ID, invalid.Value)
+	}
+	if report.Converted > 0 {
+		fmt.Printf("💰 %d row(s) converted to integer prices, %d invalid value(s)\n", report.Converted, len(report.Invalid))
+	}
+
+	// Slug produk dibuat unik per toko sebelum AutoMigrate menambahkan unique index
+	if err := MigrateProductSlugs(DB); err != nil {
+		log.Fatal("Failed to migrate product slugs!", err)
+	}
+
+	// Kolom key di foto_produks dan blobs diganti nama sebelum AutoMigrate membaca struct terbaru
+	if err := MigrateStorageKeyColumns(DB); err != nil {
+		log.Fatal("Failed to rename storage key columns!", err)
+	}
+
+	err = DB.AutoMigrate(
+		&models.SchemaMigration{},
 		&models.User{},
 		&models.Alamat{},
 		&models.Toko{},
 		&models.Category{},
 		&models.Produk{},
+		&models.SlugProduk{},
+		&models.OpsiVarian{},
+		&models.VarianProduk{},
+		&models.Blob{},
 		&models.FotoProduk{},
 		&models.Trx{},
 		&models.DetailTrx{},
 		&models.LogProduk{},
+		&models.ApiKey{},
+		&models.Session{},
+		&models.UploadSession{},
+		&models.MutasiStok{},
+		&models.ImportJob{},
+		&models.ImportFile{},
+		&models.Notifikasi{},
+		&models.Ulasan{},
+		&models.FotoUlasan{},
+		&models.Pertanyaan{},
+		&models.UpvotePertanyaan{},
+		&models.Wishlist{},
+		&models.LanggananStok{},
 	)
 
 	if err != nil {
@@ -45,5 +85,20 @@ func MigrateDB() {
 		os.Exit(1)
 	}
 
+	// Saldo awal ledger stok untuk data yang sudah ada
+	if err := MigrateStockLedger(DB); err != nil {
+		log.Fatal("Failed to migrate stock ledger!", err)
+	}
+
+	// SKU varian yang sudah ada didaftarkan ke unique index SKU per toko
+	if err := MigrateVariantSkus(DB); err != nil {
+		log.Fatal("Failed to migrate variant SKUs!", err)
+	}
+
+	// Batas waktu token lama tanpa sesi (claim sid)
+	if err := MigrateSessionTokens(DB); err != nil {
+		log.Fatal("Failed to migrate session tokens!", err)
+	}
+
 	fmt.Println("👍 Database Migration successful")
 }
@@ -0,0 +1,214 @@
+// file: database/money_migration.go
+
+package database
+
+import (
+	"evernos-api2/models"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kolom harga lama berupa varchar rupiah yang diubah ke BIGINT sen
+var moneyStringColumns = []struct {
+	table   string
+	model   interface{}
+	columns []string
+	fields  []string
+}{
+	{"produks", &models.Produk{}, []string{"harga_reseller", "harga_konsumen"}, []string{"HargaReseller", "HargaKonsumen"}},
+	{"varian_produks", &models.VarianProduk{}, []string{"harga_reseller", "harga_konsumen"}, []string{"HargaReseller", "HargaKonsumen"}},
+	{"log_produks", &models.LogProduk{}, []string{"harga_reseller", "harga_konsumen"}, []string{"HargaReseller", "HargaKonsumen"}},
+}
+
+// Kolom total lama berupa INT rupiah yang diubah ke BIGINT sen
+var moneyIntColumns = []struct {
+	table  string
+	model  interface{}
+	column string
+	field  string
+}{
+	{"trxes", &models.Trx{}, "harga_total", "HargaTotal"},
+	{"detail_trxes", &models.DetailTrx{}, "harga_total", "HargaTotal"},
+}
+
+// InvalidMoneyValue adalah nilai harga lama yang tidak bisa dikonversi dan diisi 0
+type InvalidMoneyValue struct {
+	Table  string
+	ID     uint
+	Column string
+	Value  string
+}
+
+// MoneyMigrationReport adalah hasil migrasi harga ke Money
+type MoneyMigrationReport struct {
+	Converted int64
+	Invalid   []InvalidMoneyValue
+}
+
+// MigrateMoney mengubah kolom harga lama (varchar rupiah dan INT rupiah) menjadi BIGINT sen.
+// Setiap tabel ditandai di schema_migrations setelah datanya dikonversi sehingga aman dijalankan
+// ulang. Nilai yang tidak bisa di-parse diisi 0 dan dicatat di report. Dengan dryRun, hanya
+// report yang dihitung tanpa mengubah data. Harus dijalankan sebelum AutoMigrate mengubah tipe kolom.
+func MigrateMoney(db *gorm.DB, dryRun bool) (*MoneyMigrationReport, error) {
+	report := &MoneyMigrationReport{}
+	if !dryRun {
+		if err := db.AutoMigrate(&models.SchemaMigration{}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, t := range moneyStringColumns {
+		version := "money_sen_" + t.table
+		pending, err := moneyMigrationPending(db, version, t.table, t.columns[0], dryRun)
+		if err != nil {
+			return nil, err
+		}
+		if !pending {
+			continue
+		}
+
+		updates, err := convertMoneyStrings(db, t.table, t.columns, report)
+		if err != nil {
+			return nil, err
+		}
+		if dryRun {
+			continue
+		}
+
+		// Kolom masih varchar, jadi nilai sen ditulis sebagai string lalu tipe kolom diubah
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for id, values := range updates {
+				if err := tx.Table(t.table).Where("id = ?", id).Updates(values).Error; err != nil {
+					return err
+				}
+			}
+			return markMigration(tx, version)
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range t.fields {
+			if err := db.Migrator().AlterColumn(t.model, field); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, t := range moneyIntColumns {
+		version := "money_sen_" + t.table
+		pending, err := moneyMigrationPending(db, version, t.table, t.column, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		if !pending {
+			continue
+		}
+
+		var count int64
+		if err := db.Table(t.table).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		report.Converted += count
+		if dryRun {
+			continue
+		}
+
+		// Kolom diperlebar ke BIGINT dulu agar perkalian tidak overflow
+		if err := db.Migrator().AlterColumn(t.model, t.field); err != nil {
+			return nil, err
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("UPDATE "+t.table+" SET "+t.column+" = "+t.column+" * ?", models.SenPerRupiah).Error; err != nil {
+				return err
+			}
+			return markMigration(tx, version)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// moneyMigrationPending mengecek apakah tabel masih perlu dikonversi. Tabel yang belum ada
+// (database baru) langsung ditandai karena akan dibuat dengan tipe kolom yang baru.
+func moneyMigrationPending(db *gorm.DB, version, table, column string, dryRun bool) (bool, error) {
+	if db.Migrator().HasTable(&models.SchemaMigration{}) {
+		var count int64
+		if err := db.Model(&models.SchemaMigration{}).Where("version = ?", version).Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return false, nil
+		}
+	}
+
+	if !db.Migrator().HasTable(table) || !db.Migrator().HasColumn(table, column) {
+		if dryRun {
+			return false, nil
+		}
+		return false, markMigration(db, version)
+	}
+	return true, nil
+}
+
+// convertMoneyStrings membaca semua baris (termasuk yang di-soft delete) dan mengembalikan nilai sen
+// per ID. Nilai yang tidak valid dicatat di report.
+func convertMoneyStrings(db *gorm.DB, table string, columns []string, report *MoneyMigrationReport) (map[uint]map[string]interface{}, error) {
+	rows, err := db.Table(table).Select(append([]string{"id"}, columns...)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	updates := make(map[uint]map[string]interface{})
+	for rows.Next() {
+		var id uint
+		raw := make([]*string, len(columns))
+		dest := []interface{}{&id}
+		for i := range raw {
+			dest = append(dest, &raw[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		values := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			value := ""
+			if raw[i] != nil {
+				value = *raw[i]
+			}
+			price, ok := legacyMoney(value)
+			if !ok {
+				report.Invalid = append(report.Invalid, InvalidMoneyValue{Table: table, ID: id, Column: column, Value: value})
+			}
+			values[column] = strconv.FormatInt(int64(price), 10)
+		}
+		updates[id] = values
+		report.Converted++
+	}
+	return updates, rows.Err()
+}
+
+// legacyMoney mem-parsing harga lama. Selain format rupiah biasa, nilai yang dulu lolos validasi
+// strconv.ParseFloat (misalnya "1e3" atau "12.345") tetap diterima dan dibulatkan ke sen terdekat.
+func legacyMoney(value string) (models.Money, bool) {
+	if price, err := models.ParseMoney(value); err == nil {
+		return price, true
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || f < 0 || math.IsInf(f, 0) || math.IsNaN(f) || f*models.SenPerRupiah > math.MaxInt64 {
+		return 0, false
+	}
+	return models.Money(math.Round(f * models.SenPerRupiah)), true
+}
+
+func markMigration(db *gorm.DB, version string) error {
+	return db.Create(&models.SchemaMigration{Version: version, AppliedAt: time.Now()}).Error
+}
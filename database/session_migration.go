@@ -0,0 +1,32 @@
+// file: database/session_migration.go
+
+package database
+
+import (
+	"evernos-api2/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const sessionTokenVersion = "session_tokens"
+
+// LegacyTokenCutoff adalah waktu server pertama kali berjalan dengan pencatatan sesi login. Token
+// tanpa claim sid hanya bisa diterbitkan sebelum waktu ini. Diisi oleh MigrateSessionTokens.
+var LegacyTokenCutoff time.Time
+
+// MigrateSessionTokens mencatat LegacyTokenCutoff saat pertama dijalankan dan memuatnya kembali
+// di start berikutnya
+func MigrateSessionTokens(db *gorm.DB) error {
+	var migration models.SchemaMigration
+	err := db.Where("version = ?", sessionTokenVersion).First(&migration).Error
+	if err == gorm.ErrRecordNotFound {
+		migration = models.SchemaMigration{Version: sessionTokenVersion, AppliedAt: time.Now()}
+		err = db.Create(&migration).Error
+	}
+	if err != nil {
+		return err
+	}
+	LegacyTokenCutoff = migration.AppliedAt
+	return nil
+}
@@ -0,0 +1,73 @@
+// file: database/slug_migration.go
+
+package database
+
+import (
+	"evernos-api2/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const productSlugVersion = "product_slugs_unique"
+
+// MigrateProductSlugs membuat ulang slug produk lama menjadi slug URL-safe yang unik per toko
+// (termasuk produk yang sudah dihapus). Slug lama yang berubah disimpan di slug_produks agar link
+// lama tetap di-redirect. Harus dijalankan sebelum AutoMigrate membuat unique index (id_toko, slug).
+func MigrateProductSlugs(db *gorm.DB) error {
+	if err := db.AutoMigrate(&models.SchemaMigration{}, &models.SlugProduk{}); err != nil {
+		return err
+	}
+	var count int64
+	if err := db.Model(&models.SchemaMigration{}).Where("version = ?", productSlugVersion).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if !db.Migrator().HasTable(&models.Produk{}) {
+		return markMigration(db, productSlugVersion)
+	}
+
+	var products []models.Produk
+	err := db.Unscoped().Select("id", "id_toko", "nama_produk", "slug").Order("id ASC").Find(&products).Error
+	if err != nil {
+		return err
+	}
+
+	// Produk yang lebih lama mendapat slug tanpa akhiran angka
+	taken := make(map[uint]map[string]bool)
+	slugs := make([]string, len(products))
+	for i, p := range products {
+		if taken[p.IdToko] == nil {
+			taken[p.IdToko] = make(map[string]bool)
+		}
+		base := models.Slugify(p.Slug)
+		if base == "" {
+			base = models.Slugify(p.NamaProduk)
+		}
+		slugs[i] = models.UniqueSlug(base, taken[p.IdToko])
+		taken[p.IdToko][slugs[i]] = true
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i, p := range products {
+			if slugs[i] == p.Slug {
+				continue
+			}
+			if err := tx.Unscoped().Model(&models.Produk{}).Where("id = ?", p.ID).UpdateColumn("slug", slugs[i]).Error; err != nil {
+				return err
+			}
+			// Slug lama yang bentrok dengan slug produk lain tidak bisa di-redirect. Collation MySQL
+			// tidak membedakan huruf besar/kecil, jadi slug lama yang hanya beda kapital cukup disimpan sekali.
+			if p.Slug == "" || taken[p.IdToko][p.Slug] {
+				continue
+			}
+			history := models.SlugProduk{IdToko: p.IdToko, IdProduk: p.ID, Slug: p.Slug}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&history).Error; err != nil {
+				return err
+			}
+		}
+		return markMigration(tx, productSlugVersion)
+	})
+}
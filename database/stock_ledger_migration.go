@@ -0,0 +1,48 @@
+// file: database/stock_ledger_migration.go
+
+package database
+
+import (
+	"evernos-api2/models"
+
+	"gorm.io/gorm"
+)
+
+const stockLedgerOpeningVersion = "stock_ledger_opening"
+
+// MigrateStockLedger mencatat saldo awal ledger stok untuk produk dan varian yang sudah ada sebelum
+// ledger dipakai, sehingga stok setiap produk sama dengan jumlah mutasinya. Hanya dijalankan sekali.
+func MigrateStockLedger(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&models.SchemaMigration{}).Where("version = ?", stockLedgerOpeningVersion).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Saldo awal varian
+		err := tx.Exec(`INSERT INTO mutasi_stoks (id_produk, id_varian, jenis, jumlah, stok_setelah, referensi, catatan, created_at)
+			SELECT id_produk, id, ?, stok, stok, '', 'saldo awal', NOW()
+			FROM varian_produks WHERE deleted_at IS NULL AND stok <> 0`, models.MutasiAdjustment).Error
+		if err != nil {
+			return err
+		}
+
+		// Saldo awal produk di luar stok varian (untuk produk dengan varian biasanya 0)
+		err = tx.Exec(`INSERT INTO mutasi_stoks (id_produk, id_varian, jenis, jumlah, stok_setelah, referensi, catatan, created_at)
+			SELECT id, NULL, ?, jumlah, stok, '', 'saldo awal', NOW() FROM (
+				SELECT produks.id, produks.stok, produks.stok - COALESCE((
+					SELECT SUM(varian_produks.stok) FROM varian_produks
+					WHERE varian_produks.id_produk = produks.id AND varian_produks.deleted_at IS NULL
+				), 0) AS jumlah
+				FROM produks WHERE produks.deleted_at IS NULL
+			) AS saldo WHERE jumlah <> 0`, models.MutasiAdjustment).Error
+		if err != nil {
+			return err
+		}
+
+		return markMigration(tx, stockLedgerOpeningVersion)
+	})
+}
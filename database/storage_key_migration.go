@@ -0,0 +1,25 @@
+// file: database/storage_key_migration.go
+
+package database
+
+import (
+	"evernos-api2/models"
+
+	"gorm.io/gorm"
+)
+
+// MigrateStorageKeyColumns mengganti nama kolom `key` (kata kunci MySQL) menjadi storage_key di
+// tabel foto_produks dan blobs. Harus dijalankan sebelum AutoMigrate agar AutoMigrate tidak
+// membuat kolom storage_key baru yang kosong. Tabel yang sudah memakai storage_key dilewati.
+func MigrateStorageKeyColumns(db *gorm.DB) error {
+	migrator := db.Migrator()
+	for _, model := range []interface{}{&models.FotoProduk{}, &models.Blob{}} {
+		if !migrator.HasTable(model) || !migrator.HasColumn(model, "key") || migrator.HasColumn(model, "storage_key") {
+			continue
+		}
+		if err := migrator.RenameColumn(model, "key", "storage_key"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// file: database/variant_sku_migration.go
+
+package database
+
+import (
+	"evernos-api2/models"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const variantSkuVersion = "variant_sku_unique"
+
+// MigrateVariantSkus mengisi id_toko dan sku_aktif varian yang sudah ada sebelum unique index SKU per
+// toko dipakai. Varian produk yang sudah dihapus tetap NULL. Jika data lama sudah berisi SKU ganda di
+// satu toko, hanya varian terlama yang mendapat sku_aktif dan sisanya dicatat di log agar diperbaiki
+// penjual. Hanya dijalankan sekali.
+func MigrateVariantSkus(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&models.SchemaMigration{}).Where("version = ?", variantSkuVersion).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`UPDATE varian_produks JOIN produks ON produks.id = varian_produks.id_produk
+			SET varian_produks.id_toko = produks.id_toko`).Error
+		if err != nil {
+			return err
+		}
+
+		var varian []models.VarianProduk
+		err = tx.Joins("JOIN produks ON produks.id = varian_produks.id_produk AND produks.deleted_at IS NULL").
+			Order("varian_produks.id ASC").Find(&varian).Error
+		if err != nil {
+			return err
+		}
+		taken := make(map[uint]map[string]bool)
+		for _, v := range varian {
+			if taken[v.IdToko] == nil {
+				taken[v.IdToko] = make(map[string]bool)
+			}
+			sku := strings.ToLower(v.Sku)
+			if taken[v.IdToko][sku] {
+				log.Printf("⚠️  SKU ganda di toko %d: varian id=%d sku=%q tidak diberi unique index", v.IdToko, v.ID, v.Sku)
+				continue
+			}
+			taken[v.IdToko][sku] = true
+			if err := tx.Model(&models.VarianProduk{}).Where("id = ?", v.ID).Update("sku_aktif", v.Sku).Error; err != nil {
+				return err
+			}
+		}
+
+		return markMigration(tx, variantSkuVersion)
+	})
+}
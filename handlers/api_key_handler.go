@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"evernos-api2/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ApiKeyHandler struct {
+	apiKeyService *services.ApiKeyService
+}
+
+func NewApiKeyHandler(apiKeyService *services.ApiKeyService) *ApiKeyHandler {
+	return &ApiKeyHandler{apiKeyService: apiKeyService}
+}
+
+// GetMyApiKeys mengambil semua API key toko milik user yang sedang login
+func (h *ApiKeyHandler) GetMyApiKeys(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	apiKeys, err := h.apiKeyService.GetMyApiKeys(uint(userID))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Berhasil mengambil data API key",
+		"data":    apiKeys,
+		"scopes":  services.AvailableScopes,
+	})
+}
+
+// CreateApiKey membuat API key baru untuk toko user
+func (h *ApiKeyHandler) CreateApiKey(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	// Parse request body
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Format data tidak valid",
+		})
+	}
+
+	rawKey, apiKey, err := h.apiKeyService.CreateApiKey(uint(userID), data)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Berhasil membuat API key. Simpan key ini, key tidak akan ditampilkan lagi",
+		"key":     rawKey,
+		"data":    apiKey,
+	})
+}
+
+// RevokeApiKey mencabut API key
+func (h *ApiKeyHandler) RevokeApiKey(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	idParam := c.Params("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID API key tidak valid",
+		})
+	}
+
+	if err := h.apiKeyService.RevokeApiKey(uint(id), uint(userID)); err != nil {
+		if err.Error() == "API key tidak ditemukan" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "API key berhasil dicabut",
+	})
+}
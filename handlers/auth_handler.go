@@ -5,8 +5,9 @@ package handlers
 import (
 	"evernos-api2/database"
 	"evernos-api2/models"
+	"evernos-api2/repositories"
+	"evernos-api2/services"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -39,6 +40,11 @@ func Register(c *fiber.Ctx) error {
 		})
 	}
 
+	// Validasi kebijakan password
+	if err := services.ValidatePassword(data["password"], data["email"], data["nama"], data["noTelp"]); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": err.Error()})
+	}
+
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(data["password"]), bcrypt.DefaultCost)
 	if err != nil {
@@ -132,23 +138,52 @@ func Login(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid credentials"})
 	}
 
+	// Login kembali selama masa tenggang membatalkan permintaan hapus akun
+	deletionCancelled := false
+	if user.DeletionRequestedAt != nil {
+		if err := database.DB.Model(&user).Update("deletion_requested_at", nil).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Could not cancel account deletion"})
+		}
+		deletionCancelled = true
+	}
+
+	// Catat sesi login agar token bisa dicabut per perangkat
+	expiresAt := time.Now().Add(services.SessionTTL)
+	sessionService := services.NewSessionService(repositories.NewSessionRepository(database.DB))
+	session, err := sessionService.CreateSession(user.ID, c.Get(fiber.HeaderUserAgent), c.IP(), expiresAt)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Could not create session"})
+	}
+
 	// Membuat claims untuk JWT
 	claims := jwt.MapClaims{
 		"user_id":  user.ID,
 		"is_admin": user.IsAdmin,
-		"exp":      time.Now().Add(time.Hour * 72).Unix(),
+		"sid":      session.ID,
+		"exp":      expiresAt.Unix(),
 	}
 
-	// Membuat token JWT
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
-
+	// Membuat token JWT dengan key signing aktif
+	tokenString, err := services.GenerateToken(claims)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Could not generate token"})
 	}
 
-	return c.JSON(fiber.Map{
+	response := fiber.Map{
 		"message": "Login successful",
 		"token":   tokenString,
+	}
+	if deletionCancelled {
+		response["deletionCancelled"] = true
+	}
+
+	return c.JSON(response)
+}
+
+// GetJWKS handles GET /.well-known/jwks.json
+func GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(fiber.Map{
+		"keys": services.GetJWKS(),
 	})
 }
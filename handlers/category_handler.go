@@ -58,7 +58,8 @@ func (h *CategoryHandler) GetCategoryByID(c *fiber.Ctx) error {
 // CreateCategory handles POST /category (ADMIN ONLY)
 func (h *CategoryHandler) CreateCategory(c *fiber.Ctx) error {
 	var request struct {
-		NamaCategory string `json:"nama_category"`
+		NamaCategory       string `json:"nama_category"`
+		RequiresModeration bool   `json:"requires_moderation"`
 	}
 
 	if err := c.BodyParser(&request); err != nil {
@@ -75,7 +76,7 @@ func (h *CategoryHandler) CreateCategory(c *fiber.Ctx) error {
 		})
 	}
 
-	category, err := h.categoryService.CreateCategory(request.NamaCategory)
+	category, err := h.categoryService.CreateCategory(request.NamaCategory, request.RequiresModeration)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"message": "Failed to create category",
@@ -100,7 +101,8 @@ func (h *CategoryHandler) UpdateCategory(c *fiber.Ctx) error {
 	}
 
 	var request struct {
-		NamaCategory string `json:"nama_category"`
+		NamaCategory       string `json:"nama_category"`
+		RequiresModeration *bool  `json:"requires_moderation"`
 	}
 
 	if err := c.BodyParser(&request); err != nil {
@@ -117,7 +119,7 @@ func (h *CategoryHandler) UpdateCategory(c *fiber.Ctx) error {
 		})
 	}
 
-	category, err := h.categoryService.UpdateCategory(uint(id), request.NamaCategory)
+	category, err := h.categoryService.UpdateCategory(uint(id), request.NamaCategory, request.RequiresModeration)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"message": "Failed to update category",
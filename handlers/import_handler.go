@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"evernos-api2/services"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ImportHandler struct {
+	importService *services.ImportService
+}
+
+func NewImportHandler(importService *services.ImportService) *ImportHandler {
+	return &ImportHandler{importService: importService}
+}
+
+// ImportProducts menerima file CSV/XLSX dan memulai job import produk di background
+func (h *ImportHandler) ImportProducts(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "File import harus diupload",
+		})
+	}
+
+	// dry_run bisa dikirim sebagai form field atau query parameter
+	dryRunStr := c.FormValue("dry_run", c.Query("dry_run"))
+	dryRun, _ := strconv.ParseBool(dryRunStr)
+
+	job, err := h.importService.StartImport(uint(userID), file, dryRun)
+	if err != nil {
+		if err == services.ErrImportQueueFull {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		switch err.Error() {
+		case "user belum memiliki toko", "format file harus CSV atau XLSX":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Import produk sedang diproses",
+		"data":    job,
+	})
+}
+
+// GetImportJob mengambil status dan hasil job import produk
+func (h *ImportHandler) GetImportJob(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID job import tidak valid",
+		})
+	}
+
+	job, err := h.importService.GetJob(uint(userID), uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Berhasil mengambil status import",
+		"data":    job,
+	})
+}
+
+// ExportProducts mengunduh semua produk toko sebagai CSV (default) atau XLSX
+func (h *ImportHandler) ExportProducts(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	format := c.Query("format", "csv")
+	data, err := h.importService.ExportProducts(uint(userID), format)
+	if err != nil {
+		switch err.Error() {
+		case "user belum memiliki toko", "format export harus csv atau xlsx":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == "xlsx" {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	fileName := fmt.Sprintf("produk-%d-%s.%s", uint(userID), time.Now().Format("20060102"), format)
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	return c.Send(data)
+}
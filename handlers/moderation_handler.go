@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"evernos-api2/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ModerationHandler struct {
+	moderationService *services.ModerationService
+}
+
+func NewModerationHandler(moderationService *services.ModerationService) *ModerationHandler {
+	return &ModerationHandler{moderationService: moderationService}
+}
+
+// GetModerationQueue mengambil antrian moderasi produk (?status=pending|approved|rejected) (ADMIN ONLY)
+func (h *ModerationHandler) GetModerationQueue(c *fiber.Ctx) error {
+	products, pagination, err := h.moderationService.GetQueue(c.Query("status"), c.Query("limit"), c.Query("page"), c.Query("cursor"))
+	if err != nil {
+		if err.Error() == "status moderasi tidak valid" || err.Error() == "cursor tidak valid" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Moderation queue retrieved successfully",
+		"data":       products,
+		"pagination": pagination,
+	})
+}
+
+// ApproveProduct menyetujui produk (ADMIN ONLY)
+func (h *ModerationHandler) ApproveProduct(c *fiber.Ctx) error {
+	adminID, _ := c.Locals("user_id").(float64)
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid product ID",
+		})
+	}
+
+	product, err := h.moderationService.Approve(uint(id), uint(adminID))
+	if err != nil {
+		return moderationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Product approved successfully",
+		"data":    product,
+	})
+}
+
+// RejectProduct menolak produk dengan alasan ({"reason": "..."}) (ADMIN ONLY)
+func (h *ModerationHandler) RejectProduct(c *fiber.Ctx) error {
+	adminID, _ := c.Locals("user_id").(float64)
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid product ID",
+		})
+	}
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+	}
+
+	product, err := h.moderationService.Reject(uint(id), uint(adminID), request.Reason)
+	if err != nil {
+		return moderationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Product rejected successfully",
+		"data":    product,
+	})
+}
+
+// GetQuestionModeration mengambil pertanyaan produk untuk dimoderasi (?status=visible|hidden) (ADMIN ONLY)
+func (h *ModerationHandler) GetQuestionModeration(c *fiber.Ctx) error {
+	pertanyaans, pagination, err := h.moderationService.GetQuestions(c.Query("status"), c.Query("limit"), c.Query("page"), c.Query("cursor"))
+	if err != nil {
+		if err.Error() == "status moderasi tidak valid" || err.Error() == "cursor tidak valid" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Questions retrieved successfully",
+		"data":       pertanyaans,
+		"pagination": pagination,
+	})
+}
+
+// HideQuestion menyembunyikan pertanyaan dengan alasan ({"reason": "..."}) (ADMIN ONLY)
+func (h *ModerationHandler) HideQuestion(c *fiber.Ctx) error {
+	adminID, _ := c.Locals("user_id").(float64)
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid question ID",
+		})
+	}
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+			"error":   err.Error(),
+		})
+	}
+
+	pertanyaan, err := h.moderationService.HideQuestion(uint(id), uint(adminID), request.Reason)
+	if err != nil {
+		return moderationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Question hidden successfully",
+		"data":    pertanyaan,
+	})
+}
+
+// UnhideQuestion menampilkan kembali pertanyaan yang disembunyikan (ADMIN ONLY)
+func (h *ModerationHandler) UnhideQuestion(c *fiber.Ctx) error {
+	adminID, _ := c.Locals("user_id").(float64)
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid question ID",
+		})
+	}
+
+	pertanyaan, err := h.moderationService.UnhideQuestion(uint(id), uint(adminID))
+	if err != nil {
+		return moderationError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Question unhidden successfully",
+		"data":    pertanyaan,
+	})
+}
+
+func moderationError(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "produk tidak ditemukan", "pertanyaan tidak ditemukan":
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	case "produk sudah disetujui", "produk sudah ditolak", "pertanyaan sudah disembunyikan", "pertanyaan tidak disembunyikan":
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	case "alasan penolakan wajib diisi", "alasan penolakan maksimal 255 karakter", "alasan wajib diisi", "alasan maksimal 255 karakter":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"evernos-api2/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotifikasiHandler struct {
+	notifikasiService *services.NotifikasiService
+}
+
+func NewNotifikasiHandler(notifikasiService *services.NotifikasiService) *NotifikasiHandler {
+	return &NotifikasiHandler{notifikasiService: notifikasiService}
+}
+
+// GetNotifications mengambil notifikasi milik user (?unread=true untuk yang belum dibaca saja)
+func (h *NotifikasiHandler) GetNotifications(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	notifikasi, pagination, unread, err := h.notifikasiService.GetNotifications(uint(userID),
+		c.Query("limit"), c.Query("page"), c.Query("cursor"), c.Query("unread"))
+	if err != nil {
+		if err.Error() == "unread tidak valid" || err.Error() == "cursor tidak valid" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":      "Notifications retrieved successfully",
+		"data":         notifikasi,
+		"unread_count": unread,
+		"pagination":   pagination,
+	})
+}
+
+// MarkNotificationRead menandai notifikasi sudah dibaca
+func (h *NotifikasiHandler) MarkNotificationRead(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil || id == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid notification ID",
+		})
+	}
+
+	if err := h.notifikasiService.MarkRead(uint(userID), uint(id)); err != nil {
+		if err.Error() == "notifikasi tidak ditemukan" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Notification marked as read",
+	})
+}
+
+// MarkAllNotificationsRead menandai semua notifikasi user sudah dibaca
+func (h *NotifikasiHandler) MarkAllNotificationsRead(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	if err := h.notifikasiService.MarkRead(uint(userID), 0); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "All notifications marked as read",
+	})
+}
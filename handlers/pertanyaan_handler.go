@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"evernos-api2/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PertanyaanHandler struct {
+	pertanyaanService *services.PertanyaanService
+}
+
+func NewPertanyaanHandler(pertanyaanService *services.PertanyaanService) *PertanyaanHandler {
+	return &PertanyaanHandler{pertanyaanService: pertanyaanService}
+}
+
+// GetProductQuestions mengambil pertanyaan produk dengan pagination (?sort=newest|top)
+func (h *PertanyaanHandler) GetProductQuestions(c *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID produk tidak valid",
+		})
+	}
+
+	filters := map[string]string{
+		"limit":  c.Query("limit"),
+		"page":   c.Query("page"),
+		"cursor": c.Query("cursor"),
+		"sort":   c.Query("sort"),
+	}
+
+	pertanyaans, pagination, err := h.pertanyaanService.GetQuestions(uint(productID), viewerID(c), filters)
+	if err != nil {
+		switch err.Error() {
+		case "produk tidak ditemukan":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "sort tidak valid", "cursor tidak valid":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Berhasil mengambil pertanyaan produk",
+		"data":       pertanyaans,
+		"pagination": pagination,
+	})
+}
+
+// AskQuestion membuat pertanyaan publik untuk produk ({"pertanyaan": "..."})
+func (h *PertanyaanHandler) AskQuestion(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID produk tidak valid",
+		})
+	}
+
+	var body struct {
+		Pertanyaan string `json:"pertanyaan"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Format data tidak valid",
+		})
+	}
+
+	pertanyaan, err := h.pertanyaanService.AskQuestion(uint(productID), uint(userID), body.Pertanyaan)
+	if err != nil {
+		switch err.Error() {
+		case "produk tidak ditemukan":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "pertanyaan tidak boleh kosong", "pertanyaan maksimal 1000 karakter":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Berhasil mengirim pertanyaan",
+		"data":    pertanyaan,
+	})
+}
+
+// AnswerQuestion menyimpan jawaban pemilik toko ({"jawaban": "..."})
+func (h *PertanyaanHandler) AnswerQuestion(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID pertanyaan tidak valid",
+		})
+	}
+
+	var body struct {
+		Jawaban string `json:"jawaban"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Format data tidak valid",
+		})
+	}
+
+	pertanyaan, err := h.pertanyaanService.AnswerQuestion(uint(id), uint(userID), body.Jawaban)
+	if err != nil {
+		switch err.Error() {
+		case "pertanyaan tidak ditemukan":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "anda tidak memiliki akses untuk menjawab pertanyaan ini":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "jawaban tidak boleh kosong", "jawaban maksimal 2000 karakter":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Berhasil menjawab pertanyaan",
+		"data":    pertanyaan,
+	})
+}
+
+// UpvoteQuestion memberi upvote pada pertanyaan
+func (h *PertanyaanHandler) UpvoteQuestion(c *fiber.Ctx) error {
+	return h.setUpvote(c, true)
+}
+
+// RemoveUpvoteQuestion membatalkan upvote pada pertanyaan
+func (h *PertanyaanHandler) RemoveUpvoteQuestion(c *fiber.Ctx) error {
+	return h.setUpvote(c, false)
+}
+
+func (h *PertanyaanHandler) setUpvote(c *fiber.Ctx, upvote bool) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID pertanyaan tidak valid",
+		})
+	}
+
+	update := h.pertanyaanService.Upvote
+	if !upvote {
+		update = h.pertanyaanService.RemoveUpvote
+	}
+	pertanyaan, err := update(uint(id), uint(userID))
+	if err != nil {
+		if err.Error() == "pertanyaan tidak ditemukan" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Berhasil memperbarui upvote",
+		"data":    pertanyaan,
+	})
+}
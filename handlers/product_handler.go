@@ -2,27 +2,26 @@ package handlers
 
 import (
 	"evernos-api2/services"
-	"fmt"
-	"path/filepath"
+	"net/url"
+	"os"
 	"strconv"
-	"strings"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
 type ProductHandler struct {
 	productService    *services.ProductService
 	fotoProdukService *services.FotoProdukService
 	tokoService       *services.TokoService
+	uploadService     *services.UploadService
 }
 
-func NewProductHandler(productService *services.ProductService, fotoProdukService *services.FotoProdukService, tokoService *services.TokoService) *ProductHandler {
+func NewProductHandler(productService *services.ProductService, fotoProdukService *services.FotoProdukService, tokoService *services.TokoService, uploadService *services.UploadService) *ProductHandler {
 	return &ProductHandler{
 		productService:    productService,
 		fotoProdukService: fotoProdukService,
 		tokoService:       tokoService,
+		uploadService:     uploadService,
 	}
 }
 
@@ -30,17 +29,32 @@ func NewProductHandler(productService *services.ProductService, fotoProdukServic
 func (h *ProductHandler) GetAllProducts(c *fiber.Ctx) error {
 	// Ambil query parameters
 	filters := map[string]string{
+		"q":           c.Query("q"),
 		"nama_produk": c.Query("nama_produk"),
 		"limit":       c.Query("limit"),
 		"page":        c.Query("page"),
+		"cursor":      c.Query("cursor"),
+		"sort":        c.Query("sort"),
 		"category_id": c.Query("category_id"),
 		"toko_id":     c.Query("toko_id"),
 		"max_harga":   c.Query("max_harga"),
 		"min_harga":   c.Query("min_harga"),
+		"in_stock":    c.Query("in_stock"),
+		"status":      c.Query("status"),
 	}
 
-	products, pagination, err := h.productService.GetAllProducts(filters)
+	products, pagination, facets, err := h.productService.GetAllProducts(filters, viewerID(c))
 	if err != nil {
+		if err.Error() == "sort tidak valid" || err.Error() == "cursor tidak valid" || err.Error() == "status tidak valid" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if err.Error() == "filter status hanya untuk pemilik toko" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -50,9 +64,16 @@ func (h *ProductHandler) GetAllProducts(c *fiber.Ctx) error {
 		"message":    "Berhasil mengambil data produk",
 		"data":       products,
 		"pagination": pagination,
+		"facets":     facets,
 	})
 }
 
+// viewerID mengambil ID user yang login dari middleware auth opsional (0 jika anonim)
+func viewerID(c *fiber.Ctx) uint {
+	userID, _ := c.Locals("user_id").(float64)
+	return uint(userID)
+}
+
 // GetProductByID mengambil produk berdasarkan ID
 func (h *ProductHandler) GetProductByID(c *fiber.Ctx) error {
 	idParam := c.Params("id")
@@ -63,12 +84,51 @@ func (h *ProductHandler) GetProductByID(c *fiber.Ctx) error {
 		})
 	}
 
-	product, err := h.productService.GetProductByID(uint(id))
+	product, err := h.productService.GetProductByID(uint(id), viewerID(c))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Berhasil mengambil data produk",
+		"data":    product,
+	})
+}
+
+// GetProductBySlug mengambil produk berdasarkan URL toko dan slug produk. Slug lama (sebelum produk
+// diganti nama) di-redirect permanen ke slug terbaru.
+func (h *ProductHandler) GetProductBySlug(c *fiber.Ctx) error {
+	urlToko, err := url.PathUnescape(c.Params("url_toko"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "URL toko tidak valid",
+		})
+	}
+	slug, err := url.PathUnescape(c.Params("slug"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "slug produk tidak valid",
+		})
+	}
+
+	toko, err := h.tokoService.GetTokoByUrl(urlToko)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	product, moved, err := h.productService.GetProductBySlug(toko.ID, slug, viewerID(c))
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
+	if moved {
+		return c.Redirect("/toko/"+url.PathEscape(toko.UrlToko)+"/product/"+product.Slug, fiber.StatusMovedPermanently)
+	}
 
 	return c.JSON(fiber.Map{
 		"message": "Berhasil mengambil data produk",
@@ -93,6 +153,9 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 	if namaProduk := c.FormValue("nama_produk"); namaProduk != "" {
 		productData["nama_produk"] = namaProduk
 	}
+	if sku := c.FormValue("sku"); sku != "" {
+		productData["sku"] = sku
+	}
 	if hargaReseller := c.FormValue("harga_reseller"); hargaReseller != "" {
 		productData["harga_reseller"] = hargaReseller
 	}
@@ -107,6 +170,12 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 	if deskripsi := c.FormValue("deskripsi"); deskripsi != "" {
 		productData["deskripsi"] = deskripsi
 	}
+	if status := c.FormValue("status"); status != "" {
+		productData["status"] = status
+	}
+	if publishAt := c.FormValue("publish_at"); publishAt != "" {
+		productData["publish_at"] = publishAt
+	}
 	if idCategoryStr := c.FormValue("id_category"); idCategoryStr != "" {
 		if idCategory, err := strconv.ParseFloat(idCategoryStr, 64); err == nil {
 			productData["id_category"] = idCategory
@@ -128,45 +197,19 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 	productData["id_toko"] = float64(tokoID)
 
 	// Handle foto upload (optional)
-	var photoFilename string
+	var photoPath string
 	file, err := c.FormFile("photo")
 	if err == nil && file != nil {
-		// Validasi ukuran file (maksimal 5MB)
-		if file.Size > 5*1024*1024 {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Ukuran file terlalu besar. Maksimal 5MB",
-			})
-		}
-
-		// Validasi tipe file
-		allowedTypes := []string{".jpg", ".jpeg", ".png", ".webp"}
-		fileExt := strings.ToLower(filepath.Ext(file.Filename))
-		isValidType := false
-		for _, allowedType := range allowedTypes {
-			if fileExt == allowedType {
-				isValidType = true
-				break
+		// Validasi isi file dan simpan file mentah ke staging (di luar /uploads) sebelum diproses
+		_, photoPath, err = h.uploadService.StageProductImage(file, uint(userID), 0)
+		if err != nil {
+			if err == services.ErrUploadSaveFailed {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Gagal menyimpan file foto",
+				})
 			}
-		}
-
-		if !isValidType {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Tipe file tidak didukung. Gunakan JPG, JPEG, PNG, atau WEBP",
-			})
-		}
-
-		// Generate nama file unik
-		timestamp := time.Now().Format("20060102_150405")
-		uniqueID := uuid.New().String()[:8]
-		photoFilename = fmt.Sprintf("product_%d_%s_%s%s", uint(userID), timestamp, uniqueID, fileExt)
-
-		// Path untuk menyimpan file
-		uploadPath := filepath.Join("uploads", "products", photoFilename)
-
-		// Simpan file
-		if err := c.SaveFile(file, uploadPath); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Gagal menyimpan file foto",
+				"error": err.Error(),
 			})
 		}
 	}
@@ -174,16 +217,19 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 	// Buat produk
 	product, err := h.productService.CreateProduct(uint(userID), productData)
 	if err != nil {
+		if photoPath != "" {
+			os.Remove(photoPath)
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	// Jika ada foto, simpan ke database
-	if photoFilename != "" {
-		photoURL := fmt.Sprintf("/uploads/products/%s", photoFilename)
-		_, err = h.fotoProdukService.AddPhotoToProduct(product.ID, uint(userID), photoURL)
+	// Jika ada foto, simpan ke database dan proses di background
+	if photoPath != "" {
+		_, err = h.fotoProdukService.AddPhotoToProduct(product.ID, uint(userID), photoPath)
 		if err != nil {
+			os.Remove(photoPath)
 			// Log error tapi jangan gagalkan pembuatan produk
 			// Product sudah berhasil dibuat, foto gagal disimpan
 		}
@@ -220,6 +266,9 @@ func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 	if namaProduk := c.FormValue("nama_produk"); namaProduk != "" {
 		updateData["nama_produk"] = namaProduk
 	}
+	if sku := c.FormValue("sku"); sku != "" {
+		updateData["sku"] = sku
+	}
 	if hargaReseller := c.FormValue("harga_reseller"); hargaReseller != "" {
 		updateData["harga_reseller"] = hargaReseller
 	}
@@ -234,6 +283,12 @@ func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 	if deskripsi := c.FormValue("deskripsi"); deskripsi != "" {
 		updateData["deskripsi"] = deskripsi
 	}
+	if status := c.FormValue("status"); status != "" {
+		updateData["status"] = status
+	}
+	if publishAt := c.FormValue("publish_at"); publishAt != "" {
+		updateData["publish_at"] = publishAt
+	}
 	if idCategoryStr := c.FormValue("id_category"); idCategoryStr != "" {
 		if idCategory, err := strconv.ParseFloat(idCategoryStr, 64); err == nil {
 			updateData["id_category"] = idCategory
@@ -241,45 +296,19 @@ func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 	}
 
 	// Handle foto upload (optional)
-	var photoFilename string
+	var photoPath string
 	file, err := c.FormFile("photo")
 	if err == nil && file != nil {
-		// Validasi ukuran file (maksimal 5MB)
-		if file.Size > 5*1024*1024 {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Ukuran file terlalu besar. Maksimal 5MB",
-			})
-		}
-
-		// Validasi tipe file
-		allowedTypes := []string{".jpg", ".jpeg", ".png", ".webp"}
-		fileExt := strings.ToLower(filepath.Ext(file.Filename))
-		isValidType := false
-		for _, allowedType := range allowedTypes {
-			if fileExt == allowedType {
-				isValidType = true
-				break
+		// Validasi isi file dan simpan file mentah ke staging (di luar /uploads) sebelum diproses
+		_, photoPath, err = h.uploadService.StageProductImage(file, uint(userID), 0)
+		if err != nil {
+			if err == services.ErrUploadSaveFailed {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Gagal menyimpan file foto",
+				})
 			}
-		}
-
-		if !isValidType {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Tipe file tidak didukung. Gunakan JPG, JPEG, PNG, atau WEBP",
-			})
-		}
-
-		// Generate nama file unik
-		timestamp := time.Now().Format("20060102_150405")
-		uniqueID := uuid.New().String()[:8]
-		photoFilename = fmt.Sprintf("product_%d_%s_%s%s", uint(userID), timestamp, uniqueID, fileExt)
-
-		// Path untuk menyimpan file
-		uploadPath := filepath.Join("uploads", "products", photoFilename)
-
-		// Simpan file
-		if err := c.SaveFile(file, uploadPath); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Gagal menyimpan file foto",
+				"error": err.Error(),
 			})
 		}
 	}
@@ -287,6 +316,9 @@ func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 	// Update produk
 	product, err := h.productService.UpdateProduct(uint(id), uint(userID), updateData)
 	if err != nil {
+		if photoPath != "" {
+			os.Remove(photoPath)
+		}
 		if err.Error() == "anda tidak memiliki akses untuk mengupdate produk ini" {
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"error": err.Error(),
@@ -297,11 +329,11 @@ func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
 		})
 	}
 
-	// Jika ada foto baru, simpan ke database
-	if photoFilename != "" {
-		photoURL := fmt.Sprintf("/uploads/products/%s", photoFilename)
-		_, err = h.fotoProdukService.AddPhotoToProduct(uint(id), uint(userID), photoURL)
+	// Jika ada foto baru, simpan ke database dan proses di background
+	if photoPath != "" {
+		_, err = h.fotoProdukService.AddPhotoToProduct(uint(id), uint(userID), photoPath)
 		if err != nil {
+			os.Remove(photoPath)
 			// Log error tapi jangan gagalkan update produk
 			// Product sudah berhasil diupdate, foto gagal disimpan
 		}
@@ -1,7 +1,12 @@
 package handlers
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
 	"evernos-api2/services"
+	"fmt"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -117,4 +122,154 @@ func (h *ProfileHandler) UpdateProfile(c *fiber.Ctx) error {
 			"updatedAt":    user.UpdatedAt,
 		},
 	})
-}
\ No newline at end of file
+}
+
+// ChangePassword mengganti password user yang sedang login dengan verifikasi password lama
+func (h *ProfileHandler) ChangePassword(c *fiber.Ctx) error {
+	// Ambil user_id dari middleware auth
+	userIDFloat, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+	sessionID, _ := c.Locals("session_id").(uint)
+
+	var data map[string]string
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+
+	if data["currentPassword"] == "" || data["newPassword"] == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Field currentPassword and newPassword are required",
+		})
+	}
+
+	err := h.profileService.ChangePassword(uint(userIDFloat), sessionID, data["currentPassword"], data["newPassword"])
+	if err != nil {
+		if err.Error() == "current password is incorrect" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Password updated successfully, other sessions have been logged out",
+	})
+}
+
+// ExportProfile mengekspor seluruh data pribadi user sebagai arsip ZIP (default) atau JSON (?format=json)
+func (h *ProfileHandler) ExportProfile(c *fiber.Ctx) error {
+	// Ambil user_id dari middleware auth
+	userIDFloat, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	export, err := h.profileService.ExportPersonalData(uint(userIDFloat))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	baseName := fmt.Sprintf("evernos-data-%d-%s", uint(userIDFloat), export.ExportedAt.Format("20060102"))
+
+	if c.Query("format") == "json" {
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.json"`, baseName))
+		return c.JSON(export)
+	}
+
+	// Satu file JSON per bagian data di dalam arsip ZIP
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"alamat.json", export.Alamat},
+		{"toko.json", export.Toko},
+		{"produk.json", export.Produk},
+		{"transaksi.json", export.Transaksi},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": "Failed to build export archive",
+			})
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": "Failed to build export archive",
+			})
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Failed to build export archive",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.zip"`, baseName))
+	return c.Send(buf.Bytes())
+}
+
+// DeleteAccount meminta penghapusan akun. Data dianonimkan setelah masa tenggang,
+// dan login kembali sebelum itu akan membatalkan permintaan.
+func (h *ProfileHandler) DeleteAccount(c *fiber.Ctx) error {
+	// Ambil user_id dari middleware auth
+	userIDFloat, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	var data map[string]string
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid request body",
+		})
+	}
+	if data["password"] == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Field password is required",
+		})
+	}
+
+	scheduledAt, err := h.profileService.RequestAccountDeletion(uint(userIDFloat), data["password"])
+	if err != nil {
+		if err.Error() == "password is incorrect" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message":                  "Account deletion requested. Log in again before the scheduled date to cancel",
+		"scheduledAnonymizationAt": scheduledAt.Format(time.RFC3339),
+	})
+}
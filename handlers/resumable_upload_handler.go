@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"evernos-api2/services"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const tusVersion = "1.0.0"
+
+// Status 460 dipakai ekstensi checksum tus untuk chunk yang checksum-nya tidak cocok
+const statusChecksumMismatch = 460
+
+type ResumableUploadHandler struct {
+	resumableUploadService *services.ResumableUploadService
+	basePath               string
+}
+
+func NewResumableUploadHandler(resumableUploadService *services.ResumableUploadService, basePath string) *ResumableUploadHandler {
+	return &ResumableUploadHandler{
+		resumableUploadService: resumableUploadService,
+		basePath:               basePath,
+	}
+}
+
+// Options mengembalikan kemampuan server tus (tanpa autentikasi)
+func (h *ResumableUploadHandler) Options(c *fiber.Ctx) error {
+	c.Set("Tus-Resumable", tusVersion)
+	c.Set("Tus-Version", tusVersion)
+	c.Set("Tus-Extension", "creation,expiration,checksum,termination")
+	c.Set("Tus-Max-Size", strconv.FormatInt(h.resumableUploadService.MaxSize(), 10))
+	c.Set("Tus-Checksum-Algorithm", strings.Join(services.SupportedChecksumAlgorithms, ","))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RequireTusResumable menolak request tus dengan versi protokol yang tidak didukung
+func (h *ResumableUploadHandler) RequireTusResumable(c *fiber.Ctx) error {
+	c.Set("Tus-Resumable", tusVersion)
+	if c.Get("Tus-Resumable") != tusVersion {
+		c.Set("Tus-Version", tusVersion)
+		return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
+			"error": "Tus-Resumable harus " + tusVersion,
+		})
+	}
+	return c.Next()
+}
+
+// Create membuat sesi upload baru. Metadata wajib: product_id dan filename.
+func (h *ResumableUploadHandler) Create(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	length, err := strconv.ParseInt(c.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Upload-Length harus disertakan",
+		})
+	}
+	if length > h.resumableUploadService.MaxSize() {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "Ukuran file melebihi Tus-Max-Size",
+		})
+	}
+
+	metadata, err := parseUploadMetadata(c.Get("Upload-Metadata"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Format Upload-Metadata tidak valid",
+		})
+	}
+
+	session, err := h.resumableUploadService.CreateSession(uint(userID), length, metadata)
+	if err != nil {
+		return h.sessionError(c, err)
+	}
+
+	c.Set("Location", h.basePath+"/"+session.ID)
+	c.Set("Upload-Expires", session.ExpiresAt.UTC().Format(time.RFC1123))
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Sesi upload berhasil dibuat",
+		"data":    session,
+	})
+}
+
+// Head mengembalikan offset terakhir agar client bisa melanjutkan upload
+func (h *ResumableUploadHandler) Head(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+
+	session, err := h.resumableUploadService.GetSession(c.Params("id"), uint(userID))
+	if err != nil {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	c.Set("Cache-Control", "no-store")
+	c.Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Set("Upload-Length", strconv.FormatInt(session.Length, 10))
+	c.Set("Upload-Expires", session.ExpiresAt.UTC().Format(time.RFC1123))
+	if session.IdFotoProduk != nil {
+		c.Set("X-Foto-Produk-Id", strconv.FormatUint(uint64(*session.IdFotoProduk), 10))
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// Patch menerima satu chunk data pada Upload-Offset
+func (h *ResumableUploadHandler) Patch(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	if c.Get("Content-Type") != "application/offset+octet-stream" {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": "Content-Type harus application/offset+octet-stream",
+		})
+	}
+
+	offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Upload-Offset harus disertakan",
+		})
+	}
+
+	session, fotoProduk, err := h.resumableUploadService.WriteChunk(c.Params("id"), uint(userID), offset, c.Body(), c.Get("Upload-Checksum"))
+	if err != nil {
+		return h.sessionError(c, err)
+	}
+
+	c.Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Set("Upload-Expires", session.ExpiresAt.UTC().Format(time.RFC1123))
+	if fotoProduk != nil {
+		c.Set("X-Foto-Produk-Id", strconv.FormatUint(uint64(fotoProduk.ID), 10))
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Delete membatalkan sesi upload
+func (h *ResumableUploadHandler) Delete(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	if err := h.resumableUploadService.Terminate(c.Params("id"), uint(userID)); err != nil {
+		return h.sessionError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// sessionError memetakan error resumable upload ke status HTTP sesuai spesifikasi tus
+func (h *ResumableUploadHandler) sessionError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case err == services.ErrUploadSessionNotFound:
+		status = fiber.StatusNotFound
+	case err == services.ErrUploadOffsetMismatch, err == services.ErrUploadAlreadyComplete:
+		status = fiber.StatusConflict
+	case err == services.ErrUploadChecksum:
+		status = statusChecksumMismatch
+	case err == services.ErrUploadExceedsLength:
+		status = fiber.StatusRequestEntityTooLarge
+	case err == services.ErrImageQueueFull:
+		status = fiber.StatusServiceUnavailable
+	case strings.Contains(err.Error(), "tidak memiliki akses"):
+		status = fiber.StatusForbidden
+	case strings.HasPrefix(err.Error(), "gagal"):
+		status = fiber.StatusInternalServerError
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// parseUploadMetadata mem-parsing header Upload-Metadata: "key base64value,key2 base64value"
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), " ", 2)
+		if parts[0] == "" {
+			continue
+		}
+		if len(parts) == 1 {
+			metadata[parts[0]] = ""
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		metadata[parts[0]] = string(value)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"evernos-api2/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SessionHandler struct {
+	sessionService *services.SessionService
+}
+
+func NewSessionHandler(sessionService *services.SessionService) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService}
+}
+
+// GetSessions mengambil semua sesi login aktif milik user
+func (h *SessionHandler) GetSessions(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+	currentSessionID, _ := c.Locals("session_id").(uint)
+
+	sessions, err := h.sessionService.GetUserSessions(uint(userID))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	data := make([]fiber.Map, 0, len(sessions))
+	for _, session := range sessions {
+		data = append(data, fiber.Map{
+			"id":           session.ID,
+			"user_agent":   session.UserAgent,
+			"ip_address":   session.IpAddress,
+			"created_at":   session.CreatedAt,
+			"last_seen_at": session.LastSeenAt,
+			"expires_at":   session.ExpiresAt,
+			"current":      session.ID == currentSessionID,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Sessions retrieved successfully",
+		"data":    data,
+	})
+}
+
+// RevokeSession mencabut sesi login tertentu (logout dari perangkat lain)
+func (h *SessionHandler) RevokeSession(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Unauthorized",
+		})
+	}
+
+	idParam := c.Params("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid session ID",
+		})
+	}
+
+	if err := h.sessionService.RevokeSession(uint(id), uint(userID)); err != nil {
+		if err.Error() == "sesi tidak ditemukan" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Session revoked successfully",
+	})
+}
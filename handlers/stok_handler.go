@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"evernos-api2/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StokHandler struct {
+	stokService *services.StokService
+}
+
+func NewStokHandler(stokService *services.StokService) *StokHandler {
+	return &StokHandler{stokService: stokService}
+}
+
+// GetStockHistory mengambil riwayat mutasi stok produk untuk pemilik toko
+func (h *StokHandler) GetStockHistory(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID produk tidak valid",
+		})
+	}
+
+	mutasi, pagination, err := h.stokService.GetStockHistory(uint(id), uint(userID),
+		c.Query("limit"), c.Query("page"), c.Query("cursor"), c.Query("variant_id"), c.Query("jenis"))
+	if err != nil {
+		switch err.Error() {
+		case "produk tidak ditemukan":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "anda tidak memiliki akses untuk melihat riwayat stok produk ini":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "variant_id tidak valid", "jenis mutasi tidak valid", "cursor tidak valid":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Berhasil mengambil riwayat stok",
+		"data":       mutasi,
+		"pagination": pagination,
+	})
+}
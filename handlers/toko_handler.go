@@ -146,10 +146,16 @@ func (h *TokoHandler) GetAllTokos(c *fiber.Ctx) error {
 	// Ambil query parameters
 	limit := c.Query("limit")
 	page := c.Query("page")
+	cursor := c.Query("cursor")
 	namaToko := c.Query("nama_toko")
 
-	tokos, pagination, err := h.tokoService.GetAllTokos(limit, page, namaToko)
+	tokos, pagination, err := h.tokoService.GetAllTokos(limit, page, cursor, namaToko)
 	if err != nil {
+		if err.Error() == "cursor tidak valid" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
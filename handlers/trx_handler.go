@@ -28,9 +28,15 @@ func (h *TrxHandler) GetAllTrx(c *fiber.Ctx) error {
 	// Ambil query parameters
 	limit := c.Query("limit")
 	page := c.Query("page")
+	cursor := c.Query("cursor")
 
-	trxs, pagination, err := h.trxService.GetAllTrx(uint(userID), limit, page)
+	trxs, pagination, err := h.trxService.GetAllTrx(uint(userID), limit, page, cursor)
 	if err != nil {
+		if err.Error() == "cursor tidak valid" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -43,6 +49,45 @@ func (h *TrxHandler) GetAllTrx(c *fiber.Ctx) error {
 	})
 }
 
+// GetTokoOrders mengambil pesanan yang masuk ke toko milik user
+func (h *TrxHandler) GetTokoOrders(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	// Ambil query parameters
+	limit := c.Query("limit")
+	page := c.Query("page")
+	cursor := c.Query("cursor")
+
+	trxs, pagination, err := h.trxService.GetTokoOrders(uint(userID), limit, page, cursor)
+	if err != nil {
+		if err.Error() == "user belum memiliki toko" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if err.Error() == "cursor tidak valid" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Berhasil mengambil data pesanan toko",
+		"data":       trxs,
+		"pagination": pagination,
+	})
+}
+
 // GetTrxByID mengambil transaksi berdasarkan ID
 func (h *TrxHandler) GetTrxByID(c *fiber.Ctx) error {
 	// Ambil userID dari context (dari middleware auth)
@@ -103,4 +148,95 @@ func (h *TrxHandler) CreateTrx(c *fiber.Ctx) error {
 		"message": "Berhasil membuat transaksi",
 		"data":    trx,
 	})
-}
\ No newline at end of file
+}
+
+// CancelTrx membatalkan transaksi milik user
+func (h *TrxHandler) CancelTrx(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID transaksi tidak valid",
+		})
+	}
+
+	trx, err := h.trxService.CancelTrx(uint(id), uint(userID))
+	if err != nil {
+		switch err.Error() {
+		case "transaksi tidak ditemukan":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "transaksi sudah dibatalkan":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Berhasil membatalkan transaksi",
+		"data":    trx,
+	})
+}
+
+// ReturnItem mencatat retur item pesanan yang masuk ke toko milik user
+func (h *TrxHandler) ReturnItem(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("detail_id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID item pesanan tidak valid",
+		})
+	}
+
+	var body struct {
+		Kuantitas int `json:"kuantitas"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Format data tidak valid",
+		})
+	}
+
+	if err := h.trxService.ReturnItem(uint(id), uint(userID), body.Kuantitas); err != nil {
+		switch err.Error() {
+		case "user belum memiliki toko", "item pesanan tidak ditemukan":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "transaksi sudah dibatalkan":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "gagal mencatat retur":
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Berhasil mencatat retur",
+	})
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"evernos-api2/services"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UlasanHandler struct {
+	ulasanService *services.UlasanService
+	uploadService *services.UploadService
+}
+
+func NewUlasanHandler(ulasanService *services.UlasanService, uploadService *services.UploadService) *UlasanHandler {
+	return &UlasanHandler{
+		ulasanService: ulasanService,
+		uploadService: uploadService,
+	}
+}
+
+// GetProductReviews mengambil ulasan produk dengan pagination beserta ringkasan rating
+func (h *UlasanHandler) GetProductReviews(c *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID produk tidak valid",
+		})
+	}
+
+	filters := map[string]string{
+		"limit":  c.Query("limit"),
+		"page":   c.Query("page"),
+		"cursor": c.Query("cursor"),
+		"sort":   c.Query("sort"),
+		"rating": c.Query("rating"),
+	}
+
+	ulasans, pagination, ringkasan, err := h.ulasanService.GetReviews(uint(productID), viewerID(c), filters)
+	if err != nil {
+		switch err.Error() {
+		case "produk tidak ditemukan":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "sort tidak valid", "rating tidak valid", "cursor tidak valid":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Berhasil mengambil ulasan produk",
+		"data":       ulasans,
+		"summary":    ringkasan,
+		"pagination": pagination,
+	})
+}
+
+// CreateReview membuat ulasan produk oleh pembeli. Menerima JSON atau multipart form
+// (rating, komentar, detail_trx_id opsional, dan maksimal 5 foto di field "photos").
+func (h *UlasanHandler) CreateReview(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID produk tidak valid",
+		})
+	}
+
+	reviewData := make(map[string]interface{})
+	var photoPaths []string
+	if strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEMultipartForm) {
+		form, err := c.MultipartForm()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Gagal parsing form data",
+			})
+		}
+		for _, field := range []string{"rating", "detail_trx_id"} {
+			if value := c.FormValue(field); value != "" {
+				if number, err := strconv.ParseFloat(value, 64); err == nil {
+					reviewData[field] = number
+				} else {
+					reviewData[field] = value
+				}
+			}
+		}
+		reviewData["komentar"] = c.FormValue("komentar")
+
+		files := form.File["photos"]
+		if len(files) > services.MaxFotoUlasan {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Maksimal " + strconv.Itoa(services.MaxFotoUlasan) + " foto per ulasan",
+			})
+		}
+		for i, file := range files {
+			// Validasi isi file dan simpan file mentah ke staging (di luar /uploads) sebelum diproses
+			_, stagingPath, err := h.uploadService.StageProductImage(file, uint(userID), i+1)
+			if err != nil {
+				for _, path := range photoPaths {
+					os.Remove(path)
+				}
+				if err == services.ErrUploadSaveFailed {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"error": "Gagal menyimpan file foto",
+					})
+				}
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Foto " + strconv.Itoa(i+1) + ": " + err.Error(),
+				})
+			}
+			photoPaths = append(photoPaths, stagingPath)
+		}
+	} else if err := c.BodyParser(&reviewData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Format data tidak valid",
+		})
+	}
+
+	ulasan, err := h.ulasanService.CreateReview(uint(userID), uint(productID), reviewData, photoPaths)
+	if err != nil {
+		switch err.Error() {
+		case "produk tidak ditemukan", "pembelian produk tidak ditemukan":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "anda belum pernah membeli produk ini":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "pembelian ini sudah diulas", "semua pembelian produk ini sudah diulas":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "gagal menyimpan ulasan", "gagal menyimpan foto", "gagal mengecek ulasan", "gagal mengecek pembelian":
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Berhasil membuat ulasan",
+		"data":    ulasan,
+	})
+}
+
+// ReplyReview menyimpan balasan penjual untuk ulasan produk tokonya
+func (h *UlasanHandler) ReplyReview(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID ulasan tidak valid",
+		})
+	}
+
+	var body struct {
+		Balasan string `json:"balasan"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Format data tidak valid",
+		})
+	}
+
+	ulasan, err := h.ulasanService.ReplyReview(uint(id), uint(userID), body.Balasan)
+	if err != nil {
+		switch err.Error() {
+		case "ulasan tidak ditemukan":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "anda tidak memiliki akses untuk membalas ulasan ini":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "balasan tidak boleh kosong", "balasan maksimal 2000 karakter":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Berhasil membalas ulasan",
+		"data":    ulasan,
+	})
+}
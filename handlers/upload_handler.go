@@ -3,21 +3,25 @@ package handlers
 import (
 	"evernos-api2/services"
 	"fmt"
-	"path/filepath"
+	"os"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
 type UploadHandler struct {
 	fotoProdukService *services.FotoProdukService
+	productService    *services.ProductService
+	uploadService     *services.UploadService
 }
 
-func NewUploadHandler(fotoProdukService *services.FotoProdukService) *UploadHandler {
-	return &UploadHandler{fotoProdukService: fotoProdukService}
+func NewUploadHandler(fotoProdukService *services.FotoProdukService, productService *services.ProductService, uploadService *services.UploadService) *UploadHandler {
+	return &UploadHandler{
+		fotoProdukService: fotoProdukService,
+		productService:    productService,
+		uploadService:     uploadService,
+	}
 }
 
 
@@ -55,60 +59,36 @@ func (h *UploadHandler) UploadAndAssignToProduct(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validasi ukuran file (maksimal 5MB)
-	if file.Size > 5*1024*1024 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Ukuran file terlalu besar. Maksimal 5MB",
-		})
-	}
-
-	// Validasi tipe file
-	allowedTypes := []string{".jpg", ".jpeg", ".png", ".webp"}
-	fileExt := strings.ToLower(filepath.Ext(file.Filename))
-	isValidType := false
-	for _, allowedType := range allowedTypes {
-		if fileExt == allowedType {
-			isValidType = true
-			break
+	// Validasi isi file dan simpan file mentah ke staging (di luar /uploads) sebelum diproses
+	fileName, stagingPath, err := h.uploadService.StageProductImage(file, uint(userID), 0)
+	if err != nil {
+		if err == services.ErrUploadSaveFailed {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
 		}
-	}
-
-	if !isValidType {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Tipe file tidak didukung. Gunakan JPG, JPEG, PNG, atau WEBP",
-		})
-	}
-
-	// Generate nama file unik
-	timestamp := time.Now().Format("20060102_150405")
-	uniqueID := uuid.New().String()[:8]
-	fileName := fmt.Sprintf("product_%d_%s_%s%s", uint(userID), timestamp, uniqueID, fileExt)
-
-	// Path untuk menyimpan file
-	uploadPath := filepath.Join("uploads", "products", fileName)
-
-	// Simpan file
-	if err := c.SaveFile(file, uploadPath); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal menyimpan file",
+			"error": err.Error(),
 		})
 	}
 
-	// URL file yang bisa diakses
-	fileURL := fmt.Sprintf("/uploads/products/%s", fileName)
-
-	// Assign foto ke produk melalui service
-	fotoProduk, err := h.fotoProdukService.AddPhotoToProduct(uint(productID), uint(userID), fileURL)
+	// Assign foto ke produk melalui service, rendisi dibuat di background
+	fotoProduk, err := h.fotoProdukService.AddPhotoToProduct(uint(productID), uint(userID), stagingPath)
 	if err != nil {
+		os.Remove(stagingPath)
+		if err == services.ErrImageQueueFull {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message":     "Foto berhasil diupload dan ditambahkan ke produk",
+		"message":     "Foto berhasil diupload dan sedang diproses",
 		"filename":    fileName,
-		"url":         fileURL,
 		"size":        file.Size,
 		"foto_produk": fotoProduk,
 	})
@@ -162,66 +142,49 @@ func (h *UploadHandler) UploadMultipleAndAssignToProduct(c *fiber.Ctx) error {
 	}
 
 	var uploadedFiles []map[string]interface{}
-	var photoURLs []string
+	var stagingPaths []string
 	var errors []string
 
 	for i, file := range files {
-		// Validasi ukuran file (maksimal 5MB)
-		if file.Size > 5*1024*1024 {
-			errors = append(errors, fmt.Sprintf("File %d: Ukuran terlalu besar (maksimal 5MB)", i+1))
-			continue
-		}
-
-		// Validasi tipe file
-		allowedTypes := []string{".jpg", ".jpeg", ".png", ".webp"}
-		fileExt := strings.ToLower(filepath.Ext(file.Filename))
-		isValidType := false
-		for _, allowedType := range allowedTypes {
-			if fileExt == allowedType {
-				isValidType = true
-				break
-			}
-		}
-
-		if !isValidType {
-			errors = append(errors, fmt.Sprintf("File %d: Tipe file tidak didukung", i+1))
-			continue
-		}
-
-		// Generate nama file unik
-		timestamp := time.Now().Format("20060102_150405")
-		uniqueID := uuid.New().String()[:8]
-		fileName := fmt.Sprintf("product_%d_%s_%s_%d%s", uint(userID), timestamp, uniqueID, i+1, fileExt)
-
-		// Path untuk menyimpan file
-		uploadPath := filepath.Join("uploads", "products", fileName)
-
-		// Simpan file
-		if err := c.SaveFile(file, uploadPath); err != nil {
-			errors = append(errors, fmt.Sprintf("File %d: Gagal menyimpan file", i+1))
+		// Validasi isi file dan simpan file mentah ke staging (di luar /uploads) sebelum diproses
+		fileName, stagingPath, err := h.uploadService.StageProductImage(file, uint(userID), i+1)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("File %d: %s", i+1, err.Error()))
 			continue
 		}
 
 		// Tambahkan ke list file yang berhasil diupload
-		fileURL := fmt.Sprintf("/uploads/products/%s", fileName)
-		photoURLs = append(photoURLs, fileURL)
+		stagingPaths = append(stagingPaths, stagingPath)
 		uploadedFiles = append(uploadedFiles, map[string]interface{}{
 			"filename": fileName,
-			"url":      fileURL,
 			"size":     file.Size,
 		})
 	}
 
 	// Assign semua foto ke produk jika ada yang berhasil diupload
 	var fotoProduks []interface{}
-	if len(photoURLs) > 0 {
-		fotoProduks_result, err := h.fotoProdukService.AddMultiplePhotosToProduct(uint(productID), uint(userID), photoURLs)
+	if len(stagingPaths) > 0 {
+		fotoProduks_result, err := h.fotoProdukService.AddMultiplePhotosToProduct(uint(productID), uint(userID), stagingPaths)
 		if err != nil {
+			for _, path := range stagingPaths {
+				os.Remove(path)
+			}
+			if err == services.ErrImageQueueFull {
+				return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": err.Error(),
 			})
 		}
-		
+
+		// Foto yang tidak masuk antrian karena server sibuk dihitung sebagai gagal
+		for i := len(fotoProduks_result); i < len(uploadedFiles); i++ {
+			errors = append(errors, fmt.Sprintf("%s: %s", uploadedFiles[i]["filename"], services.ErrImageQueueFull.Error()))
+		}
+		uploadedFiles = uploadedFiles[:len(fotoProduks_result)]
+
 		for _, fp := range fotoProduks_result {
 			fotoProduks = append(fotoProduks, fp)
 		}
@@ -301,6 +264,13 @@ func (h *UploadHandler) GetProductPhotos(c *fiber.Ctx) error {
 		})
 	}
 
+	// Produk yang belum tampil untuk publik hanya bisa dilihat pemiliknya
+	if _, err := h.productService.GetProductByID(uint(productID), viewerID(c)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	// Ambil foto-foto produk menggunakan service
 	photos, err := h.fotoProdukService.GetPhotosByProductID(uint(productID))
 	if err != nil {
@@ -313,4 +283,121 @@ func (h *UploadHandler) GetProductPhotos(c *fiber.Ctx) error {
 		"message": "Foto produk berhasil diambil",
 		"data":    photos,
 	})
-}
\ No newline at end of file
+}
+// ReorderProductPhotos mengatur ulang urutan semua foto produk dalam satu request
+func (h *UploadHandler) ReorderProductPhotos(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID produk tidak valid",
+		})
+	}
+
+	var body struct {
+		FotoIDs []uint `json:"foto_ids"`
+	}
+	if err := c.BodyParser(&body); err != nil || len(body.FotoIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "foto_ids harus berupa array ID foto",
+		})
+	}
+
+	photos, err := h.fotoProdukService.ReorderPhotos(uint(productID), uint(userID), body.FotoIDs)
+	if err != nil {
+		return h.photoError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Urutan foto berhasil diperbarui",
+		"data":    photos,
+	})
+}
+
+// SetPrimaryPhoto menjadikan foto sebagai foto utama (cover) produk
+func (h *UploadHandler) SetPrimaryPhoto(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	fotoID, err := strconv.ParseUint(c.Params("foto_id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID foto tidak valid",
+		})
+	}
+
+	photo, err := h.fotoProdukService.SetPrimaryPhoto(uint(fotoID), uint(userID))
+	if err != nil {
+		return h.photoError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Foto utama berhasil diperbarui",
+		"data":    photo,
+	})
+}
+
+// UpdatePhotoAltText memperbarui teks alternatif foto
+func (h *UploadHandler) UpdatePhotoAltText(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	fotoID, err := strconv.ParseUint(c.Params("foto_id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID foto tidak valid",
+		})
+	}
+
+	var body struct {
+		AltText *string `json:"alt_text"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.AltText == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "alt_text harus disertakan",
+		})
+	}
+
+	photo, err := h.fotoProdukService.UpdatePhotoAltText(uint(fotoID), uint(userID), *body.AltText)
+	if err != nil {
+		return h.photoError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Alt text foto berhasil diperbarui",
+		"data":    photo,
+	})
+}
+
+// photoError memetakan error service foto ke status HTTP
+func (h *UploadHandler) photoError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	switch {
+	case strings.Contains(err.Error(), "tidak ditemukan"):
+		status = fiber.StatusNotFound
+	case strings.Contains(err.Error(), "tidak memiliki akses"):
+		status = fiber.StatusForbidden
+	case strings.HasPrefix(err.Error(), "gagal"):
+		status = fiber.StatusInternalServerError
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
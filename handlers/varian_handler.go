@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"evernos-api2/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type VarianHandler struct {
+	varianService *services.VarianService
+}
+
+func NewVarianHandler(varianService *services.VarianService) *VarianHandler {
+	return &VarianHandler{varianService: varianService}
+}
+
+// GetVariants mengambil opsi dan varian produk
+func (h *VarianHandler) GetVariants(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID produk tidak valid",
+		})
+	}
+
+	opsi, varian, err := h.varianService.GetVariants(uint(id), viewerID(c))
+	if err != nil {
+		if err.Error() == "produk tidak ditemukan" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Berhasil mengambil varian produk",
+		"data": fiber.Map{
+			"opsi":   opsi,
+			"varian": varian,
+		},
+	})
+}
+
+// SetVariants mengganti seluruh opsi dan varian produk
+func (h *VarianHandler) SetVariants(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID produk tidak valid",
+		})
+	}
+
+	var data map[string]interface{}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Format data tidak valid",
+		})
+	}
+
+	opsi, varian, err := h.varianService.SetVariants(uint(id), uint(userID), data)
+	if err != nil {
+		switch err.Error() {
+		case "produk tidak ditemukan":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "anda tidak memiliki akses untuk mengubah varian produk ini":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "gagal mengecek kepemilikan produk", "gagal mengecek SKU", "gagal menyimpan varian produk":
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Berhasil menyimpan varian produk",
+		"data": fiber.Map{
+			"opsi":   opsi,
+			"varian": varian,
+		},
+	})
+}
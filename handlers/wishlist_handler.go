@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"evernos-api2/services"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WishlistHandler struct {
+	wishlistService *services.WishlistService
+}
+
+func NewWishlistHandler(wishlistService *services.WishlistService) *WishlistHandler {
+	return &WishlistHandler{wishlistService: wishlistService}
+}
+
+// GetWishlist mengambil wishlist user dengan pagination
+func (h *WishlistHandler) GetWishlist(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	wishlists, pagination, err := h.wishlistService.GetWishlist(uint(userID), c.Query("limit"), c.Query("page"), c.Query("cursor"))
+	if err != nil {
+		if err.Error() == "cursor tidak valid" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Berhasil mengambil wishlist",
+		"data":       wishlists,
+		"pagination": pagination,
+	})
+}
+
+// AddToWishlist menambahkan produk ke wishlist ({"product_id": 1})
+func (h *WishlistHandler) AddToWishlist(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	var body struct {
+		ProductID uint `json:"product_id"`
+	}
+	if err := c.BodyParser(&body); err != nil || body.ProductID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "product_id tidak valid",
+		})
+	}
+
+	if err := h.wishlistService.AddToWishlist(uint(userID), body.ProductID); err != nil {
+		if err.Error() == "produk tidak ditemukan" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Berhasil menambahkan produk ke wishlist",
+	})
+}
+
+// RemoveFromWishlist menghapus produk dari wishlist
+func (h *WishlistHandler) RemoveFromWishlist(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	productID, err := strconv.ParseUint(c.Params("product_id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID produk tidak valid",
+		})
+	}
+
+	if err := h.wishlistService.RemoveFromWishlist(uint(userID), uint(productID)); err != nil {
+		if err.Error() == "produk tidak ada di wishlist" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Berhasil menghapus produk dari wishlist",
+	})
+}
+
+// SubscribeBackInStock mendaftarkan user untuk notifikasi saat produk yang habis tersedia kembali
+func (h *WishlistHandler) SubscribeBackInStock(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID produk tidak valid",
+		})
+	}
+
+	if err := h.wishlistService.SubscribeBackInStock(uint(userID), uint(productID)); err != nil {
+		switch err.Error() {
+		case "produk tidak ditemukan":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case "stok produk masih tersedia":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Anda akan diberi tahu saat produk tersedia kembali",
+	})
+}
+
+// UnsubscribeBackInStock membatalkan langganan notifikasi stok produk
+func (h *WishlistHandler) UnsubscribeBackInStock(c *fiber.Ctx) error {
+	// Ambil userID dari context (dari middleware auth)
+	userID, ok := c.Locals("user_id").(float64)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak terautentikasi",
+		})
+	}
+
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ID produk tidak valid",
+		})
+	}
+
+	if err := h.wishlistService.UnsubscribeBackInStock(uint(userID), uint(productID)); err != nil {
+		if err.Error() == "anda tidak berlangganan stok produk ini" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Berhasil membatalkan langganan stok",
+	})
+}
@@ -6,6 +6,11 @@ import (
 	"log"
 	"evernos-api2/database"
 	"evernos-api2/routes"
+	"evernos-api2/services"
+	"evernos-api2/storage"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
@@ -18,8 +23,34 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
-	// Inisialisasi aplikasi Fiber
-	app := fiber.New()
+	// Muat key JWT (HS256 / RS256 / EdDSA)
+	if err := services.LoadJWTKeys(); err != nil {
+		log.Fatal("Failed to load JWT keys: ", err)
+	}
+
+	// Reload key JWT saat menerima SIGHUP (untuk rotasi key tanpa restart)
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := services.LoadJWTKeys(); err != nil {
+				log.Println("Failed to reload JWT keys:", err)
+				continue
+			}
+			log.Println("🔑 JWT keys reloaded")
+		}
+	}()
+
+	// Inisialisasi storage file upload (local / S3-compatible)
+	if err := storage.Init(); err != nil {
+		log.Fatal("Failed to initialize storage: ", err)
+	}
+
+	// Inisialisasi aplikasi Fiber. BodyLimit menampung upload multiple (5 x 5MB)
+	// dan chunk resumable upload hingga 30MB.
+	app := fiber.New(fiber.Config{
+		BodyLimit: 30 * 1024 * 1024,
+	})
 
 	// Hubungkan & migrasi database
 	database.ConnectDB()
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"evernos-api2/services"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuthOrApiKeyMiddleware menerima Bearer JWT atau API key toko (header "X-API-Key" atau
+// "Authorization: ApiKey <key>"). API key hanya lolos jika memiliki scope yang diminta,
+// sedangkan JWT user tetap diproses oleh AuthMiddleware seperti biasa.
+func AuthOrApiKeyMiddleware(apiKeyService *services.ApiKeyService, scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if status, message := authenticate(c, apiKeyService, scope); status != 0 {
+			return c.Status(status).JSON(fiber.Map{"message": message})
+		}
+		return c.Next()
+	}
+}
+
+// OptionalAuthMiddleware seperti AuthOrApiKeyMiddleware, tetapi request tanpa kredensial atau
+// dengan kredensial yang tidak valid (token kedaluwarsa, sesi dicabut, API key tanpa scope) tetap
+// diteruskan sebagai anonim (user_id tidak diisi). Dipakai di endpoint publik yang menampilkan
+// data tambahan untuk pemilik, misalnya produk draft.
+func OptionalAuthMiddleware(apiKeyService *services.ApiKeyService, scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get("Authorization") != "" || c.Get("X-API-Key") != "" {
+			authenticate(c, apiKeyService, scope)
+		}
+		return c.Next()
+	}
+}
+
+// authenticate mengautentikasi request lewat API key atau JWT dan mengisi user_id di context.
+// Jika gagal, status HTTP dan pesan error dikembalikan dan context tidak diubah.
+func authenticate(c *fiber.Ctx, apiKeyService *services.ApiKeyService, scope string) (int, string) {
+	rawKey := c.Get("X-API-Key")
+	if rawKey == "" {
+		parts := strings.Split(c.Get("Authorization"), " ")
+		if len(parts) == 2 && parts[0] == "ApiKey" {
+			rawKey = parts[1]
+		}
+	}
+
+	// Tidak ada API key, lanjut ke autentikasi JWT
+	if rawKey == "" {
+		if message := authenticateJWT(c); message != "" {
+			return fiber.StatusUnauthorized, message
+		}
+		return 0, ""
+	}
+
+	apiKey, toko, err := apiKeyService.Authenticate(rawKey)
+	if err != nil {
+		return fiber.StatusUnauthorized, "Invalid API key"
+	}
+
+	if !apiKeyService.HasScope(apiKey, scope) {
+		return fiber.StatusForbidden, "API key does not have scope " + scope
+	}
+
+	// API key bertindak atas nama pemilik toko. user_id disimpan sebagai float64
+	// agar konsisten dengan claims JWT yang dibaca oleh handler.
+	c.Locals("user_id", float64(toko.IdUser))
+	c.Locals("is_admin", false)
+	c.Locals("api_key_id", apiKey.ID)
+	c.Locals("toko_id", toko.ID)
+	return 0, ""
+}
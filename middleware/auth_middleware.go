@@ -3,52 +3,73 @@
 package middleware
 
 import (
-	"os"
+	"evernos-api2/database"
+	"evernos-api2/repositories"
+	"evernos-api2/services"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 func AuthMiddleware(c *fiber.Ctx) error {
+	if message := authenticateJWT(c); message != "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": message})
+	}
+
+	// Lanjutkan ke handler/middleware selanjutnya
+	return c.Next()
+}
+
+// authenticateJWT memvalidasi Bearer token lalu menyimpan informasi user ke context.
+// Jika gagal, pesan error dikembalikan dan context tidak diubah.
+func authenticateJWT(c *fiber.Ctx) string {
 	// Ambil header Authorization
 	authHeader := c.Get("Authorization")
 	if authHeader == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Missing authorization header"})
+		return "Missing authorization header"
 	}
 
 	// Pisahkan "Bearer" dengan tokennya
 	parts := strings.Split(authHeader, " ")
 	if len(parts) != 2 || parts[0] != "Bearer" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid authorization header format"})
+		return "Invalid authorization header format"
 	}
 	
 	tokenString := parts[1]
 
-	// Parse dan validasi token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fiber.NewError(fiber.StatusUnauthorized, "Unexpected signing method")
-		}
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
-
-	if err != nil || !token.Valid {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid or expired token"})
+	// Parse dan validasi token (HS256 fallback, RS256, atau EdDSA berdasarkan kid)
+	claims, err := services.ParseToken(tokenString)
+	if err != nil {
+		return "Invalid or expired token"
 	}
 
-	// Ambil claims dari token
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Invalid token claims"})
+	// Pastikan sesi dari token belum dicabut. Token yang diterbitkan sebelum pencatatan sesi tidak
+	// punya claim sid dan tidak bisa dicabut; token tersebut hanya diterima jika diterbitkan sebelum
+	// LegacyTokenCutoff (dihitung dari exp dikurangi SessionTTL), sehingga paling lama berlaku satu
+	// masa token setelah deploy.
+	userID, _ := claims["user_id"].(float64)
+	sessionID, hasSession := claims["sid"].(float64)
+	if hasSession {
+		sessionService := services.NewSessionService(repositories.NewSessionRepository(database.DB))
+		if _, err := sessionService.ValidateSession(uint(sessionID), uint(userID)); err != nil {
+			return "Session has been revoked or expired"
+		}
+	} else {
+		exp, _ := claims["exp"].(float64)
+		issuedAt := time.Unix(int64(exp), 0).Add(-services.SessionTTL)
+		if !issuedAt.Before(database.LegacyTokenCutoff) {
+			return "Session has been revoked or expired"
+		}
 	}
 
 	// Simpan informasi user ke context untuk digunakan di handler selanjutnya
 	c.Locals("user_id", claims["user_id"])
 	c.Locals("is_admin", claims["is_admin"])
-
-	// Lanjutkan ke handler/middleware selanjutnya
-	return c.Next()
+	if hasSession {
+		c.Locals("session_id", uint(sessionID))
+	}
+	return ""
 }
 
 func AdminMiddleware(c *fiber.Ctx) error {
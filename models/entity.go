@@ -3,6 +3,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -26,6 +27,10 @@ type User struct {
 	Alamat       []Alamat `gorm:"foreignKey:IdUser"`
 	Toko         Toko     `gorm:"foreignKey:IdUser"`
 	Trx          []Trx    `gorm:"foreignKey:IdUser"`
+
+	// DeletionRequestedAt diisi saat user meminta hapus akun; data dianonimkan setelah masa tenggang
+	DeletionRequestedAt *time.Time
+	AnonymizedAt        *time.Time
 }
 
 type Alamat struct {
@@ -42,55 +47,232 @@ type Alamat struct {
 type Toko struct {
 	ID        uint     `gorm:"primaryKey"`
 	IdUser    uint     `gorm:"unique"`
-	NamaToko  string   `gorm:"type:varchar(255)"`
+	NamaToko  string   `gorm:"type:varchar(255);index:idx_toko_nama_ft,class:FULLTEXT,option:WITH PARSER ngram"`
 	UrlToko   string   `gorm:"type:varchar(255)"`
 	Produk    []Produk `gorm:"foreignKey:IdToko"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
+
+	// Agregat ulasan semua produk toko, dihitung ulang setiap ada ulasan baru
+	Rating       float64 `gorm:"type:decimal(3,2);default:0"`
+	JumlahUlasan int     `gorm:"default:0"`
 }
 
 type Produk struct {
 	gorm.Model
-	IdToko        uint
-	NamaProduk    string `gorm:"type:varchar(255)"`
-	Slug          string `gorm:"type:varchar(255)"`
-	HargaReseller string `gorm:"type:varchar(255)"`
-	HargaKonsumen string `gorm:"type:varchar(255)"`
+	IdToko        uint   `gorm:"uniqueIndex:idx_produk_toko_slug"`
+	NamaProduk    string `gorm:"type:varchar(255);index:idx_produk_nama_ft,class:FULLTEXT,option:WITH PARSER ngram"`
+	Slug          string `gorm:"type:varchar(255);uniqueIndex:idx_produk_toko_slug"` // unik per toko, termasuk produk yang sudah dihapus
+	Sku           string `gorm:"type:varchar(100);index"`
+	HargaReseller Money  `gorm:"type:bigint"`
+	HargaKonsumen Money  `gorm:"type:bigint"`
 	Stok          int
-	Deskripsi     string `gorm:"type:text"`
+	Deskripsi     string `gorm:"type:text;index:idx_produk_deskripsi_ft,class:FULLTEXT,option:WITH PARSER ngram"`
 	IdCategory    uint
 	FotoProduk    []FotoProduk `gorm:"foreignKey:IdProduk"`
+
+	// Status publikasi. Produk published dengan PublishAt di masa depan (terjadwal) baru tampil setelah waktunya.
+	Status    string     `gorm:"type:varchar(20);default:published;index"`
+	PublishAt *time.Time `gorm:"index"`
+
+	// Moderasi admin untuk kategori dengan RequiresModeration; produk baru tampil setelah approved
+	ModerationStatus string `gorm:"type:varchar(20);default:approved;index"`
+	ModerationReason string `gorm:"type:varchar(255)"`
+	ModeratedAt      *time.Time
+	ModeratedBy      *uint
+
+	// Agregat ulasan pembeli, dihitung ulang setiap ada ulasan baru
+	Rating       float64 `gorm:"type:decimal(3,2);default:0;index"`
+	JumlahUlasan int     `gorm:"default:0"`
+
+	// Produk dengan varian: Stok adalah total stok semua varian dan harga adalah harga varian termurah
+	OpsiVarian   []OpsiVarian   `gorm:"foreignKey:IdProduk"`
+	VarianProduk []VarianProduk `gorm:"foreignKey:IdProduk"`
+
+	// Highlight berisi potongan teks dengan kata yang cocok ditandai <mark>, hanya diisi pada hasil pencarian
+	Highlight map[string]string `gorm:"-" json:"highlight,omitempty"`
 }
 
+// Status publikasi produk
+const (
+	ProdukStatusDraft     = "draft"
+	ProdukStatusPublished = "published"
+	ProdukStatusArchived  = "archived"
+)
+
+// Status moderasi produk
+const (
+	ModerasiPending  = "pending"
+	ModerasiApproved = "approved"
+	ModerasiRejected = "rejected"
+)
+
+// IsPublished mengecek apakah produk sudah tampil untuk publik dan bisa dibeli pada waktu now
+func (p *Produk) IsPublished(now time.Time) bool {
+	return p.Status == ProdukStatusPublished && (p.PublishAt == nil || !p.PublishAt.After(now)) &&
+		p.ModerationStatus == ModerasiApproved
+}
+
+// SlugProduk adalah slug lama produk setelah diganti nama. Slug lama tetap dipesan untuk produk
+// tersebut dan link lama di-redirect ke slug terbaru.
+type SlugProduk struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	IdToko    uint      `gorm:"uniqueIndex:idx_slug_produk_toko_slug" json:"id_toko"`
+	Slug      string    `gorm:"type:varchar(255);uniqueIndex:idx_slug_produk_toko_slug" json:"slug"`
+	IdProduk  uint      `gorm:"index" json:"id_produk"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Status pemrosesan foto produk
+const (
+	FotoStatusProcessing = "processing"
+	FotoStatusReady      = "ready"
+	FotoStatusFailed     = "failed"
+)
+
+// Jenis media produk
+const (
+	MediaTypeImage = "image"
+	MediaTypeVideo = "video"
+)
+
 type FotoProduk struct {
 	gorm.Model
-	IdProduk uint
-	Url      string `gorm:"type:varchar(255)"`
+	IdProduk     uint
+	Url          string `gorm:"type:varchar(255)"`
+	UrlMedium    string `gorm:"type:varchar(255)"`
+	UrlThumbnail string `gorm:"type:varchar(255)"`
+	Status       string `gorm:"type:varchar(20);default:ready"`
+	Urutan       int    `gorm:"default:0"`
+	IsPrimary    bool   `gorm:"default:false"`
+	AltText      string `gorm:"type:varchar(255)"`
+	MediaType    string `gorm:"type:varchar(20);default:image"`
+	IdBlob       *uint  `gorm:"index" json:"-"`
+
+	// Key file di storage. URL di atas diisi ulang dari key oleh service sebelum dikirim ke client
+	// agar mengikuti STORAGE_PUBLIC_URL terbaru atau presigned URL yang masih berlaku.
+	Key          string `gorm:"column:storage_key;type:varchar(255)" json:"-"`
+	KeyMedium    string `gorm:"type:varchar(255)" json:"-"`
+	KeyThumbnail string `gorm:"type:varchar(255)" json:"-"`
+}
+
+// StorageKeys mengembalikan semua key file milik foto. Foto lama (sebelum ada kolom key)
+// menyimpan URL "/uploads/<key>", sehingga key diturunkan dari URL tersebut.
+func (f *FotoProduk) StorageKeys() []string {
+	var keys []string
+	for _, pair := range [][2]string{{f.Key, f.Url}, {f.KeyMedium, f.UrlMedium}, {f.KeyThumbnail, f.UrlThumbnail}} {
+		if pair[0] != "" {
+			keys = append(keys, pair[0])
+		} else if key := LegacyUploadKey(pair[1]); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// LegacyUploadKey mengubah URL lama "/uploads/products/x.jpg" menjadi key "products/x.jpg"
+func LegacyUploadKey(url string) string {
+	if !strings.HasPrefix(url, "/uploads/") {
+		return ""
+	}
+	return strings.TrimPrefix(url, "/uploads/")
+}
+
+// OpsiVarian adalah dimensi varian produk (misalnya Ukuran atau Warna) beserta nilai yang tersedia
+type OpsiVarian struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	IdProduk  uint      `gorm:"index" json:"id_produk"`
+	Nama      string    `gorm:"type:varchar(50)" json:"nama"`
+	Nilai     []string  `gorm:"type:text;serializer:json" json:"nilai"`
+	Urutan    int       `json:"urutan"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// VarianProduk adalah satu kombinasi opsi varian (misalnya Ukuran M, Warna Merah) dengan SKU,
+// harga, dan stok sendiri. Varian dihapus secara soft delete agar detail transaksi lama tetap valid.
+type VarianProduk struct {
+	ID            uint              `gorm:"primaryKey" json:"id"`
+	IdProduk      uint              `gorm:"index" json:"id_produk"`
+	IdToko        uint              `gorm:"not null;default:0;uniqueIndex:idx_varian_toko_sku" json:"-"`
+	Sku           string            `gorm:"type:varchar(100);index" json:"sku"`
+	Opsi          map[string]string `gorm:"type:text;serializer:json" json:"opsi"`
+	HargaReseller Money             `gorm:"type:bigint" json:"harga_reseller"`
+	HargaKonsumen Money             `gorm:"type:bigint" json:"harga_konsumen"`
+	Stok          int               `json:"stok"`
+	IdFotoProduk  *uint             `json:"id_foto_produk"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt    `gorm:"index" json:"-"`
+
+	// SkuAktif sama dengan Sku selama varian aktif dan NULL setelah varian dihapus, sehingga
+	// unique index SKU per toko tidak terhalang varian yang sudah di-soft delete
+	SkuAktif *string `gorm:"type:varchar(100);uniqueIndex:idx_varian_toko_sku" json:"-"`
 }
 
 type Category struct {
 	gorm.Model
-	NamaCategory string   `gorm:"type:varchar(255)"`
+	NamaCategory string   `gorm:"type:varchar(255);index:idx_category_nama_ft,class:FULLTEXT,option:WITH PARSER ngram"`
 	Produk       []Produk `gorm:"foreignKey:IdCategory"`
+
+	// Produk baru atau yang diubah secara material di kategori ini harus disetujui admin sebelum tampil
+	RequiresModeration bool `gorm:"default:false"`
 }
 
 type Trx struct {
 	gorm.Model
 	IdUser           uint
 	AlamatPengiriman int
-	HargaTotal       int
+	HargaTotal       Money `gorm:"type:bigint"`
 	KodeInvoice      string      `gorm:"type:varchar(255)"`
 	MethodBayar      string      `gorm:"type:varchar(255)"`
+	Status           string      `gorm:"type:varchar(20);default:created"`
 	DetailTrx        []DetailTrx `gorm:"foreignKey:IdTrx"`
 }
 
+// Status transaksi
+const (
+	TrxStatusCreated   = "created"
+	TrxStatusCancelled = "cancelled"
+)
+
 type DetailTrx struct {
 	gorm.Model
 	IdTrx      uint
 	IdProduk   uint
+	IdVarian   *uint
 	Kuantitas  int
-	HargaTotal int
-	Produk     Produk `gorm:"foreignKey:IdProduk"`
+	// KuantitasRetur adalah jumlah item yang sudah diretur dan stoknya dikembalikan
+	KuantitasRetur int           `gorm:"default:0"`
+	HargaTotal Money `gorm:"type:bigint"`
+	Produk     Produk        `gorm:"foreignKey:IdProduk"`
+	Varian     *VarianProduk `gorm:"foreignKey:IdVarian"`
+}
+
+// Jenis mutasi stok
+const (
+	MutasiSale       = "sale"
+	MutasiCancel     = "cancel"
+	MutasiAdjustment = "adjustment"
+	MutasiImport     = "import"
+	MutasiReturn     = "return"
+)
+
+// MutasiStok adalah catatan perubahan stok yang hanya ditambah, tidak pernah diubah atau dihapus.
+// Stok produk sama dengan jumlah Jumlah semua mutasinya, dan stok varian sama dengan jumlah mutasi
+// yang IdVarian-nya varian tersebut. StokSetelah adalah stok varian (atau produk jika tanpa varian)
+// setelah mutasi. IdUser adalah pelaku perubahan (nil untuk proses sistem).
+type MutasiStok struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	IdProduk    uint      `gorm:"index" json:"id_produk"`
+	IdVarian    *uint     `gorm:"index" json:"id_varian"`
+	Jenis       string    `gorm:"type:varchar(20)" json:"jenis"`
+	Jumlah      int       `json:"jumlah"`
+	StokSetelah int       `json:"stok_setelah"`
+	IdUser      *uint     `json:"id_user"`
+	Referensi   string    `gorm:"type:varchar(100);index" json:"referensi"`
+	Catatan     string    `gorm:"type:varchar(255)" json:"catatan"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 type LogProduk struct {
@@ -100,11 +282,290 @@ type LogProduk struct {
 	IdCategory    uint
 	NamaProduk    string `gorm:"type:varchar(255)"`
 	Slug          string `gorm:"type:varchar(255)"`
-	HargaReseller string `gorm:"type:varchar(255)"`
-	HargaKonsumen string `gorm:"type:varchar(255)"`
+	HargaReseller Money  `gorm:"type:bigint"`
+	HargaKonsumen Money  `gorm:"type:bigint"`
 	Deskripsi     string `gorm:"type:text"`
 }
 
+type ApiKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	IdToko     uint       `gorm:"index" json:"id_toko"`
+	IdUser     uint       `json:"id_user"`
+	Nama       string     `gorm:"type:varchar(255)" json:"nama"`
+	Prefix     string     `gorm:"type:varchar(32)" json:"prefix"`
+	KeyHash    string     `gorm:"type:varchar(64);uniqueIndex" json:"-"`
+	Scopes     string     `gorm:"type:varchar(255)" json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type Session struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	IdUser     uint       `gorm:"index" json:"id_user"`
+	UserAgent  string     `gorm:"type:varchar(512)" json:"user_agent"`
+	IpAddress  string     `gorm:"type:varchar(64)" json:"ip_address"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Blob adalah satu file unik (berdasarkan SHA-256 isi upload) yang bisa dipakai banyak FotoProduk.
+// RefCount menghitung FotoProduk yang merujuk blob; file di storage dihapus saat RefCount mencapai 0.
+type Blob struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Sha256       string    `gorm:"type:varchar(64);uniqueIndex" json:"sha256"`
+	MediaType    string    `gorm:"type:varchar(20)" json:"media_type"`
+	Size         int64     `json:"size"`
+	Status       string    `gorm:"type:varchar(20)" json:"status"`
+	Owner        string    `gorm:"type:varchar(100);index" json:"-"` // instance API yang menyimpan file staging blob
+	Key          string    `gorm:"column:storage_key;type:varchar(255)" json:"-"`
+	KeyMedium    string    `gorm:"type:varchar(255)" json:"-"`
+	KeyThumbnail string    `gorm:"type:varchar(255)" json:"-"`
+	RefCount     int       `json:"ref_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// StorageKeys mengembalikan semua key file milik blob
+func (b *Blob) StorageKeys() []string {
+	var keys []string
+	for _, key := range []string{b.Key, b.KeyMedium, b.KeyThumbnail} {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// UploadSession menyimpan progres resumable upload (protokol tus). Data chunk ditulis ke file
+// staging; setelah Offset mencapai Length, file dilampirkan ke produk sebagai FotoProduk.
+type UploadSession struct {
+	ID           string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	IdUser       uint       `gorm:"index" json:"id_user"`
+	IdProduk     uint       `json:"id_produk"`
+	FileName     string     `gorm:"type:varchar(255)" json:"file_name"`
+	MediaType    string     `gorm:"type:varchar(20)" json:"media_type"`
+	Length       int64      `json:"length"`
+	Offset       int64      `gorm:"column:upload_offset" json:"offset"`
+	ExpiresAt    time.Time  `gorm:"index" json:"expires_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	IdFotoProduk *uint      `json:"id_foto_produk"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Status job import produk
+const (
+	ImportStatusPending    = "pending"
+	ImportStatusProcessing = "processing"
+	ImportStatusCompleted  = "completed"
+	ImportStatusFailed     = "failed"
+)
+
+// ImportJob adalah import produk massal dari file CSV/XLSX yang diproses di background.
+// Dengan DryRun, setiap baris hanya divalidasi dan dihitung sebagai created/updated tanpa disimpan.
+// Processed adalah jumlah baris data yang sudah diproses, dipakai sebagai checkpoint saat job dilanjutkan.
+type ImportJob struct {
+	ID         uint             `gorm:"primaryKey" json:"id"`
+	IdToko     uint             `gorm:"index" json:"id_toko"`
+	IdUser     uint             `json:"id_user"`
+	FileName   string           `gorm:"type:varchar(255)" json:"file_name"`
+	Format     string           `gorm:"type:varchar(10)" json:"format"`
+	DryRun     bool             `json:"dry_run"`
+	Status     string           `gorm:"type:varchar(20);index" json:"status"`
+	TotalRows  int              `json:"total_rows"`
+	Created    int              `json:"created"`
+	Updated    int              `json:"updated"`
+	Failed     int              `json:"failed"`
+	Processed  int              `json:"processed"`
+	Errors     []ImportRowError `gorm:"type:mediumtext;serializer:json" json:"errors"`
+	Message    string           `gorm:"type:varchar(255)" json:"message,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+	FinishedAt *time.Time       `json:"finished_at"`
+}
+
+// ImportFile adalah isi file import yang disimpan di database sampai job selesai, sehingga job bisa
+// dilanjutkan oleh instance mana pun setelah restart
+type ImportFile struct {
+	IdJob     uint      `gorm:"primaryKey;autoIncrement:false"`
+	Data      []byte    `gorm:"type:longblob"`
+	CreatedAt time.Time
+}
+
+// ImportRowError adalah error validasi satu baris file import. Row adalah nomor baris di file
+// (baris 1 adalah header).
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Key   string `json:"key,omitempty"`
+	Error string `json:"error"`
+}
+
+// Jenis notifikasi user
+const (
+	NotifikasiProdukDitolak     = "product_rejected"
+	NotifikasiUlasanDibalas     = "review_replied"
+	NotifikasiPertanyaanBaru    = "question_asked"
+	NotifikasiPertanyaanDijawab = "question_answered"
+	NotifikasiStokTersedia      = "back_in_stock"
+)
+
+// Notifikasi adalah pemberitahuan untuk user, misalnya produk yang ditolak moderasi
+type Notifikasi struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	IdUser    uint       `gorm:"index" json:"id_user"`
+	Jenis     string     `gorm:"type:varchar(50)" json:"jenis"`
+	Judul     string     `gorm:"type:varchar(255)" json:"judul"`
+	Pesan     string     `gorm:"type:text" json:"pesan"`
+	Referensi string     `gorm:"type:varchar(100)" json:"referensi"`
+	DibacaAt  *time.Time `json:"dibaca_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Ulasan adalah ulasan pembeli untuk satu baris pembelian (DetailTrx); satu baris hanya bisa diulas sekali
+type Ulasan struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	IdDetailTrx uint         `gorm:"uniqueIndex" json:"id_detail_trx"`
+	IdProduk    uint         `gorm:"index" json:"id_produk"`
+	IdVarian    *uint        `json:"id_varian"`
+	IdToko      uint         `gorm:"index" json:"id_toko"`
+	IdUser      uint         `gorm:"index" json:"id_user"`
+	Rating      int          `gorm:"type:tinyint" json:"rating"`
+	Komentar    string       `gorm:"type:text" json:"komentar"`
+	Balasan     string       `gorm:"type:text" json:"balasan"`
+	DibalasAt   *time.Time   `json:"dibalas_at"`
+	Foto        []FotoUlasan `gorm:"foreignKey:IdUlasan" json:"foto"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+
+	// NamaUser diisi dari User saat dibaca; nomor telepon dan email pembeli tidak ikut ditampilkan
+	User     *User  `gorm:"foreignKey:IdUser" json:"-"`
+	NamaUser string `gorm:"-" json:"nama_user"`
+}
+
+// AfterFind mengisi nama pembeli jika User ikut di-preload
+func (u *Ulasan) AfterFind(tx *gorm.DB) error {
+	if u.User != nil {
+		u.NamaUser = u.User.Nama
+	}
+	return nil
+}
+
+// FotoUlasan adalah foto yang dilampirkan pada ulasan. File disimpan sebagai blob yang diproses
+// di background seperti foto produk; URL diisi dari blob oleh service sebelum dikirim ke client.
+type FotoUlasan struct {
+	ID       uint  `gorm:"primaryKey" json:"id"`
+	IdUlasan uint  `gorm:"index" json:"id_ulasan"`
+	IdBlob   uint  `gorm:"index" json:"-"`
+	Urutan   int   `gorm:"default:0" json:"urutan"`
+	Blob     *Blob `gorm:"foreignKey:IdBlob" json:"-"`
+
+	Status       string `gorm:"-" json:"status"`
+	Url          string `gorm:"-" json:"url"`
+	UrlMedium    string `gorm:"-" json:"url_medium"`
+	UrlThumbnail string `gorm:"-" json:"url_thumbnail"`
+}
+
+// Pertanyaan adalah pertanyaan publik pembeli tentang produk beserta jawaban pemilik toko.
+// Pertanyaan yang disembunyikan admin (konten kasar) tidak tampil di listing publik.
+type Pertanyaan struct {
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	IdProduk            uint       `gorm:"index" json:"id_produk"`
+	IdUser              uint       `gorm:"index" json:"id_user"`
+	Pertanyaan          string     `gorm:"type:text" json:"pertanyaan"`
+	Jawaban             string     `gorm:"type:text" json:"jawaban"`
+	DijawabAt           *time.Time `json:"dijawab_at"`
+	JumlahUpvote        int        `gorm:"default:0;index" json:"jumlah_upvote"`
+	Disembunyikan       bool       `gorm:"default:false;index" json:"disembunyikan"`
+	AlasanDisembunyikan string     `gorm:"type:varchar(255)" json:"alasan_disembunyikan,omitempty"`
+	DimoderasiAt        *time.Time `json:"-"`
+	DimoderasiOleh      *uint      `json:"-"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+
+	User     *User  `gorm:"foreignKey:IdUser" json:"-"`
+	NamaUser string `gorm:"-" json:"nama_user"`
+	// Diupvote bernilai true jika user yang melihat sudah memberi upvote
+	Diupvote bool `gorm:"-" json:"upvoted"`
+}
+
+// AfterFind mengisi nama penanya jika User ikut di-preload
+func (p *Pertanyaan) AfterFind(tx *gorm.DB) error {
+	if p.User != nil {
+		p.NamaUser = p.User.Nama
+	}
+	return nil
+}
+
+// UpvotePertanyaan mencatat upvote user untuk pertanyaan; satu user hanya bisa upvote sekali
+type UpvotePertanyaan struct {
+	IdPertanyaan uint `gorm:"primaryKey;autoIncrement:false"`
+	IdUser       uint `gorm:"primaryKey;autoIncrement:false;index"`
+	CreatedAt    time.Time
+}
+
+// Wishlist adalah produk yang disimpan user; item dihapus saat produknya dihapus
+type Wishlist struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	IdUser    uint      `gorm:"uniqueIndex:idx_wishlist_user_produk" json:"id_user"`
+	IdProduk  uint      `gorm:"uniqueIndex:idx_wishlist_user_produk;index" json:"id_produk"`
+	Produk    *Produk   `gorm:"foreignKey:IdProduk" json:"produk,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LanggananStok adalah permintaan user untuk diberi tahu saat produk yang habis tersedia kembali.
+// Langganan dihapus setelah notifikasi dikirim.
+type LanggananStok struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	IdUser    uint      `gorm:"uniqueIndex:idx_langganan_stok_user_produk" json:"id_user"`
+	IdProduk  uint      `gorm:"uniqueIndex:idx_langganan_stok_user_produk;index" json:"id_produk"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RingkasanUlasan adalah rata-rata rating, jumlah ulasan, dan sebaran jumlah ulasan per bintang (1-5)
+type RingkasanUlasan struct {
+	Rating       float64       `json:"rating"`
+	JumlahUlasan int           `json:"jumlah_ulasan"`
+	Sebaran      map[int]int64 `json:"sebaran"`
+}
+
+// SchemaMigration menandai migrasi data yang sudah dijalankan agar tidak dijalankan ulang.
+// Perubahan skema biasa cukup lewat AutoMigrate.
+type SchemaMigration struct {
+	Version   string    `gorm:"primaryKey;type:varchar(100)"`
+	AppliedAt time.Time
+}
+
+// ProductFacets adalah jumlah produk per nilai filter untuk query listing produk saat ini
+type ProductFacets struct {
+	Categories   []FacetCount  `json:"categories"`
+	Tokos        []FacetCount  `json:"tokos"`
+	PriceBuckets []PriceBucket `json:"price_buckets"`
+	Stock        StockFacet    `json:"stock"`
+}
+
+type FacetCount struct {
+	ID    uint   `json:"id"`
+	Nama  string `json:"nama"`
+	Count int64  `json:"count"`
+}
+
+// PriceBucket berisi jumlah produk dengan Min <= harga < Max. Max nil berarti tanpa batas atas.
+type PriceBucket struct {
+	Min   Money  `json:"min"`
+	Max   *Money `json:"max"`
+	Count int64    `json:"count"`
+}
+
+type StockFacet struct {
+	InStock    int64 `json:"in_stock"`
+	OutOfStock int64 `json:"out_of_stock"`
+}
+
 // Response structs for create transaction (without product details)
 type DetailTrxCreateResponse struct {
 	ID         uint      `json:"ID"`
@@ -113,8 +574,9 @@ type DetailTrxCreateResponse struct {
 	DeletedAt  *gorm.DeletedAt `json:"DeletedAt"`
 	IdTrx      uint      `json:"IdTrx"`
 	IdProduk   uint      `json:"IdProduk"`
+	IdVarian   *uint     `json:"IdVarian"`
 	Kuantitas  int       `json:"Kuantitas"`
-	HargaTotal int       `json:"HargaTotal"`
+	HargaTotal Money     `json:"HargaTotal"`
 }
 
 type TrxCreateResponse struct {
@@ -124,7 +586,7 @@ type TrxCreateResponse struct {
 	DeletedAt        *gorm.DeletedAt           `json:"DeletedAt"`
 	IdUser           uint                      `json:"IdUser"`
 	AlamatPengiriman int                       `json:"AlamatPengiriman"`
-	HargaTotal       int                       `json:"HargaTotal"`
+	HargaTotal       Money                     `json:"HargaTotal"`
 	KodeInvoice      string                    `json:"KodeInvoice"`
 	MethodBayar      string                    `json:"MethodBayar"`
 	DetailTrx        []DetailTrxCreateResponse `json:"DetailTrx"`
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money adalah nominal rupiah dalam satuan sen (1 rupiah = 100 sen) sehingga semua perhitungan
+// harga memakai bilangan bulat. Di JSON Money ditulis sebagai angka rupiah (misalnya 12500 atau
+// 12500.5) dan di database disimpan sebagai BIGINT sen.
+type Money int64
+
+// SenPerRupiah adalah jumlah satuan Money dalam satu rupiah
+const SenPerRupiah = 100
+
+var ErrInvalidMoney = errors.New("format harga tidak valid")
+
+// Rupiah membuat Money dari nominal rupiah utuh
+func Rupiah(rp int64) Money {
+	return Money(rp * SenPerRupiah)
+}
+
+// ParseMoney mem-parsing nominal rupiah seperti "12500" atau "12500.50" (maksimal dua angka
+// di belakang titik). Pemisah ribuan, simbol mata uang, dan nilai negatif ditolak.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if whole == "" || !isDigits(whole) || (hasFrac && (frac == "" || len(frac) > 2 || !isDigits(frac))) {
+		return 0, ErrInvalidMoney
+	}
+
+	rp, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || rp > (1<<63-1)/SenPerRupiah {
+		return 0, ErrInvalidMoney
+	}
+	sen := int64(0)
+	if hasFrac {
+		sen, _ = strconv.ParseInt((frac + "0")[:2], 10, 64)
+	}
+	return Money(rp*SenPerRupiah + sen), nil
+}
+
+// MoneyFromJSON mengambil Money dari nilai hasil decode JSON (string atau angka)
+func MoneyFromJSON(v interface{}) (Money, error) {
+	switch value := v.(type) {
+	case string:
+		return ParseMoney(value)
+	case float64:
+		return ParseMoney(strconv.FormatFloat(value, 'f', -1, 64))
+	default:
+		return 0, ErrInvalidMoney
+	}
+}
+
+// Mul mengalikan harga dengan kuantitas
+func (m Money) Mul(qty int) Money {
+	return m * Money(qty)
+}
+
+// String menulis nominal dalam rupiah, tanpa desimal jika sen-nya nol
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign, m = "-", -m
+	}
+	rp, sen := int64(m)/SenPerRupiah, int64(m)%SenPerRupiah
+	if sen == 0 {
+		return sign + strconv.FormatInt(rp, 10)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, rp, sen)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON menerima angka maupun string rupiah. null diabaikan (nilai tidak berubah) sesuai
+// konvensi json.Unmarshaler; field wajib dicek oleh service.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	parsed, err := ParseMoney(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
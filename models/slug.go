@@ -0,0 +1,68 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxSlugLength adalah panjang maksimal slug dasar, menyisakan ruang untuk akhiran angka unik
+const MaxSlugLength = 200
+
+// Huruf yang tidak terurai menjadi huruf latin dasar dengan NFKD
+var slugReplacer = strings.NewReplacer(
+	"&", " dan ",
+	"ß", "ss", "ẞ", "ss",
+	"æ", "ae", "Æ", "ae",
+	"œ", "oe", "Œ", "oe",
+	"ø", "o", "Ø", "o",
+	"đ", "d", "Đ", "d",
+	"ł", "l", "Ł", "l",
+	"þ", "th", "Þ", "th",
+	"ı", "i",
+)
+
+// Slugify membuat slug URL-safe dari teks: huruf beraksen ditransliterasi ke huruf latin
+// ("Kopi Café Ñ" menjadi "kopi-cafe-n"), apostrof dihapus, dan karakter lain menjadi pemisah "-".
+// Karakter non-latin yang tidak bisa ditransliterasi dibuang sehingga hasilnya bisa kosong.
+func Slugify(s string) string {
+	s = norm.NFKD.String(slugReplacer.Replace(s))
+
+	var b strings.Builder
+	separator := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if separator && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			separator = false
+			b.WriteRune(r)
+		case unicode.Is(unicode.Mn, r), r == '\'', r == '’':
+			// Tanda diakritik hasil NFKD dan apostrof dihapus tanpa pemisah
+		default:
+			separator = true
+		}
+	}
+
+	slug := b.String()
+	if len(slug) > MaxSlugLength {
+		slug = strings.TrimRight(slug[:MaxSlugLength], "-")
+	}
+	return slug
+}
+
+// UniqueSlug mengembalikan base, atau base dengan akhiran angka ("kaos-2", "kaos-3", ...) jika base
+// sudah dipakai. Base kosong (misalnya nama tanpa huruf latin) diganti "produk".
+func UniqueSlug(base string, taken map[string]bool) string {
+	if base == "" {
+		base = "produk"
+	}
+	slug := base
+	for i := 2; taken[slug]; i++ {
+		slug = base + "-" + strconv.Itoa(i)
+	}
+	return slug
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"evernos-api2/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ApiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewApiKeyRepository(db *gorm.DB) *ApiKeyRepository {
+	return &ApiKeyRepository{db: db}
+}
+
+// Create menyimpan API key baru
+func (r *ApiKeyRepository) Create(apiKey *models.ApiKey) error {
+	return r.db.Create(apiKey).Error
+}
+
+// GetByTokoID mengambil semua API key milik toko tertentu
+func (r *ApiKeyRepository) GetByTokoID(tokoID uint) ([]models.ApiKey, error) {
+	var apiKeys []models.ApiKey
+	err := r.db.Where("id_toko = ?", tokoID).Order("created_at DESC").Find(&apiKeys).Error
+	return apiKeys, err
+}
+
+// GetByID mengambil API key berdasarkan ID dan toko ID (untuk security)
+func (r *ApiKeyRepository) GetByID(id uint, tokoID uint) (*models.ApiKey, error) {
+	var apiKey models.ApiKey
+	err := r.db.Where("id = ? AND id_toko = ?", id, tokoID).First(&apiKey).Error
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// GetActiveByHash mengambil API key yang belum dicabut berdasarkan hash
+func (r *ApiKeyRepository) GetActiveByHash(keyHash string) (*models.ApiKey, error) {
+	var apiKey models.ApiKey
+	err := r.db.Where("key_hash = ? AND revoked_at IS NULL", keyHash).First(&apiKey).Error
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// Revoke mencabut API key
+func (r *ApiKeyRepository) Revoke(id uint, tokoID uint) error {
+	return r.db.Model(&models.ApiKey{}).
+		Where("id = ? AND id_toko = ? AND revoked_at IS NULL", id, tokoID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// TouchLastUsed memperbarui waktu terakhir API key digunakan
+func (r *ApiKeyRepository) TouchLastUsed(id uint, usedAt time.Time) error {
+	return r.db.Model(&models.ApiKey{}).Where("id = ?", id).UpdateColumn("last_used_at", usedAt).Error
+}
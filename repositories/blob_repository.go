@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"evernos-api2/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BlobProcessingTimeout adalah batas waktu blob berstatus processing tanpa perubahan. Blob yang
+// lebih lama dari ini dianggap macet (misalnya job hilang) dan diproses ulang oleh upload berikutnya.
+const BlobProcessingTimeout = 15 * time.Minute
+
+type BlobRepository struct {
+	db *gorm.DB
+}
+
+func NewBlobRepository(db *gorm.DB) *BlobRepository {
+	return &BlobRepository{db: db}
+}
+
+// Acquire menambah referensi ke blob dengan SHA-256 yang sama, atau membuat blob baru dengan RefCount 1.
+// needsProcessing bernilai true jika file harus (ulang) diproses: blob baru, blob yang sebelumnya gagal,
+// atau blob yang macet di status processing lebih lama dari BlobProcessingTimeout.
+func (r *BlobRepository) Acquire(candidate *models.Blob) (blob *models.Blob, needsProcessing bool, err error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		err = r.db.Transaction(func(tx *gorm.DB) error {
+			var existing models.Blob
+			findErr := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("sha256 = ?", candidate.Sha256).First(&existing).Error
+			if findErr == gorm.ErrRecordNotFound {
+				candidate.ID = 0
+				candidate.RefCount = 1
+				candidate.Status = models.FotoStatusProcessing
+				if err := tx.Create(candidate).Error; err != nil {
+					return err
+				}
+				blob, needsProcessing = candidate, true
+				return nil
+			}
+			if findErr != nil {
+				return findErr
+			}
+
+			updates := map[string]interface{}{"ref_count": gorm.Expr("ref_count + 1")}
+			stuck := existing.Status == models.FotoStatusProcessing && time.Since(existing.UpdatedAt) > BlobProcessingTimeout
+			if existing.Status == models.FotoStatusFailed || stuck {
+				updates["status"] = models.FotoStatusProcessing
+				updates["owner"] = candidate.Owner
+				existing.Status = models.FotoStatusProcessing
+				existing.Owner = candidate.Owner
+				needsProcessing = true
+			}
+			// Update (bukan UpdateColumn) agar updated_at ikut diperbarui; dipakai oleh storage-gc
+			if err := tx.Model(&existing).Updates(updates).Error; err != nil {
+				return err
+			}
+			existing.RefCount++
+			blob = &existing
+			return nil
+		})
+		// Upload identik bersamaan bisa bentrok di unique index; ulangi sekali untuk memakai blob yang menang
+		if err == nil || attempt > 0 {
+			break
+		}
+		needsProcessing = false
+	}
+	return blob, needsProcessing, err
+}
+
+// Release mengurangi referensi blob. Jika tidak ada lagi yang merujuk, blob dihapus dan
+// dikembalikan dengan removed true agar file-nya bisa dihapus dari storage.
+func (r *BlobRepository) Release(id uint) (blob *models.Blob, removed bool, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.Blob
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&existing, id).Error; err != nil {
+			return err
+		}
+		blob = &existing
+
+		if existing.RefCount <= 1 {
+			removed = true
+			return tx.Delete(&existing).Error
+		}
+		return tx.Model(&existing).Update("ref_count", gorm.Expr("ref_count - 1")).Error
+	})
+	return blob, removed, err
+}
+
+// MarkReady menyimpan key rendisi blob setelah diproses
+func (r *BlobRepository) MarkReady(id uint, keys models.Blob) error {
+	return r.db.Model(&models.Blob{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"storage_key":   keys.Key,
+		"key_medium":    keys.KeyMedium,
+		"key_thumbnail": keys.KeyThumbnail,
+		"status":        models.FotoStatusReady,
+	}).Error
+}
+
+// UpdateStatus memperbarui status pemrosesan blob
+func (r *BlobRepository) UpdateStatus(id uint, status string) error {
+	return r.db.Model(&models.Blob{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// GetRecoverable mengambil blob berstatus processing milik instance owner, ditambah blob instance
+// lain yang macet lebih lama dari BlobProcessingTimeout (dipakai untuk memulihkan antrian saat start)
+func (r *BlobRepository) GetRecoverable(owner string) ([]models.Blob, error) {
+	var blobs []models.Blob
+	err := r.db.Where("status = ? AND (owner = ? OR updated_at < ?)",
+		models.FotoStatusProcessing, owner, time.Now().Add(-BlobProcessingTimeout)).
+		Find(&blobs).Error
+	return blobs, err
+}
+
+// GetAll mengambil semua blob (dipakai storage-gc sebagai daftar file yang masih dirujuk)
+func (r *BlobRepository) GetAll() ([]models.Blob, error) {
+	var blobs []models.Blob
+	err := r.db.Find(&blobs).Error
+	return blobs, err
+}
+
+// DeleteUnreferenced menghapus blob yang tidak lagi dirujuk foto dari produk aktif maupun foto
+// ulasan dan tidak tersentuh sejak cutoff (misalnya foto milik produk dari akun yang dianonimkan)
+func (r *BlobRepository) DeleteUnreferenced(cutoff time.Time) (int64, error) {
+	result := r.db.Where("updated_at < ?", cutoff).
+		Where(`NOT EXISTS (SELECT 1 FROM foto_produks
+			JOIN produks ON produks.id = foto_produks.id_produk AND produks.deleted_at IS NULL
+			WHERE foto_produks.id_blob = blobs.id AND foto_produks.deleted_at IS NULL)`).
+		Where("NOT EXISTS (SELECT 1 FROM foto_ulasans WHERE foto_ulasans.id_blob = blobs.id)").
+		Delete(&models.Blob{})
+	return result.RowsAffected, result.Error
+}
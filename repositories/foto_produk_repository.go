@@ -5,6 +5,19 @@ import (
 	"gorm.io/gorm"
 )
 
+// PhotoOrder adalah urutan tampil foto produk: foto utama dulu, lalu sesuai urutan
+const PhotoOrder = "is_primary DESC, urutan ASC, id ASC"
+
+// OrderedPhotos dipakai untuk Preload("FotoProduk", ...) agar foto selalu terurut
+func OrderedPhotos(db *gorm.DB) *gorm.DB {
+	return db.Order(PhotoOrder)
+}
+
+// ReadyOrderedPhotos seperti OrderedPhotos, hanya memuat foto yang sudah selesai diproses
+func ReadyOrderedPhotos(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ?", models.FotoStatusReady).Order(PhotoOrder)
+}
+
 type FotoProdukRepository struct {
 	db *gorm.DB
 }
@@ -26,7 +39,130 @@ func (r *FotoProdukRepository) CreateMultiple(fotoProduks []models.FotoProduk) e
 // GetByProductID mengambil semua foto berdasarkan product ID
 func (r *FotoProdukRepository) GetByProductID(productID uint) ([]models.FotoProduk, error) {
 	var fotoProduks []models.FotoProduk
-	err := r.db.Where("id_produk = ?", productID).Find(&fotoProduks).Error
+	err := r.db.Where("id_produk = ?", productID).Order(PhotoOrder).Find(&fotoProduks).Error
+	return fotoProduks, err
+}
+
+// NextUrutan mengembalikan posisi untuk foto baru (setelah foto terakhir)
+func (r *FotoProdukRepository) NextUrutan(productID uint) (int, error) {
+	var maxUrutan int
+	err := r.db.Model(&models.FotoProduk{}).Where("id_produk = ?", productID).
+		Select("COALESCE(MAX(urutan), 0)").Scan(&maxUrutan).Error
+	return maxUrutan + 1, err
+}
+
+// HasPrimary mengecek apakah produk sudah memiliki foto utama
+func (r *FotoProdukRepository) HasPrimary(productID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.FotoProduk{}).Where("id_produk = ? AND is_primary = ?", productID, true).Count(&count).Error
+	return count > 0, err
+}
+
+// Reorder menyimpan urutan baru sesuai posisi ID di fotoIDs
+func (r *FotoProdukRepository) Reorder(productID uint, fotoIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range fotoIDs {
+			if err := tx.Model(&models.FotoProduk{}).Where("id = ? AND id_produk = ?", id, productID).
+				Update("urutan", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetPrimary menjadikan foto sebagai foto utama dan melepas status utama foto lain di produk yang sama
+func (r *FotoProdukRepository) SetPrimary(id uint, productID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.FotoProduk{}).Where("id_produk = ? AND id <> ?", productID, id).
+			Update("is_primary", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.FotoProduk{}).Where("id = ?", id).Update("is_primary", true).Error
+	})
+}
+
+// PromoteFirst menjadikan foto gambar pertama (sesuai urutan) sebagai foto utama
+func (r *FotoProdukRepository) PromoteFirst(productID uint) error {
+	var fotoProduk models.FotoProduk
+	err := r.db.Where("id_produk = ? AND media_type = ?", productID, models.MediaTypeImage).
+		Order(PhotoOrder).First(&fotoProduk).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return r.db.Model(&models.FotoProduk{}).Where("id = ?", fotoProduk.ID).Update("is_primary", true).Error
+}
+
+// UpdateAltText memperbarui teks alternatif foto
+func (r *FotoProdukRepository) UpdateAltText(id uint, altText string) error {
+	return r.db.Model(&models.FotoProduk{}).Where("id = ?", id).Update("alt_text", altText).Error
+}
+
+// UpdateVariantsByBlob menyalin key rendisi blob ke semua foto yang merujuknya dan menandainya ready
+func (r *FotoProdukRepository) UpdateVariantsByBlob(blobID uint, blob models.Blob) error {
+	return r.db.Model(&models.FotoProduk{}).Where("id_blob = ?", blobID).Updates(map[string]interface{}{
+		"storage_key":   blob.Key,
+		"key_medium":    blob.KeyMedium,
+		"key_thumbnail": blob.KeyThumbnail,
+		"status":        models.FotoStatusReady,
+	}).Error
+}
+
+// SyncFromBlob menyalin status dan key rendisi blob ke foto yang baru dibuat. Blob bisa selesai
+// (atau gagal) diproses di antara Acquire dan insert foto sehingga UpdateVariantsByBlob dari worker
+// tidak mengenai foto tersebut; karena dipanggil setelah insert, update worker berikutnya tetap berlaku.
+func (r *FotoProdukRepository) SyncFromBlob(fotoProduk *models.FotoProduk) error {
+	if fotoProduk.IdBlob == nil {
+		return nil
+	}
+	var blob models.Blob
+	if err := r.db.First(&blob, *fotoProduk.IdBlob).Error; err != nil {
+		return err
+	}
+	if blob.Status == fotoProduk.Status {
+		return nil
+	}
+
+	err := r.db.Model(&models.FotoProduk{}).Where("id = ?", fotoProduk.ID).Updates(map[string]interface{}{
+		"storage_key":   blob.Key,
+		"key_medium":    blob.KeyMedium,
+		"key_thumbnail": blob.KeyThumbnail,
+		"status":        blob.Status,
+	}).Error
+	if err != nil {
+		return err
+	}
+	fotoProduk.Key = blob.Key
+	fotoProduk.KeyMedium = blob.KeyMedium
+	fotoProduk.KeyThumbnail = blob.KeyThumbnail
+	fotoProduk.Status = blob.Status
+	return nil
+}
+
+// UpdateStatusByBlob memperbarui status semua foto yang merujuk blob
+func (r *FotoProdukRepository) UpdateStatusByBlob(blobID uint, status string) error {
+	return r.db.Model(&models.FotoProduk{}).Where("id_blob = ?", blobID).Update("status", status).Error
+}
+
+// GetByID mengambil foto berdasarkan ID
+func (r *FotoProdukRepository) GetByID(id uint) (*models.FotoProduk, error) {
+	var fotoProduk models.FotoProduk
+	err := r.db.First(&fotoProduk, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &fotoProduk, nil
+}
+
+// GetReferenced mengambil semua foto yang produknya masih aktif. Dipakai oleh garbage
+// collector storage untuk menentukan file mana yang masih dipakai.
+func (r *FotoProdukRepository) GetReferenced() ([]models.FotoProduk, error) {
+	var fotoProduks []models.FotoProduk
+	err := r.db.Joins("JOIN produks ON produks.id = foto_produks.id_produk AND produks.deleted_at IS NULL").
+		Find(&fotoProduks).Error
 	return fotoProduks, err
 }
 
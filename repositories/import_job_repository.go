@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"evernos-api2/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ImportJobTimeout adalah lama job processing tanpa checkpoint sebelum dianggap ditinggalkan
+// instance yang berhenti dan boleh diklaim ulang
+const ImportJobTimeout = 5 * time.Minute
+
+type ImportJobRepository struct {
+	db *gorm.DB
+}
+
+func NewImportJobRepository(db *gorm.DB) *ImportJobRepository {
+	return &ImportJobRepository{db: db}
+}
+
+// Create membuat job import baru beserta isi filenya dalam satu transaksi
+func (r *ImportJobRepository) Create(job *models.ImportJob, data []byte) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ImportFile{IdJob: job.ID, Data: data}).Error
+	})
+}
+
+// Update menyimpan progres dan hasil job import
+func (r *ImportJobRepository) Update(job *models.ImportJob) error {
+	return r.db.Save(job).Error
+}
+
+// Claim menandai job processing jika masih pending atau ditinggalkan instance lain (tidak ada
+// checkpoint selama ImportJobTimeout). Hanya satu instance yang berhasil mengklaim job yang sama.
+func (r *ImportJobRepository) Claim(id uint) (bool, error) {
+	result := r.db.Model(&models.ImportJob{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at < ?))",
+			id, models.ImportStatusPending, models.ImportStatusProcessing, time.Now().Add(-ImportJobTimeout)).
+		Update("status", models.ImportStatusProcessing)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetFile mengambil isi file job import
+func (r *ImportJobRepository) GetFile(jobID uint) ([]byte, error) {
+	var file models.ImportFile
+	if err := r.db.First(&file, jobID).Error; err != nil {
+		return nil, err
+	}
+	return file.Data, nil
+}
+
+// DeleteFile menghapus isi file job import yang sudah selesai
+func (r *ImportJobRepository) DeleteFile(jobID uint) error {
+	return r.db.Delete(&models.ImportFile{}, jobID).Error
+}
+
+// GetByID mengambil job import milik toko tertentu
+func (r *ImportJobRepository) GetByID(id uint, tokoID uint) (*models.ImportJob, error) {
+	var job models.ImportJob
+	err := r.db.Where("id = ? AND id_toko = ?", id, tokoID).First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindByID mengambil job import tanpa filter toko (dipakai worker)
+func (r *ImportJobRepository) FindByID(id uint) (*models.ImportJob, error) {
+	var job models.ImportJob
+	err := r.db.First(&job, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetUnfinished mengambil job yang belum selesai (misalnya terhenti karena server restart)
+func (r *ImportJobRepository) GetUnfinished() ([]models.ImportJob, error) {
+	var jobs []models.ImportJob
+	err := r.db.Where("status IN ?", []string{models.ImportStatusPending, models.ImportStatusProcessing}).
+		Order("id ASC").Find(&jobs).Error
+	return jobs, err
+}
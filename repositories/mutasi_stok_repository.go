@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"evernos-api2/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MutasiStokRepository struct {
+	db *gorm.DB
+}
+
+func NewMutasiStokRepository(db *gorm.DB) *MutasiStokRepository {
+	return &MutasiStokRepository{db: db}
+}
+
+// Riwayat stok diurutkan dari mutasi terbaru
+var mutasiNewestSort = SortKey{Name: "newest", ID: "mutasi_stoks.id", Desc: true}
+
+// StockDiscrepancy adalah produk atau varian yang stoknya berbeda dengan jumlah mutasi di ledger
+type StockDiscrepancy struct {
+	IdProduk uint
+	IdVarian *uint
+	Stok     int
+	Ledger   int
+}
+
+// recordMutasi menambahkan mutasi ke ledger di dalam transaksi tx. Stok produk/varian harus sudah
+// diubah oleh pemanggil di transaksi yang sama; mutasi dengan Jumlah 0 dilewati.
+func recordMutasi(tx *gorm.DB, mutasi ...models.MutasiStok) error {
+	var entries []models.MutasiStok
+	for _, m := range mutasi {
+		if m.Jumlah != 0 {
+			entries = append(entries, m)
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return tx.Create(&entries).Error
+}
+
+// lockProduk mengambil produk dengan row lock agar perubahan stok tidak saling menimpa
+func lockProduk(tx *gorm.DB, productID uint) (*models.Produk, error) {
+	var produk models.Produk
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&produk, productID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &produk, nil
+}
+
+// SetProductStock mengubah stok produk tanpa varian menjadi target dan mencatat selisihnya di ledger
+func (r *MutasiStokRepository) SetProductStock(productID uint, target int, mutasi models.MutasiStok) (*models.MutasiStok, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return setProductStock(tx, productID, target, &mutasi)
+	})
+	return &mutasi, err
+}
+
+// setProductStock adalah SetProductStock di dalam transaksi tx; mutasi diisi jumlah dan stok setelahnya
+func setProductStock(tx *gorm.DB, productID uint, target int, mutasi *models.MutasiStok) error {
+	produk, err := lockProduk(tx, productID)
+	if err != nil {
+		return err
+	}
+	mutasi.IdProduk = productID
+	mutasi.Jumlah = target - produk.Stok
+	mutasi.StokSetelah = target
+	if mutasi.Jumlah == 0 {
+		return nil
+	}
+	if err := tx.Model(produk).Update("stok", target).Error; err != nil {
+		return err
+	}
+	return recordMutasi(tx, *mutasi)
+}
+
+// GetByProductID mengambil riwayat mutasi stok produk, bisa difilter per varian dan jenis
+func (r *MutasiStokRepository) GetByProductID(productID uint, variantID *uint, jenis string, page PageRequest) ([]models.MutasiStok, PageResult, error) {
+	query := r.db.Model(&models.MutasiStok{}).Where("id_produk = ?", productID)
+	if variantID != nil {
+		query = query.Where("id_varian = ?", *variantID)
+	}
+	if jenis != "" {
+		query = query.Where("jenis = ?", jenis)
+	}
+	return findPage(query, page, mutasiNewestSort, func(m models.MutasiStok) uint { return m.ID })
+}
+
+// FindDiscrepancies mencari produk dan varian aktif yang stoknya tidak sama dengan jumlah ledger
+func (r *MutasiStokRepository) FindDiscrepancies() ([]StockDiscrepancy, error) {
+	var produkDiffs []StockDiscrepancy
+	err := r.db.Table("produks").
+		Select("produks.id AS id_produk, produks.stok AS stok, COALESCE(SUM(mutasi_stoks.jumlah), 0) AS ledger").
+		Joins("LEFT JOIN mutasi_stoks ON mutasi_stoks.id_produk = produks.id").
+		Where("produks.deleted_at IS NULL").
+		Group("produks.id, produks.stok").
+		Having("stok <> ledger").
+		Scan(&produkDiffs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var varianDiffs []StockDiscrepancy
+	err = r.db.Table("varian_produks").
+		Select("varian_produks.id_produk AS id_produk, varian_produks.id AS id_varian, varian_produks.stok AS stok, COALESCE(SUM(mutasi_stoks.jumlah), 0) AS ledger").
+		Joins("LEFT JOIN mutasi_stoks ON mutasi_stoks.id_varian = varian_produks.id").
+		Where("varian_produks.deleted_at IS NULL").
+		Group("varian_produks.id, varian_produks.id_produk, varian_produks.stok").
+		Having("stok <> ledger").
+		Scan(&varianDiffs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return append(produkDiffs, varianDiffs...), nil
+}
+
+// FixDiscrepancy menyamakan stok produk atau varian dengan jumlah ledger (ledger dianggap benar)
+func (r *MutasiStokRepository) FixDiscrepancy(d StockDiscrepancy) error {
+	if d.IdVarian != nil {
+		return r.db.Exec("UPDATE varian_produks SET stok = (SELECT COALESCE(SUM(jumlah), 0) FROM mutasi_stoks WHERE id_varian = ?) WHERE id = ?",
+			*d.IdVarian, *d.IdVarian).Error
+	}
+	return r.db.Exec("UPDATE produks SET stok = (SELECT COALESCE(SUM(jumlah), 0) FROM mutasi_stoks WHERE id_produk = ?) WHERE id = ?",
+		d.IdProduk, d.IdProduk).Error
+}
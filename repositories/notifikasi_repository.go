@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"evernos-api2/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotifikasiRepository struct {
+	db *gorm.DB
+}
+
+func NewNotifikasiRepository(db *gorm.DB) *NotifikasiRepository {
+	return &NotifikasiRepository{db: db}
+}
+
+// Notifikasi diurutkan dari yang terbaru
+var notifikasiNewestSort = SortKey{Name: "newest", ID: "notifikasis.id", Desc: true}
+
+// Create menyimpan notifikasi baru
+func (r *NotifikasiRepository) Create(notifikasi *models.Notifikasi) error {
+	return r.db.Create(notifikasi).Error
+}
+
+// GetByUserID mengambil notifikasi user dengan pagination, bisa dibatasi yang belum dibaca saja
+func (r *NotifikasiRepository) GetByUserID(userID uint, unreadOnly bool, page PageRequest) ([]models.Notifikasi, PageResult, error) {
+	query := r.db.Model(&models.Notifikasi{}).Where("id_user = ?", userID)
+	if unreadOnly {
+		query = query.Where("dibaca_at IS NULL")
+	}
+	return findPage(query, page, notifikasiNewestSort, func(n models.Notifikasi) uint { return n.ID })
+}
+
+// CountUnread menghitung notifikasi user yang belum dibaca
+func (r *NotifikasiRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notifikasi{}).Where("id_user = ? AND dibaca_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// CheckExists mengecek apakah notifikasi dengan ID tertentu ada untuk user tertentu
+func (r *NotifikasiRepository) CheckExists(id uint, userID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Notifikasi{}).Where("id = ? AND id_user = ?", id, userID).Count(&count).Error
+	return count > 0, err
+}
+
+// MarkRead menandai notifikasi user sudah dibaca; id 0 berarti semua notifikasi user
+func (r *NotifikasiRepository) MarkRead(id uint, userID uint) error {
+	query := r.db.Model(&models.Notifikasi{}).Where("id_user = ? AND dibaca_at IS NULL", userID)
+	if id != 0 {
+		query = query.Where("id = ?", id)
+	}
+	return query.Update("dibaca_at", time.Now()).Error
+}
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// DefaultPageLimit adalah jumlah item per halaman jika limit tidak diisi
+const DefaultPageLimit = 10
+
+var ErrInvalidCursor = errors.New("cursor tidak valid")
+
+// PageRequest adalah parameter pagination listing. Jika Cursor diisi, dipakai mode cursor (keyset):
+// halaman diambil setelah baris terakhir halaman sebelumnya sehingga tetap cepat untuk halaman
+// yang dalam. Tanpa cursor, dipakai mode offset (page/limit) seperti sebelumnya.
+type PageRequest struct {
+	Limit  int
+	Page   int
+	Cursor string
+}
+
+// NewPageRequest mem-parsing query string limit, page, dan cursor dengan nilai default
+func NewPageRequest(limitStr, pageStr, cursor string) PageRequest {
+	req := PageRequest{Limit: DefaultPageLimit, Page: 1, Cursor: cursor}
+	if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+		req.Limit = l
+	}
+	if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+		req.Page = p
+	}
+	return req
+}
+
+// IsCursor bernilai true jika request memakai mode cursor
+func (p PageRequest) IsCursor() bool {
+	return p.Cursor != ""
+}
+
+// PageResult adalah hasil pagination. Total hanya dihitung pada mode offset.
+type PageResult struct {
+	Total      int64
+	HasNext    bool
+	NextCursor string
+}
+
+// SortKey mendefinisikan urutan listing. Expr adalah ekspresi SQL nilai sort (kosong berarti
+// urut berdasarkan ID saja); ID selalu dipakai sebagai pemecah nilai yang sama agar urutan
+// deterministik. Sort dengan OffsetCursor diurutkan oleh pemanggil (misalnya relevansi
+// pencarian) sehingga cursor-nya hanya menyimpan posisi.
+type SortKey struct {
+	Name         string
+	Table        string
+	Expr         string
+	ID           string
+	Desc         bool
+	OffsetCursor bool
+}
+
+// cursorData adalah isi cursor sebelum di-encode; client memperlakukannya sebagai string opaque
+type cursorData struct {
+	Sort   string `json:"s"`
+	Value  string `json:"v,omitempty"`
+	ID     uint   `json:"id,omitempty"`
+	Offset int    `json:"o,omitempty"`
+}
+
+func encodeCursor(c cursorData) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursorData, error) {
+	var c cursorData
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// findPage menerapkan urutan dan pagination pada query lalu mengambil satu halaman.
+// idOf mengambil ID item untuk membentuk next_cursor dari baris terakhir.
+func findPage[T any](query *gorm.DB, page PageRequest, sort SortKey, idOf func(T) uint) ([]T, PageResult, error) {
+	var items []T
+	var result PageResult
+	offset := (page.Page - 1) * page.Limit
+
+	if page.IsCursor() {
+		cursor, err := decodeCursor(page.Cursor)
+		if err != nil || cursor.Sort != sort.Name {
+			return nil, result, ErrInvalidCursor
+		}
+		offset = 0
+		switch {
+		case sort.OffsetCursor:
+			offset = cursor.Offset
+		case sort.Expr == "":
+			query = query.Where(sort.ID+" "+sort.comparator()+" ?", cursor.ID)
+		default:
+			query = query.Where("("+sort.Expr+" "+sort.comparator()+" ? OR ("+sort.Expr+" = ? AND "+sort.ID+" "+sort.comparator()+" ?))",
+				cursor.Value, cursor.Value, cursor.ID)
+		}
+	} else if err := query.Count(&result.Total).Error; err != nil {
+		return nil, result, err
+	}
+
+	if !sort.OffsetCursor {
+		if sort.Expr != "" {
+			query = query.Order(sort.Expr + sort.direction())
+		}
+		query = query.Order(sort.ID + sort.direction())
+	}
+
+	// Ambil satu baris lebih untuk mengetahui apakah masih ada halaman berikutnya
+	if err := query.Limit(page.Limit + 1).Offset(offset).Find(&items).Error; err != nil {
+		return nil, result, err
+	}
+	if len(items) <= page.Limit {
+		return items, result, nil
+	}
+	items = items[:page.Limit]
+	result.HasNext = true
+
+	next := cursorData{Sort: sort.Name}
+	switch {
+	case sort.OffsetCursor:
+		next.Offset = offset + page.Limit
+	default:
+		next.ID = idOf(items[len(items)-1])
+		if sort.Expr != "" {
+			if err := query.Session(&gorm.Session{NewDB: true}).Table(sort.Table).
+				Select(sort.Expr).Where(sort.ID+" = ?", next.ID).Scan(&next.Value).Error; err != nil {
+				return nil, result, err
+			}
+		}
+	}
+	result.NextCursor = encodeCursor(next)
+
+	return items, result, nil
+}
+
+func (s SortKey) direction() string {
+	if s.Desc {
+		return " DESC"
+	}
+	return " ASC"
+}
+
+func (s SortKey) comparator() string {
+	if s.Desc {
+		return "<"
+	}
+	return ">"
+}
@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"evernos-api2/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PertanyaanRepository struct {
+	db *gorm.DB
+}
+
+func NewPertanyaanRepository(db *gorm.DB) *PertanyaanRepository {
+	return &PertanyaanRepository{db: db}
+}
+
+// Pilihan sort listing pertanyaan. ID pertanyaan dipakai sebagai pemecah nilai yang sama.
+var pertanyaanSorts = map[string]SortKey{
+	"newest": {Name: "newest", ID: "pertanyaans.id", Desc: true},
+	"top":    {Name: "top", Table: "pertanyaans", Expr: "pertanyaans.jumlah_upvote", ID: "pertanyaans.id", Desc: true},
+}
+
+// PertanyaanSort mengembalikan SortKey berdasarkan nama sort; sort kosong berarti newest
+func PertanyaanSort(name string) (SortKey, bool) {
+	if name == "" {
+		name = "newest"
+	}
+	sort, ok := pertanyaanSorts[name]
+	return sort, ok
+}
+
+// preloadPenanya memuat nama penanya saja
+func preloadPenanya(db *gorm.DB) *gorm.DB {
+	return db.Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "nama") })
+}
+
+func pertanyaanID(p models.Pertanyaan) uint {
+	return p.ID
+}
+
+// GetByProductID mengambil pertanyaan produk yang tidak disembunyikan dengan pagination
+func (r *PertanyaanRepository) GetByProductID(productID uint, sort SortKey, page PageRequest) ([]models.Pertanyaan, PageResult, error) {
+	query := r.db.Model(&models.Pertanyaan{}).Scopes(preloadPenanya).
+		Where("pertanyaans.id_produk = ? AND pertanyaans.disembunyikan = ?", productID, false)
+	return findPage(query, page, sort, pertanyaanID)
+}
+
+// GetByHidden mengambil semua pertanyaan berdasarkan status disembunyikan, terbaru lebih dulu
+func (r *PertanyaanRepository) GetByHidden(hidden bool, page PageRequest) ([]models.Pertanyaan, PageResult, error) {
+	query := r.db.Model(&models.Pertanyaan{}).Scopes(preloadPenanya).Where("pertanyaans.disembunyikan = ?", hidden)
+	return findPage(query, page, pertanyaanSorts["newest"], pertanyaanID)
+}
+
+// GetByID mengambil pertanyaan berdasarkan ID
+func (r *PertanyaanRepository) GetByID(id uint) (*models.Pertanyaan, error) {
+	var pertanyaan models.Pertanyaan
+	err := r.db.Scopes(preloadPenanya).First(&pertanyaan, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pertanyaan, nil
+}
+
+// Create menyimpan pertanyaan baru
+func (r *PertanyaanRepository) Create(pertanyaan *models.Pertanyaan) error {
+	return r.db.Create(pertanyaan).Error
+}
+
+// SetAnswer menyimpan jawaban pemilik toko; jawaban lama ditimpa
+func (r *PertanyaanRepository) SetAnswer(id uint, jawaban string) error {
+	return r.db.Model(&models.Pertanyaan{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"jawaban":    jawaban,
+		"dijawab_at": time.Now(),
+	}).Error
+}
+
+// SetHidden menyembunyikan atau menampilkan kembali pertanyaan oleh admin
+func (r *PertanyaanRepository) SetHidden(id uint, hidden bool, reason string, adminID uint) error {
+	return r.db.Model(&models.Pertanyaan{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"disembunyikan":        hidden,
+		"alasan_disembunyikan": reason,
+		"dimoderasi_at":        time.Now(),
+		"dimoderasi_oleh":      adminID,
+	}).Error
+}
+
+// AddUpvote mencatat upvote user dan menaikkan jumlah upvote. Upvote ulang oleh user yang sama diabaikan.
+func (r *PertanyaanRepository) AddUpvote(id uint, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UpvotePertanyaan{IdPertanyaan: id, IdUser: userID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Pertanyaan{}).Where("id = ?", id).
+			UpdateColumn("jumlah_upvote", gorm.Expr("jumlah_upvote + 1")).Error
+	})
+}
+
+// RemoveUpvote menghapus upvote user dan menurunkan jumlah upvote jika user memang sudah upvote
+func (r *PertanyaanRepository) RemoveUpvote(id uint, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id_pertanyaan = ? AND id_user = ?", id, userID).Delete(&models.UpvotePertanyaan{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Pertanyaan{}).Where("id = ? AND jumlah_upvote > 0", id).
+			UpdateColumn("jumlah_upvote", gorm.Expr("jumlah_upvote - 1")).Error
+	})
+}
+
+// GetUpvotedIDs mengembalikan ID pertanyaan di antara ids yang sudah di-upvote user
+func (r *PertanyaanRepository) GetUpvotedIDs(userID uint, ids []uint) ([]uint, error) {
+	var upvoted []uint
+	if len(ids) == 0 {
+		return upvoted, nil
+	}
+	err := r.db.Model(&models.UpvotePertanyaan{}).Where("id_user = ? AND id_pertanyaan IN ?", userID, ids).
+		Pluck("id_pertanyaan", &upvoted).Error
+	return upvoted, err
+}
@@ -2,10 +2,14 @@ package repositories
 
 import (
 	"evernos-api2/models"
+	"evernos-api2/search"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepository struct {
@@ -16,94 +20,362 @@ func NewProductRepository(db *gorm.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-// GetAllWithFilters mengambil semua produk dengan filtering dan pagination
-func (r *ProductRepository) GetAllWithFilters(filters map[string]string) ([]models.Produk, int64, error) {
-	var products []models.Produk
-	var total int64
+// Pilihan sort listing produk. ID produk dipakai sebagai pemecah nilai yang sama.
+var productSorts = map[string]SortKey{
+	"newest":     {Name: "newest", ID: "produks.id", Desc: true},
+	"price_asc":  {Name: "price_asc", Table: "produks", Expr: "produks.harga_konsumen", ID: "produks.id"},
+	"price_desc": {Name: "price_desc", Table: "produks", Expr: "produks.harga_konsumen", ID: "produks.id", Desc: true},
+	"name":       {Name: "name", Table: "produks", Expr: "produks.nama_produk", ID: "produks.id"},
+	"best_selling": {Name: "best_selling", Table: "produks", ID: "produks.id", Desc: true,
+		Expr: "(SELECT COALESCE(SUM(detail_trxes.kuantitas), 0) FROM detail_trxes WHERE detail_trxes.id_produk = produks.id AND detail_trxes.deleted_at IS NULL)"},
+	"rating": {Name: "rating", Table: "produks", Expr: "produks.rating", ID: "produks.id", Desc: true},
+}
 
-	query := r.db.Model(&models.Produk{}).Preload("FotoProduk")
+// RelevanceSort mengurutkan hasil pencarian sesuai urutan ID dari search engine
+var RelevanceSort = SortKey{Name: "relevance", OffsetCursor: true}
 
-	// Apply filters
-	if namaProduk := filters["nama_produk"]; namaProduk != "" {
-		query = query.Where("nama_produk LIKE ?", "%"+namaProduk+"%")
+// ProductSort mengembalikan SortKey berdasarkan nama sort; sort kosong berarti newest
+func ProductSort(name string) (SortKey, bool) {
+	if name == "" {
+		name = "newest"
 	}
+	sort, ok := productSorts[name]
+	return sort, ok
+}
 
-	if categoryID := filters["category_id"]; categoryID != "" {
-		if id, err := strconv.ParseUint(categoryID, 10, 32); err == nil {
-			query = query.Where("id_category = ?", uint(id))
-		}
+// GetAllWithFilters mengambil semua produk dengan filtering, sorting, dan pagination
+func (r *ProductRepository) GetAllWithFilters(filters map[string]string, sort SortKey, page PageRequest) ([]models.Produk, PageResult, error) {
+	// Listing hanya memuat foto yang sudah selesai diproses, foto utama di urutan pertama
+	query := applyProductFilters(r.db.Model(&models.Produk{}).Preload("FotoProduk", ReadyOrderedPhotos).Scopes(preloadVarian), filters)
+	return findPage(query, page, sort, productID)
+}
+
+// GetByIDsWithFilters mengambil produk hasil pencarian dengan filtering dan pagination.
+// Dengan RelevanceSort, urutan ids (relevansi) dipertahankan.
+func (r *ProductRepository) GetByIDsWithFilters(ids []uint, filters map[string]string, sort SortKey, page PageRequest) ([]models.Produk, PageResult, error) {
+	if len(ids) == 0 {
+		return []models.Produk{}, PageResult{}, nil
 	}
 
-	if tokoID := filters["toko_id"]; tokoID != "" {
-		if id, err := strconv.ParseUint(tokoID, 10, 32); err == nil {
-			query = query.Where("id_toko = ?", uint(id))
-		}
+	query := applyProductFilters(r.db.Model(&models.Produk{}).Preload("FotoProduk", ReadyOrderedPhotos).Scopes(preloadVarian), filters).
+		Where("produks.id IN ?", ids)
+	if sort.Name == RelevanceSort.Name {
+		query = query.Clauses(clause.OrderBy{
+			Expression: clause.Expr{SQL: "FIELD(produks.id, ?)", Vars: []interface{}{ids}, WithoutParentheses: true},
+		})
 	}
+	return findPage(query, page, sort, productID)
+}
 
-	// Price range filters
-	if minHarga := filters["min_harga"]; minHarga != "" {
-		if price, err := strconv.ParseFloat(minHarga, 64); err == nil {
-			// Convert HargaKonsumen string to number for comparison
-			query = query.Where("CAST(harga_konsumen AS DECIMAL(10,2)) >= ?", price)
+func productID(p models.Produk) uint {
+	return p.ID
+}
+
+// GetSearchDocuments mengambil data produk untuk index pencarian. Tanpa ids, semua produk diambil.
+func (r *ProductRepository) GetSearchDocuments(ids ...uint) ([]search.Document, error) {
+	var docs []search.Document
+	query := r.db.Table("produks").
+		Select("produks.id, produks.nama_produk, produks.deskripsi, categories.nama_category, tokos.nama_toko").
+		Joins("LEFT JOIN categories ON categories.id = produks.id_category").
+		Joins("LEFT JOIN tokos ON tokos.id = produks.id_toko").
+		Where("produks.deleted_at IS NULL")
+	if len(ids) > 0 {
+		query = query.Where("produks.id IN ?", ids)
+	}
+	err := query.Scan(&docs).Error
+	return docs, err
+}
+
+// Batas atas bucket harga untuk facet price_buckets; bucket terakhir tanpa batas atas
+var priceBucketBounds = []models.Money{
+	models.Rupiah(50000), models.Rupiah(100000), models.Rupiah(250000), models.Rupiah(500000), models.Rupiah(1000000),
+}
+
+// GetFacets menghitung facet untuk query listing saat ini. Setiap facet mengabaikan filter miliknya
+// sendiri (misalnya jumlah per kategori tidak dibatasi category_id) agar client bisa menampilkan
+// pilihan lain yang tersedia. Jika searchIDs tidak nil, facet dibatasi pada produk hasil pencarian.
+func (r *ProductRepository) GetFacets(searchIDs []uint, filters map[string]string) (*models.ProductFacets, error) {
+	facets := &models.ProductFacets{
+		Categories:   []models.FacetCount{},
+		Tokos:        []models.FacetCount{},
+		PriceBuckets: []models.PriceBucket{},
+	}
+	if searchIDs != nil && len(searchIDs) == 0 {
+		return facets, nil
+	}
+
+	base := func(skip ...string) *gorm.DB {
+		query := applyProductFilters(r.db.Model(&models.Produk{}), filters, skip...)
+		if searchIDs != nil {
+			query = query.Where("produks.id IN ?", searchIDs)
 		}
+		return query
+	}
+
+	err := base("category_id").
+		Select("categories.id, categories.nama_category AS nama, COUNT(*) AS count").
+		Joins("JOIN categories ON categories.id = produks.id_category AND categories.deleted_at IS NULL").
+		Group("categories.id, categories.nama_category").
+		Order("count DESC, categories.id ASC").
+		Scan(&facets.Categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = base("toko_id").
+		Select("tokos.id, tokos.nama_toko AS nama, COUNT(*) AS count").
+		Joins("JOIN tokos ON tokos.id = produks.id_toko").
+		Group("tokos.id, tokos.nama_toko").
+		Order("count DESC, tokos.id ASC").
+		Scan(&facets.Tokos).Error
+	if err != nil {
+		return nil, err
 	}
 
-	if maxHarga := filters["max_harga"]; maxHarga != "" {
-		if price, err := strconv.ParseFloat(maxHarga, 64); err == nil {
-			// Convert HargaKonsumen string to number for comparison
-			query = query.Where("CAST(harga_konsumen AS DECIMAL(10,2)) <= ?", price)
+	// Bucket ke-i berisi harga di bawah priceBucketBounds[i]; bucket terakhir sisanya
+	bucketSQL := "CASE"
+	for i, bound := range priceBucketBounds {
+		bucketSQL += fmt.Sprintf(" WHEN produks.harga_konsumen < %d THEN %d", int64(bound), i)
+	}
+	bucketSQL += fmt.Sprintf(" ELSE %d END", len(priceBucketBounds))
+
+	var buckets []struct {
+		Bucket int
+		Count  int64
+	}
+	err = base("min_harga", "max_harga").
+		Select(bucketSQL + " AS bucket, COUNT(*) AS count").
+		Group("bucket").
+		Scan(&buckets).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[int]int64, len(buckets))
+	for _, bucket := range buckets {
+		counts[bucket.Bucket] = bucket.Count
+	}
+	for i := 0; i <= len(priceBucketBounds); i++ {
+		bucket := models.PriceBucket{Count: counts[i]}
+		if i > 0 {
+			bucket.Min = priceBucketBounds[i-1]
+		}
+		if i < len(priceBucketBounds) {
+			max := priceBucketBounds[i]
+			bucket.Max = &max
 		}
+		facets.PriceBuckets = append(facets.PriceBuckets, bucket)
 	}
 
-	// Count total records
-	err := query.Count(&total).Error
+	err = base("in_stock").
+		Select("COALESCE(SUM(CASE WHEN produks.stok > 0 THEN 1 ELSE 0 END), 0) AS in_stock, " +
+			"COALESCE(SUM(CASE WHEN produks.stok > 0 THEN 0 ELSE 1 END), 0) AS out_of_stock").
+		Scan(&facets.Stock).Error
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	// Apply pagination
-	limit := 10 // default
-	page := 1   // default
+	return facets, nil
+}
 
-	if limitStr := filters["limit"]; limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
+// Filter status listing produk. Tanpa filter status hanya produk yang sudah terbit yang dimuat;
+// filter lain hanya boleh diisi service untuk produk toko milik user sendiri.
+const (
+	ProductStatusScheduled = "scheduled"
+	ProductStatusAll       = "all"
+)
+
+// publishedProduk membatasi query ke produk yang sudah terbit, sama seperti Produk.IsPublished
+func publishedProduk(db *gorm.DB) *gorm.DB {
+	return db.Where("produks.status = ? AND (produks.publish_at IS NULL OR produks.publish_at <= ?) AND produks.moderation_status = ?",
+		models.ProdukStatusPublished, time.Now(), models.ModerasiApproved)
+}
+
+// applyProductFilters menerapkan filter status publikasi, kategori, toko (boleh beberapa ID dipisah koma),
+// rentang harga, dan ketersediaan stok. Filter yang namanya ada di skip diabaikan (dipakai untuk facet).
+func applyProductFilters(query *gorm.DB, filters map[string]string, skip ...string) *gorm.DB {
+	active := func(name string) string {
+		for _, s := range skip {
+			if s == name {
+				return ""
+			}
 		}
+		return filters[name]
+	}
+
+	switch status := filters["status"]; status {
+	case "":
+		query = query.Scopes(publishedProduk)
+	case ProductStatusScheduled:
+		query = query.Where("produks.status = ? AND produks.publish_at > ?", models.ProdukStatusPublished, time.Now())
+	case ProductStatusAll:
+	default:
+		query = query.Where("produks.status = ?", status)
 	}
 
-	if pageStr := filters["page"]; pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
+	if ids := parseIDList(active("category_id")); len(ids) > 0 {
+		query = query.Where("produks.id_category IN ?", ids)
+	}
+
+	if ids := parseIDList(active("toko_id")); len(ids) > 0 {
+		query = query.Where("produks.id_toko IN ?", ids)
+	}
+
+	// Price range filters
+	if minHarga := active("min_harga"); minHarga != "" {
+		if price, err := models.ParseMoney(minHarga); err == nil {
+			query = query.Where("produks.harga_konsumen >= ?", int64(price))
 		}
 	}
 
-	offset := (page - 1) * limit
-	err = query.Limit(limit).Offset(offset).Find(&products).Error
-	if err != nil {
-		return nil, 0, err
+	if maxHarga := active("max_harga"); maxHarga != "" {
+		if price, err := models.ParseMoney(maxHarga); err == nil {
+			query = query.Where("produks.harga_konsumen <= ?", int64(price))
+		}
+	}
+
+	// Query terlalu pendek untuk FULLTEXT index dicari langsung di nama dan deskripsi produk
+	if text := active("q_like"); text != "" {
+		pattern := "%" + escapeLike(text) + "%"
+		query = query.Where("(produks.nama_produk LIKE ? OR produks.deskripsi LIKE ?)", pattern, pattern)
 	}
 
-	return products, total, nil
+	if inStock, err := strconv.ParseBool(active("in_stock")); err == nil {
+		if inStock {
+			query = query.Where("produks.stok > 0")
+		} else {
+			query = query.Where("produks.stok <= 0")
+		}
+	}
+
+	return query
+}
+
+// escapeLike meng-escape karakter wildcard LIKE agar dicari sebagai teks biasa
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+}
+
+// parseIDList mem-parsing daftar ID dipisah koma ("1,2,3"); nilai yang tidak valid diabaikan
+func parseIDList(value string) []uint {
+	var ids []uint
+	for _, part := range strings.Split(value, ",") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// preloadVarian memuat opsi dan varian produk
+func preloadVarian(db *gorm.DB) *gorm.DB {
+	return db.Preload("OpsiVarian", OrderedOpsi).Preload("VarianProduk", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	})
 }
 
 // GetByID mengambil produk berdasarkan ID
 func (r *ProductRepository) GetByID(id uint) (*models.Produk, error) {
 	var product models.Produk
-	err := r.db.Preload("FotoProduk").First(&product, id).Error
+	err := r.db.Preload("FotoProduk", ReadyOrderedPhotos).Scopes(preloadVarian).First(&product, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// Create membuat produk baru dan mencatat stok awalnya di ledger dengan jenis, pelaku, dan referensi dari mutasi
+func (r *ProductRepository) Create(product *models.Produk, mutasi models.MutasiStok) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		mutasi.IdProduk = product.ID
+		mutasi.Jumlah = product.Stok
+		mutasi.StokSetelah = product.Stok
+		return recordMutasi(tx, mutasi)
+	})
+}
+
+// GetByTokoAndSku mengambil produk toko berdasarkan SKU
+func (r *ProductRepository) GetByTokoAndSku(tokoID uint, sku string) (*models.Produk, error) {
+	var product models.Produk
+	err := r.db.Scopes(preloadVarian).Where("id_toko = ? AND sku = ?", tokoID, sku).First(&product).Error
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// GetByTokoAndSlug mengambil produk toko berdasarkan slug
+func (r *ProductRepository) GetByTokoAndSlug(tokoID uint, slug string) (*models.Produk, error) {
+	var product models.Produk
+	err := r.db.Scopes(preloadVarian).Where("id_toko = ? AND slug = ?", tokoID, slug).First(&product).Error
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
-// Create membuat produk baru
-func (r *ProductRepository) Create(product *models.Produk) error {
-	return r.db.Create(product).Error
+// CheckSkuTaken mengecek apakah SKU sudah dipakai produk lain di toko yang sama
+func (r *ProductRepository) CheckSkuTaken(tokoID uint, sku string, excludeProductID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Produk{}).
+		Where("id_toko = ? AND sku = ? AND id <> ?", tokoID, sku, excludeProductID).
+		Count(&count).Error
+	return count > 0, err
 }
 
-// Update memperbarui produk
-func (r *ProductRepository) Update(product *models.Produk) error {
-	return r.db.Save(product).Error
+// Kolom moderasi hanya disimpan Update jika statusnya memang diubah (lihat applyModeration), agar
+// keputusan admin yang disimpan bersamaan tidak tertimpa nilai lama
+var moderationColumns = []string{"moderation_status", "moderation_reason", "moderated_at", "moderated_by"}
+
+// Update memperbarui produk. Stok tidak ikut disimpan karena hanya boleh berubah lewat mutasi stok,
+// rating dan jumlah ulasan dihitung ulang dari ulasan, dan kolom moderasi hanya disimpan jika moderated. Jika slug berubah, slug lama disimpan di
+// slug_produks agar link lama tetap bisa di-redirect.
+func (r *ProductRepository) Update(product *models.Produk, moderated bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return updateProduk(tx, product, moderated)
+	})
+}
+
+// UpdateWithStock seperti Update, sekaligus mengubah stok produk tanpa varian menjadi target dalam
+// transaksi yang sama sehingga perubahan produk dan mutasi stoknya tersimpan bersama atau tidak sama sekali
+func (r *ProductRepository) UpdateWithStock(product *models.Produk, moderated bool, target int, mutasi models.MutasiStok) (*models.MutasiStok, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := updateProduk(tx, product, moderated); err != nil {
+			return err
+		}
+		return setProductStock(tx, product.ID, target, &mutasi)
+	})
+	return &mutasi, err
+}
+
+// updateProduk adalah Update di dalam transaksi tx
+func updateProduk(tx *gorm.DB, product *models.Produk, moderated bool) error {
+	var old models.Produk
+	if err := tx.Select("id", "slug").First(&old, product.ID).Error; err != nil {
+		return err
+	}
+
+	if old.Slug != product.Slug {
+		// Produk kembali memakai slug lamanya sendiri
+		if err := tx.Where("id_toko = ? AND slug = ? AND id_produk = ?", product.IdToko, product.Slug, product.ID).
+			Delete(&models.SlugProduk{}).Error; err != nil {
+			return err
+		}
+		if old.Slug != "" {
+			history := models.SlugProduk{IdToko: product.IdToko, IdProduk: product.ID, Slug: old.Slug}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&history).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	omit := []string{"stok", "rating", "jumlah_ulasan"}
+	if !moderated {
+		omit = append(omit, moderationColumns...)
+	}
+	return tx.Omit(omit...).Save(product).Error
 }
 
 // Delete menghapus produk berdasarkan ID
@@ -111,6 +383,38 @@ func (r *ProductRepository) Delete(id uint) error {
 	return r.db.Delete(&models.Produk{}, id).Error
 }
 
+// DeleteWithPhotos menghapus produk beserta semua foto dan variannya dalam satu transaksi.
+// Foto yang terhapus dikembalikan agar file-nya bisa dihapus dari storage.
+func (r *ProductRepository) DeleteWithPhotos(id uint) ([]models.FotoProduk, error) {
+	var fotoProduks []models.FotoProduk
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id_produk = ?", id).Find(&fotoProduks).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id_produk = ?", id).Delete(&models.FotoProduk{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id_produk = ?", id).Delete(&models.OpsiVarian{}).Error; err != nil {
+			return err
+		}
+		if err := releaseSku(tx.Where("id_produk = ?", id)); err != nil {
+			return err
+		}
+		if err := tx.Where("id_produk = ?", id).Delete(&models.VarianProduk{}).Error; err != nil {
+			return err
+		}
+		// Wishlist dan langganan stok produk yang dihapus tidak berguna lagi
+		if err := tx.Where("id_produk = ?", id).Delete(&models.Wishlist{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id_produk = ?", id).Delete(&models.LanggananStok{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Produk{}, id).Error
+	})
+	return fotoProduks, err
+}
+
 // CheckExists mengecek apakah produk dengan ID tertentu ada
 func (r *ProductRepository) CheckExists(id uint) (bool, error) {
 	var count int64
@@ -131,22 +435,111 @@ func (r *ProductRepository) CheckOwnership(productID uint, userID uint) (bool, e
 // GetByTokoID mengambil produk berdasarkan toko ID
 func (r *ProductRepository) GetByTokoID(tokoID uint) ([]models.Produk, error) {
 	var products []models.Produk
-	err := r.db.Where("id_toko = ?", tokoID).Preload("FotoProduk").Find(&products).Error
+	err := r.db.Where("id_toko = ?", tokoID).Preload("FotoProduk", OrderedPhotos).Find(&products).Error
 	return products, err
 }
 
-// GenerateSlug membuat slug dari nama produk
-func (r *ProductRepository) GenerateSlug(namaProduk string) string {
-	slug := strings.ToLower(namaProduk)
-	slug = strings.ReplaceAll(slug, " ", "-")
-	// Remove special characters (basic implementation)
-	slug = strings.ReplaceAll(slug, ".", "")
-	slug = strings.ReplaceAll(slug, ",", "")
-	slug = strings.ReplaceAll(slug, "!", "")
-	slug = strings.ReplaceAll(slug, "?", "")
-	slug = strings.ReplaceAll(slug, "'", "")
-	slug = strings.ReplaceAll(slug, "\"", "")
-	return slug
+// GenerateSlug membuat slug URL-safe dari nama produk yang unik di dalam toko. Slug produk lain
+// (termasuk yang sudah dihapus) dan slug lama mereka tidak dipakai ulang agar link lama tidak
+// mengarah ke produk yang berbeda.
+func (r *ProductRepository) GenerateSlug(tokoID uint, namaProduk string, excludeProductID uint) (string, error) {
+	base := models.Slugify(namaProduk)
+	if base == "" {
+		base = "produk"
+	}
+
+	// base hanya berisi huruf, angka, dan "-" sehingga aman dipakai di LIKE
+	var current, history []string
+	err := r.db.Unscoped().Model(&models.Produk{}).
+		Where("id_toko = ? AND id <> ? AND (slug = ? OR slug LIKE ?)", tokoID, excludeProductID, base, base+"-%").
+		Pluck("slug", &current).Error
+	if err != nil {
+		return "", err
+	}
+	err = r.db.Model(&models.SlugProduk{}).
+		Where("id_toko = ? AND id_produk <> ? AND (slug = ? OR slug LIKE ?)", tokoID, excludeProductID, base, base+"-%").
+		Pluck("slug", &history).Error
+	if err != nil {
+		return "", err
+	}
+
+	taken := make(map[string]bool, len(current)+len(history))
+	for _, slug := range append(current, history...) {
+		taken[slug] = true
+	}
+	return models.UniqueSlug(base, taken), nil
+}
+
+// CheckSlugTaken mengecek apakah slug sudah dipakai (atau pernah dipakai) produk lain di toko yang sama
+func (r *ProductRepository) CheckSlugTaken(tokoID uint, slug string, excludeProductID uint) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Produk{}).
+		Where("id_toko = ? AND slug = ? AND id <> ?", tokoID, slug, excludeProductID).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+	err = r.db.Model(&models.SlugProduk{}).
+		Where("id_toko = ? AND slug = ? AND id_produk <> ?", tokoID, slug, excludeProductID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetSlugHistory mengambil slug lama produk di toko tertentu
+func (r *ProductRepository) GetSlugHistory(tokoID uint, slug string) (*models.SlugProduk, error) {
+	var history models.SlugProduk
+	err := r.db.Where("id_toko = ? AND slug = ?", tokoID, slug).First(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// CategoryRequiresModeration mengecek apakah produk di kategori tertentu harus dimoderasi admin
+func (r *ProductRepository) CategoryRequiresModeration(categoryID uint) (bool, error) {
+	var category models.Category
+	err := r.db.Select("id", "requires_moderation").First(&category, categoryID).Error
+	return category.RequiresModeration, err
+}
+
+// Antrian moderasi diurutkan dari produk yang paling lama menunggu
+var moderationQueueSort = SortKey{Name: "oldest", ID: "produks.id"}
+
+// GetByModerationStatus mengambil produk dengan status moderasi tertentu dengan pagination
+func (r *ProductRepository) GetByModerationStatus(status string, page PageRequest) ([]models.Produk, PageResult, error) {
+	query := r.db.Model(&models.Produk{}).Where("moderation_status = ?", status).
+		Preload("FotoProduk", OrderedPhotos).Scopes(preloadVarian)
+	return findPage(query, page, moderationQueueSort, productID)
+}
+
+// SetModeration menyimpan keputusan moderasi admin
+func (r *ProductRepository) SetModeration(id uint, status, reason string, adminID uint) error {
+	return r.db.Model(&models.Produk{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"moderation_status": status,
+		"moderation_reason": reason,
+		"moderated_at":      time.Now(),
+		"moderated_by":      adminID,
+	}).Error
+}
+
+// ResetModeration menyimpan status moderasi produk yang dikirim ulang ke moderasi oleh penjual
+func (r *ProductRepository) ResetModeration(product *models.Produk) error {
+	return r.db.Model(&models.Produk{}).Where("id = ?", product.ID).Updates(map[string]interface{}{
+		"moderation_status": product.ModerationStatus,
+		"moderation_reason": product.ModerationReason,
+		"moderated_at":      product.ModeratedAt,
+		"moderated_by":      product.ModeratedBy,
+	}).Error
+}
+
+// GetOwnerUserID mengambil ID user pemilik toko dari produk
+func (r *ProductRepository) GetOwnerUserID(productID uint) (uint, error) {
+	var toko models.Toko
+	err := r.db.Select("tokos.id, tokos.id_user").
+		Joins("JOIN produks ON produks.id_toko = tokos.id").
+		Where("produks.id = ?", productID).
+		First(&toko).Error
+	return toko.IdUser, err
 }
 
 // CheckCategoryExists mengecek apakah category dengan ID tertentu ada
@@ -156,6 +549,13 @@ func (r *ProductRepository) CheckCategoryExists(categoryID uint) (bool, error) {
 	return count > 0, err
 }
 
+// GetTokoIDByUserID mengambil ID toko milik user tertentu
+func (r *ProductRepository) GetTokoIDByUserID(userID uint) (uint, error) {
+	var toko models.Toko
+	err := r.db.Select("id").Where("id_user = ?", userID).First(&toko).Error
+	return toko.ID, err
+}
+
 // CheckTokoExists mengecek apakah toko dengan ID tertentu ada
 func (r *ProductRepository) CheckTokoExists(tokoID uint) (bool, error) {
 	var count int64
@@ -2,12 +2,21 @@ package repositories
 
 import (
 	"evernos-api2/models"
+	"fmt"
+	"time"
+
 	"gorm.io/gorm"
 )
 
 type ProfileRepository interface {
 	GetByID(id uint) (*models.User, error)
 	Update(user *models.User) error
+	GetAlamats(userID uint) ([]models.Alamat, error)
+	GetToko(userID uint) (*models.Toko, error)
+	GetProductsByTokoID(tokoID uint) ([]models.Produk, error)
+	GetTrxs(userID uint) ([]models.Trx, error)
+	GetDueForAnonymization(requestedBefore time.Time) ([]models.User, error)
+	Anonymize(userID uint) error
 }
 
 type profileRepository struct {
@@ -31,4 +40,104 @@ func (r *profileRepository) GetByID(id uint) (*models.User, error) {
 
 func (r *profileRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
-}
\ No newline at end of file
+}
+
+func (r *profileRepository) GetAlamats(userID uint) ([]models.Alamat, error) {
+	var alamats []models.Alamat
+	err := r.db.Where("id_user = ?", userID).Find(&alamats).Error
+	return alamats, err
+}
+
+func (r *profileRepository) GetToko(userID uint) (*models.Toko, error) {
+	var toko models.Toko
+	err := r.db.Where("id_user = ?", userID).First(&toko).Error
+	if err != nil {
+		return nil, err
+	}
+	return &toko, nil
+}
+
+func (r *profileRepository) GetProductsByTokoID(tokoID uint) ([]models.Produk, error) {
+	var products []models.Produk
+	err := r.db.Where("id_toko = ?", tokoID).Preload("FotoProduk", OrderedPhotos).Find(&products).Error
+	return products, err
+}
+
+func (r *profileRepository) GetTrxs(userID uint) ([]models.Trx, error) {
+	var trxs []models.Trx
+	err := r.db.Where("id_user = ?", userID).
+		Preload("DetailTrx").
+		Order("created_at DESC").
+		Find(&trxs).Error
+	return trxs, err
+}
+
+// GetDueForAnonymization mengambil user yang meminta hapus akun sebelum waktu tertentu dan belum dianonimkan
+func (r *profileRepository) GetDueForAnonymization(requestedBefore time.Time) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("deletion_requested_at IS NOT NULL AND deletion_requested_at <= ? AND anonymized_at IS NULL", requestedBefore).
+		Find(&users).Error
+	return users, err
+}
+
+// Anonymize menghapus data pribadi user dan alamatnya tanpa menghapus transaksi.
+// Baris alamat tetap ada karena direferensikan oleh transaksi dan toko dinonaktifkan. Produk toko
+// dihapus lebih dulu oleh ProductService.DeleteStoreProducts agar foto dan index pencarian ikut dibersihkan.
+func (r *profileRepository) Anonymize(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"nama":          "Deleted User",
+			"email":         fmt.Sprintf("deleted-%d@deleted.invalid", userID),
+			"no_telp":       fmt.Sprintf("deleted-%d", userID),
+			"kata_sandi":    "",
+			"tanggal_lahir": time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+			"jenis_kelamin": "",
+			"tentang":       "",
+			"pekerjaan":     "",
+			"id_provinsi":   "",
+			"id_kota":       "",
+			"anonymized_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.Alamat{}).Where("id_user = ?", userID).Updates(map[string]interface{}{
+			"judul_alamat":  "Deleted",
+			"nama_penerima": "Deleted User",
+			"no_telp":       "",
+			"detail_alamat": "",
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		var toko models.Toko
+		err = tx.Where("id_user = ?", userID).First(&toko).Error
+		if err == nil {
+			err = tx.Model(&toko).Updates(map[string]interface{}{
+				"nama_toko": "Deleted Store",
+				"url_toko":  fmt.Sprintf("deleted-store-%d", toko.ID),
+			}).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&models.ApiKey{}).Where("id_toko = ? AND revoked_at IS NULL", toko.ID).Update("revoked_at", now).Error; err != nil {
+				return err
+			}
+		} else if err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		if err := tx.Where("id_user = ?", userID).Delete(&models.Wishlist{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id_user = ?", userID).Delete(&models.LanggananStok{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Session{}).Where("id_user = ? AND revoked_at IS NULL", userID).Update("revoked_at", now).Error
+	})
+}
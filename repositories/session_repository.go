@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"evernos-api2/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create menyimpan sesi login baru
+func (r *SessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+// GetByID mengambil sesi berdasarkan ID dan user ID (untuk security)
+func (r *SessionRepository) GetByID(id uint, userID uint) (*models.Session, error) {
+	var session models.Session
+	err := r.db.Where("id = ? AND id_user = ?", id, userID).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetActiveByUserID mengambil semua sesi user yang belum dicabut dan belum kedaluwarsa
+func (r *SessionRepository) GetActiveByUserID(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("id_user = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Revoke mencabut sesi tertentu milik user
+func (r *SessionRepository) Revoke(id uint, userID uint) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ? AND id_user = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllExcept mencabut semua sesi user kecuali sesi dengan ID tertentu (0 = cabut semua)
+func (r *SessionRepository) RevokeAllExcept(userID uint, exceptID uint) error {
+	return r.db.Model(&models.Session{}).
+		Where("id_user = ? AND id <> ? AND revoked_at IS NULL", userID, exceptID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// TouchLastSeen memperbarui waktu terakhir sesi digunakan
+func (r *SessionRepository) TouchLastSeen(id uint, seenAt time.Time) error {
+	return r.db.Model(&models.Session{}).Where("id = ?", id).UpdateColumn("last_seen_at", seenAt).Error
+}
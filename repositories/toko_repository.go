@@ -33,21 +33,29 @@ func (r *TokoRepository) GetByID(id uint) (*models.Toko, error) {
 	return &toko, nil
 }
 
+// GetByUrl mengambil toko berdasarkan URL toko. URL toko belum dijamin unik, jadi toko terlama yang dipakai.
+func (r *TokoRepository) GetByUrl(urlToko string) (*models.Toko, error) {
+	var toko models.Toko
+	err := r.db.Where("url_toko = ?", urlToko).Order("id ASC").First(&toko).Error
+	if err != nil {
+		return nil, err
+	}
+	return &toko, nil
+}
+
 // Create membuat toko baru
 func (r *TokoRepository) Create(toko *models.Toko) error {
 	return r.db.Create(toko).Error
 }
 
-// Update memperbarui toko
+// Update memperbarui toko. Rating dan jumlah ulasan tidak ikut disimpan karena dihitung ulang dari
+// ulasan, agar ulasan yang masuk bersamaan tidak tertimpa nilai lama.
 func (r *TokoRepository) Update(toko *models.Toko) error {
-	return r.db.Save(toko).Error
+	return r.db.Omit("rating", "jumlah_ulasan").Save(toko).Error
 }
 
-// GetAllWithPagination mengambil semua toko dengan pagination dan filter nama
-func (r *TokoRepository) GetAllWithPagination(limit, offset int, namaToko string) ([]models.Toko, int64, error) {
-	var tokos []models.Toko
-	var total int64
-
+// GetAllWithPagination mengambil semua toko dengan pagination dan filter nama, urut dari toko terlama
+func (r *TokoRepository) GetAllWithPagination(page PageRequest, namaToko string) ([]models.Toko, PageResult, error) {
 	query := r.db.Model(&models.Toko{})
 
 	// Filter berdasarkan nama toko jika ada (case-insensitive contains)
@@ -55,19 +63,7 @@ func (r *TokoRepository) GetAllWithPagination(limit, offset int, namaToko string
 		query = query.Where("LOWER(nama_toko) LIKE LOWER(?)", "%"+namaToko+"%")
 	}
 
-	// Hitung total records
-	err := query.Count(&total).Error
-	if err != nil {
-		return nil, 0, err
-	}
-
-	// Ambil data dengan pagination
-	err = query.Limit(limit).Offset(offset).Find(&tokos).Error
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return tokos, total, nil
+	return findPage(query, page, SortKey{Name: "oldest", ID: "tokos.id"}, func(t models.Toko) uint { return t.ID })
 }
 
 // CheckExists mengecek apakah toko dengan ID tertentu ada
@@ -1,9 +1,20 @@
 package repositories
 
 import (
+	"errors"
 	"evernos-api2/models"
 	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrTrxCancelled dikembalikan jika transaksi sudah dibatalkan
+	ErrTrxCancelled = errors.New("transaksi sudah dibatalkan")
+	// ErrReturnExceeded dikembalikan jika jumlah retur melebihi item yang belum diretur
+	ErrReturnExceeded = errors.New("jumlah retur melebihi item yang dibeli")
+	// ErrVariantChanged dikembalikan jika varian produk dihapus atau ditambahkan setelah pesanan divalidasi
+	ErrVariantChanged = errors.New("varian produk sudah berubah")
 )
 
 type TrxRepository struct {
@@ -14,28 +25,47 @@ func NewTrxRepository(db *gorm.DB) *TrxRepository {
 	return &TrxRepository{db: db}
 }
 
-// GetByUserID mengambil semua transaksi berdasarkan user ID dengan pagination
-func (r *TrxRepository) GetByUserID(userID uint, limit, offset int) ([]models.Trx, int64, error) {
-	var trxs []models.Trx
-	var total int64
-
-	// Count total records
-	err := r.db.Model(&models.Trx{}).Where("id_user = ?", userID).Count(&total).Error
-	if err != nil {
-		return nil, 0, err
-	}
+// Transaksi diurutkan dari yang terbaru; ID naik seiring created_at sehingga cukup dipakai sebagai kunci cursor
+var trxNewestSort = SortKey{Name: "newest", ID: "trxes.id", Desc: true}
 
+// GetByUserID mengambil semua transaksi berdasarkan user ID dengan pagination
+func (r *TrxRepository) GetByUserID(userID uint, page PageRequest) ([]models.Trx, PageResult, error) {
 	// Get paginated data with preloaded relations
-	err = r.db.Where("id_user = ?", userID).
+	query := r.db.Model(&models.Trx{}).Where("id_user = ?", userID).
 		Preload("DetailTrx").
 		Preload("DetailTrx.Produk").
-		Preload("DetailTrx.Produk.FotoProduk").
-		Limit(limit).
-		Offset(offset).
-		Order("created_at DESC").
-		Find(&trxs).Error
+		Preload("DetailTrx.Varian", unscopedVarian).
+		Preload("DetailTrx.Produk.FotoProduk", OrderedPhotos)
 
-	return trxs, total, err
+	return findPage(query, page, trxNewestSort, trxID)
+}
+
+// GetByTokoID mengambil transaksi yang berisi produk dari toko tertentu dengan pagination.
+// Detail transaksi yang dimuat hanya yang berasal dari toko tersebut.
+func (r *TrxRepository) GetByTokoID(tokoID uint, page PageRequest) ([]models.Trx, PageResult, error) {
+	tokoTrxIDs := r.db.Model(&models.DetailTrx{}).
+		Select("detail_trxes.id_trx").
+		Joins("JOIN produks ON produks.id = detail_trxes.id_produk").
+		Where("produks.id_toko = ?", tokoID)
+	tokoProductIDs := r.db.Unscoped().Model(&models.Produk{}).Select("id").Where("id_toko = ?", tokoID)
+
+	query := r.db.Model(&models.Trx{}).Where("id IN (?)", tokoTrxIDs).
+		Preload("DetailTrx", "id_produk IN (?)", tokoProductIDs).
+		Preload("DetailTrx.Produk").
+		Preload("DetailTrx.Varian", unscopedVarian)
+
+	return findPage(query, page, trxNewestSort, trxID)
+}
+
+func trxID(t models.Trx) uint {
+	return t.ID
+}
+
+// GetTokoIDByUserID mengambil ID toko milik user tertentu
+func (r *TrxRepository) GetTokoIDByUserID(userID uint) (uint, error) {
+	var toko models.Toko
+	err := r.db.Select("id").Where("id_user = ?", userID).First(&toko).Error
+	return toko.ID, err
 }
 
 // GetByID mengambil transaksi berdasarkan ID dan user ID (untuk security)
@@ -44,7 +74,8 @@ func (r *TrxRepository) GetByID(id uint, userID uint) (*models.Trx, error) {
 	err := r.db.Where("id = ? AND id_user = ?", id, userID).
 		Preload("DetailTrx").
 		Preload("DetailTrx.Produk").
-		Preload("DetailTrx.Produk.FotoProduk").
+		Preload("DetailTrx.Varian", unscopedVarian).
+		Preload("DetailTrx.Produk.FotoProduk", OrderedPhotos).
 		First(&trx).Error
 	if err != nil {
 		return nil, err
@@ -60,10 +91,10 @@ func (r *TrxRepository) Create(trx *models.Trx) error {
 			return err
 		}
 
-		// Update product stock for each detail
+		// Update product stock for each detail, dicatat sebagai sale di ledger stok
 		for _, detail := range trx.DetailTrx {
-			var produk models.Produk
-			if err := tx.First(&produk, detail.IdProduk).Error; err != nil {
+			produk, err := lockProduk(tx, detail.IdProduk)
+			if err != nil {
 				return err
 			}
 
@@ -72,9 +103,52 @@ func (r *TrxRepository) Create(trx *models.Trx) error {
 				return gorm.ErrInvalidData // Will be handled as insufficient stock
 			}
 
+			mutasi := models.MutasiStok{
+				IdProduk:    detail.IdProduk,
+				Jenis:       models.MutasiSale,
+				Jumlah:      -detail.Kuantitas,
+				StokSetelah: produk.Stok - detail.Kuantitas,
+				IdUser:      &trx.IdUser,
+				Referensi:   "trx:" + trx.KodeInvoice,
+			}
+
+			// Produk dengan varian: stok varian dikunci dan dikurangi, stok produk adalah totalnya.
+			// Varian bisa diganti pemilik toko setelah validasi di service, jadi dicek ulang setelah
+			// produk dikunci (penggantian varian juga mengunci produk).
+			if detail.IdVarian != nil {
+				var varian models.VarianProduk
+				err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+					Where("id = ? AND id_produk = ?", *detail.IdVarian, detail.IdProduk).
+					First(&varian).Error
+				if err == gorm.ErrRecordNotFound {
+					return ErrVariantChanged
+				}
+				if err != nil {
+					return err
+				}
+				if varian.Stok < detail.Kuantitas {
+					return gorm.ErrInvalidData
+				}
+				if err := tx.Model(&varian).Update("stok", gorm.Expr("stok - ?", detail.Kuantitas)).Error; err != nil {
+					return err
+				}
+				mutasi.IdVarian = detail.IdVarian
+				mutasi.StokSetelah = varian.Stok - detail.Kuantitas
+			} else {
+				var variantCount int64
+				if err := tx.Model(&models.VarianProduk{}).Where("id_produk = ?", detail.IdProduk).Count(&variantCount).Error; err != nil {
+					return err
+				}
+				if variantCount > 0 {
+					return ErrVariantChanged
+				}
+			}
+
 			// Update stock
-			produk.Stok -= detail.Kuantitas
-			if err := tx.Save(&produk).Error; err != nil {
+			if err := tx.Model(produk).Update("stok", gorm.Expr("stok - ?", detail.Kuantitas)).Error; err != nil {
+				return err
+			}
+			if err := recordMutasi(tx, mutasi); err != nil {
 				return err
 			}
 		}
@@ -83,6 +157,116 @@ func (r *TrxRepository) Create(trx *models.Trx) error {
 	})
 }
 
+// Cancel membatalkan transaksi milik user dan mengembalikan stok item yang belum diretur,
+// dicatat sebagai cancel di ledger stok
+func (r *TrxRepository) Cancel(id uint, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var trx models.Trx
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND id_user = ?", id, userID).Preload("DetailTrx").First(&trx).Error; err != nil {
+			return err
+		}
+		if trx.Status == models.TrxStatusCancelled {
+			return ErrTrxCancelled
+		}
+
+		for _, detail := range trx.DetailTrx {
+			err := restock(tx, detail, detail.Kuantitas-detail.KuantitasRetur, models.MutasiStok{
+				Jenis:     models.MutasiCancel,
+				IdUser:    &userID,
+				Referensi: "trx:" + trx.KodeInvoice,
+				Catatan:   "transaksi dibatalkan",
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Model(&trx).Update("status", models.TrxStatusCancelled).Error
+	})
+}
+
+// Return mencatat retur item transaksi yang produknya milik toko dan mengembalikan stoknya,
+// dicatat sebagai return di ledger stok
+func (r *TrxRepository) Return(detailID uint, tokoID uint, kuantitas int, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var detail models.DetailTrx
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Joins("JOIN produks ON produks.id = detail_trxes.id_produk").
+			Where("detail_trxes.id = ? AND produks.id_toko = ?", detailID, tokoID).
+			First(&detail).Error
+		if err != nil {
+			return err
+		}
+		var trx models.Trx
+		if err := tx.Select("id", "status", "kode_invoice").First(&trx, detail.IdTrx).Error; err != nil {
+			return err
+		}
+		if trx.Status == models.TrxStatusCancelled {
+			return ErrTrxCancelled
+		}
+		if detail.KuantitasRetur+kuantitas > detail.Kuantitas {
+			return ErrReturnExceeded
+		}
+
+		err = restock(tx, detail, kuantitas, models.MutasiStok{
+			Jenis:     models.MutasiReturn,
+			IdUser:    &userID,
+			Referensi: "trx:" + trx.KodeInvoice,
+			Catatan:   "retur item transaksi",
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Model(&detail).Update("kuantitas_retur", gorm.Expr("kuantitas_retur + ?", kuantitas)).Error
+	})
+}
+
+// restock menambah kembali stok produk (dan varian) dari detail transaksi lalu mencatatnya di ledger.
+// Produk atau varian yang sudah dihapus dilewati karena stoknya tidak bisa dijual lagi.
+func restock(tx *gorm.DB, detail models.DetailTrx, kuantitas int, mutasi models.MutasiStok) error {
+	if kuantitas <= 0 {
+		return nil
+	}
+	produk, err := lockProduk(tx, detail.IdProduk)
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	mutasi.IdProduk = detail.IdProduk
+	mutasi.Jumlah = kuantitas
+	mutasi.StokSetelah = produk.Stok + kuantitas
+
+	if detail.IdVarian != nil {
+		var varian models.VarianProduk
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND id_produk = ?", *detail.IdVarian, detail.IdProduk).
+			First(&varian).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&varian).Update("stok", gorm.Expr("stok + ?", kuantitas)).Error; err != nil {
+			return err
+		}
+		mutasi.IdVarian = detail.IdVarian
+		mutasi.StokSetelah = varian.Stok + kuantitas
+	}
+
+	if err := tx.Model(produk).Update("stok", gorm.Expr("stok + ?", kuantitas)).Error; err != nil {
+		return err
+	}
+	return recordMutasi(tx, mutasi)
+}
+
+// unscopedVarian tetap memuat varian yang sudah dihapus agar riwayat transaksi lengkap
+func unscopedVarian(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
 // CheckProductExists mengecek apakah produk dengan ID tertentu ada
 func (r *TrxRepository) CheckProductExists(productID uint) (bool, error) {
 	var count int64
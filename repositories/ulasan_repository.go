@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"evernos-api2/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UlasanRepository struct {
+	db *gorm.DB
+}
+
+func NewUlasanRepository(db *gorm.DB) *UlasanRepository {
+	return &UlasanRepository{db: db}
+}
+
+// Pilihan sort listing ulasan. ID ulasan dipakai sebagai pemecah nilai yang sama.
+var ulasanSorts = map[string]SortKey{
+	"newest":      {Name: "newest", ID: "ulasans.id", Desc: true},
+	"rating_desc": {Name: "rating_desc", Table: "ulasans", Expr: "ulasans.rating", ID: "ulasans.id", Desc: true},
+	"rating_asc":  {Name: "rating_asc", Table: "ulasans", Expr: "ulasans.rating", ID: "ulasans.id"},
+}
+
+// UlasanSort mengembalikan SortKey berdasarkan nama sort; sort kosong berarti newest
+func UlasanSort(name string) (SortKey, bool) {
+	if name == "" {
+		name = "newest"
+	}
+	sort, ok := ulasanSorts[name]
+	return sort, ok
+}
+
+// preloadUlasan memuat foto (urut sesuai upload) beserta blob-nya dan nama pembeli
+func preloadUlasan(db *gorm.DB) *gorm.DB {
+	return db.Preload("Foto", func(db *gorm.DB) *gorm.DB { return db.Order("urutan ASC, id ASC") }).
+		Preload("Foto.Blob").
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "nama") })
+}
+
+// GetByProductID mengambil ulasan produk dengan pagination; rating > 0 membatasi ulasan dengan bintang tersebut
+func (r *UlasanRepository) GetByProductID(productID uint, rating int, sort SortKey, page PageRequest) ([]models.Ulasan, PageResult, error) {
+	query := r.db.Model(&models.Ulasan{}).Scopes(preloadUlasan).Where("ulasans.id_produk = ?", productID)
+	if rating > 0 {
+		query = query.Where("ulasans.rating = ?", rating)
+	}
+	return findPage(query, page, sort, func(u models.Ulasan) uint { return u.ID })
+}
+
+// GetByID mengambil ulasan berdasarkan ID
+func (r *UlasanRepository) GetByID(id uint) (*models.Ulasan, error) {
+	var ulasan models.Ulasan
+	err := r.db.Scopes(preloadUlasan).First(&ulasan, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ulasan, nil
+}
+
+// GetSummary menghitung sebaran jumlah ulasan per bintang untuk produk
+func (r *UlasanRepository) GetSummary(productID uint) (map[int]int64, error) {
+	var rows []struct {
+		Rating int
+		Count  int64
+	}
+	err := r.db.Model(&models.Ulasan{}).Select("rating, COUNT(*) AS count").
+		Where("id_produk = ?", productID).Group("rating").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sebaran := map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+	for _, row := range rows {
+		sebaran[row.Rating] = row.Count
+	}
+	return sebaran, nil
+}
+
+// purchasedDetail membatasi query detail_trxes ke pembelian yang masih berlaku: transaksinya tidak
+// dibatalkan dan itemnya tidak diretur seluruhnya
+func purchasedDetail(db *gorm.DB) *gorm.DB {
+	return db.Joins("JOIN trxes ON trxes.id = detail_trxes.id_trx AND trxes.deleted_at IS NULL").
+		Where("trxes.status <> ? AND detail_trxes.kuantitas_retur < detail_trxes.kuantitas", models.TrxStatusCancelled)
+}
+
+// GetPurchasedDetail mengambil baris pembelian milik user berdasarkan ID
+func (r *UlasanRepository) GetPurchasedDetail(detailTrxID uint, userID uint) (*models.DetailTrx, error) {
+	var detail models.DetailTrx
+	err := r.db.Scopes(purchasedDetail).
+		Where("detail_trxes.id = ? AND trxes.id_user = ?", detailTrxID, userID).
+		First(&detail).Error
+	if err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
+
+// FindUnreviewedDetail mengambil baris pembelian produk oleh user yang paling lama dan belum diulas
+func (r *UlasanRepository) FindUnreviewedDetail(userID uint, productID uint) (*models.DetailTrx, error) {
+	var detail models.DetailTrx
+	err := r.db.Scopes(purchasedDetail).
+		Where("detail_trxes.id_produk = ? AND trxes.id_user = ?", productID, userID).
+		Where("NOT EXISTS (SELECT 1 FROM ulasans WHERE ulasans.id_detail_trx = detail_trxes.id)").
+		Order("detail_trxes.id ASC").
+		First(&detail).Error
+	if err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
+
+// HasPurchased mengecek apakah user pernah membeli produk
+func (r *UlasanRepository) HasPurchased(userID uint, productID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.DetailTrx{}).
+		Scopes(purchasedDetail).
+		Where("detail_trxes.id_produk = ? AND trxes.id_user = ?", productID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CheckDetailReviewed mengecek apakah baris pembelian sudah diulas
+func (r *UlasanRepository) CheckDetailReviewed(detailTrxID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Ulasan{}).Where("id_detail_trx = ?", detailTrxID).Count(&count).Error
+	return count > 0, err
+}
+
+// Create menyimpan ulasan beserta fotonya lalu menghitung ulang rating produk dan toko dalam satu transaksi
+func (r *UlasanRepository) Create(ulasan *models.Ulasan) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(ulasan).Error; err != nil {
+			return err
+		}
+		return recalculateRating(tx, ulasan.IdProduk, ulasan.IdToko)
+	})
+}
+
+// recalculateRating menghitung ulang rata-rata rating dan jumlah ulasan produk dan tokonya.
+// Produk yang sudah dihapus tetap dihitung agar agregat toko konsisten dengan ulasannya.
+func recalculateRating(tx *gorm.DB, productID uint, tokoID uint) error {
+	err := tx.Unscoped().Model(&models.Produk{}).Where("id = ?", productID).UpdateColumns(map[string]interface{}{
+		"rating":        gorm.Expr("(SELECT COALESCE(ROUND(AVG(rating), 2), 0) FROM ulasans WHERE id_produk = ?)", productID),
+		"jumlah_ulasan": gorm.Expr("(SELECT COUNT(*) FROM ulasans WHERE id_produk = ?)", productID),
+	}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.Toko{}).Where("id = ?", tokoID).UpdateColumns(map[string]interface{}{
+		"rating":        gorm.Expr("(SELECT COALESCE(ROUND(AVG(rating), 2), 0) FROM ulasans WHERE id_toko = ?)", tokoID),
+		"jumlah_ulasan": gorm.Expr("(SELECT COUNT(*) FROM ulasans WHERE id_toko = ?)", tokoID),
+	}).Error
+}
+
+// SetReply menyimpan balasan penjual untuk ulasan; balasan lama ditimpa
+func (r *UlasanRepository) SetReply(id uint, balasan string) error {
+	return r.db.Model(&models.Ulasan{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"balasan":    balasan,
+		"dibalas_at": time.Now(),
+	}).Error
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"evernos-api2/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UploadSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewUploadSessionRepository(db *gorm.DB) *UploadSessionRepository {
+	return &UploadSessionRepository{db: db}
+}
+
+// Create menyimpan sesi upload baru
+func (r *UploadSessionRepository) Create(session *models.UploadSession) error {
+	return r.db.Create(session).Error
+}
+
+// GetByID mengambil sesi upload milik user yang belum kedaluwarsa
+func (r *UploadSessionRepository) GetByID(id string, userID uint) (*models.UploadSession, error) {
+	var session models.UploadSession
+	err := r.db.Where("id = ? AND id_user = ? AND expires_at > ?", id, userID, time.Now()).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// AdvanceOffset memajukan offset hanya jika offset di database masih sama dengan expected.
+// Mengembalikan false jika ada request lain yang sudah menulis lebih dulu.
+func (r *UploadSessionRepository) AdvanceOffset(id string, expected int64, offset int64) (bool, error) {
+	result := r.db.Model(&models.UploadSession{}).
+		Where("id = ? AND upload_offset = ?", id, expected).
+		Update("upload_offset", offset)
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkCompleted mencatat foto produk hasil upload
+func (r *UploadSessionRepository) MarkCompleted(id string, fotoProdukID uint) error {
+	return r.db.Model(&models.UploadSession{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"completed_at":   time.Now(),
+		"id_foto_produk": fotoProdukID,
+	}).Error
+}
+
+// Delete menghapus sesi upload
+func (r *UploadSessionRepository) Delete(id string) error {
+	return r.db.Delete(&models.UploadSession{}, "id = ?", id).Error
+}
+
+// GetExpired mengambil sesi yang sudah kedaluwarsa
+func (r *UploadSessionRepository) GetExpired(now time.Time) ([]models.UploadSession, error) {
+	var sessions []models.UploadSession
+	err := r.db.Where("expires_at <= ?", now).Find(&sessions).Error
+	return sessions, err
+}
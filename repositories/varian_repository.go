@@ -0,0 +1,212 @@
+package repositories
+
+import (
+	"errors"
+	"evernos-api2/models"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSkuTaken dikembalikan Replace jika SKU sudah dipakai varian aktif lain di toko yang sama
+var ErrSkuTaken = errors.New("SKU sudah dipakai produk lain di toko ini")
+
+type VarianRepository struct {
+	db *gorm.DB
+}
+
+func NewVarianRepository(db *gorm.DB) *VarianRepository {
+	return &VarianRepository{db: db}
+}
+
+// OrderedOpsi mengurutkan opsi varian sesuai urutan yang diatur penjual
+func OrderedOpsi(db *gorm.DB) *gorm.DB {
+	return db.Order("urutan ASC, id ASC")
+}
+
+// GetByProductID mengambil opsi dan varian produk
+func (r *VarianRepository) GetByProductID(productID uint) ([]models.OpsiVarian, []models.VarianProduk, error) {
+	var opsi []models.OpsiVarian
+	var varian []models.VarianProduk
+	if err := OrderedOpsi(r.db.Where("id_produk = ?", productID)).Find(&opsi).Error; err != nil {
+		return nil, nil, err
+	}
+	if err := r.db.Where("id_produk = ?", productID).Order("id ASC").Find(&varian).Error; err != nil {
+		return nil, nil, err
+	}
+	return opsi, varian, nil
+}
+
+// GetByID mengambil varian milik produk tertentu
+func (r *VarianRepository) GetByID(id uint, productID uint) (*models.VarianProduk, error) {
+	var varian models.VarianProduk
+	err := r.db.Where("id = ? AND id_produk = ?", id, productID).First(&varian).Error
+	if err != nil {
+		return nil, err
+	}
+	return &varian, nil
+}
+
+// CountByProductID menghitung varian aktif produk
+func (r *VarianRepository) CountByProductID(productID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.VarianProduk{}).Where("id_produk = ?", productID).Count(&count).Error
+	return count, err
+}
+
+// CheckSkuTaken mengecek apakah SKU sudah dipakai varian produk lain di toko yang sama
+func (r *VarianRepository) CheckSkuTaken(tokoID uint, sku string, excludeProductID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.VarianProduk{}).
+		Joins("JOIN produks ON produks.id = varian_produks.id_produk AND produks.deleted_at IS NULL").
+		Where("produks.id_toko = ? AND varian_produks.sku = ? AND varian_produks.id_produk <> ?", tokoID, sku, excludeProductID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Replace mengganti seluruh opsi dan varian produk dalam satu transaksi. Varian dengan ID yang
+// sudah ada diperbarui, varian yang tidak disertakan di-soft delete. Stok produk diisi total stok
+// varian dan harga produk diisi harga varian termurah agar listing, filter, dan sort tetap berlaku.
+// Setiap perubahan stok varian dicatat di ledger sebagai adjustment oleh userID. Keunikan SKU per
+// toko dijaga unique index; bentrok dengan produk lain yang disimpan bersamaan menghasilkan ErrSkuTaken.
+func (r *VarianRepository) Replace(productID uint, opsi []models.OpsiVarian, varian []models.VarianProduk, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		produk, err := lockProduk(tx, productID)
+		if err != nil {
+			return err
+		}
+		var current []models.VarianProduk
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_produk = ?", productID).Find(&current).Error; err != nil {
+			return err
+		}
+		// SKU varian lama dilepas dulu agar varian bisa bertukar SKU atau memakai ulang SKU varian yang dihapus
+		if err := releaseSku(tx.Where("id_produk = ?", productID)); err != nil {
+			return err
+		}
+		existing := make(map[uint]models.VarianProduk, len(current))
+		for _, v := range current {
+			existing[v.ID] = v
+		}
+
+		if err := tx.Where("id_produk = ?", productID).Delete(&models.OpsiVarian{}).Error; err != nil {
+			return err
+		}
+		for i := range opsi {
+			opsi[i].IdProduk = productID
+		}
+		if len(opsi) > 0 {
+			if err := tx.Create(&opsi).Error; err != nil {
+				return err
+			}
+		}
+
+		mutasi := func(idVarian uint, jumlah, stokSetelah int) models.MutasiStok {
+			return models.MutasiStok{
+				IdProduk:    productID,
+				IdVarian:    &idVarian,
+				Jenis:       models.MutasiAdjustment,
+				Jumlah:      jumlah,
+				StokSetelah: stokSetelah,
+				IdUser:      &userID,
+				Referensi:   "product:" + strconv.FormatUint(uint64(productID), 10),
+				Catatan:     "atur varian produk",
+			}
+		}
+
+		var entries []models.MutasiStok
+		variantDelta := 0
+		keep := make(map[uint]bool)
+		for i := range varian {
+			varian[i].IdProduk = productID
+			varian[i].IdToko = produk.IdToko
+			varian[i].SkuAktif = &varian[i].Sku
+			oldStok := 0
+			if varian[i].ID != 0 {
+				old, ok := existing[varian[i].ID]
+				if !ok {
+					return gorm.ErrRecordNotFound
+				}
+				oldStok = old.Stok
+				if err := tx.Model(&old).
+					Select("id_toko", "sku", "sku_aktif", "opsi", "harga_reseller", "harga_konsumen", "stok", "id_foto_produk").
+					Updates(&varian[i]).Error; err != nil {
+					return skuError(err)
+				}
+			} else if err := tx.Create(&varian[i]).Error; err != nil {
+				return skuError(err)
+			}
+			keep[varian[i].ID] = true
+			entries = append(entries, mutasi(varian[i].ID, varian[i].Stok-oldStok, varian[i].Stok))
+			variantDelta += varian[i].Stok - oldStok
+		}
+
+		for _, old := range current {
+			if keep[old.ID] {
+				continue
+			}
+			if err := tx.Delete(&old).Error; err != nil {
+				return err
+			}
+			entries = append(entries, mutasi(old.ID, -old.Stok, 0))
+			variantDelta -= old.Stok
+		}
+
+		// Stok di level produk (tanpa varian) disesuaikan agar total ledger tetap sama dengan stok produk
+		newStok := produk.Stok
+		updates := map[string]interface{}{}
+		if len(varian) > 0 {
+			var hargaReseller, hargaKonsumen models.Money
+			newStok, hargaReseller, hargaKonsumen = variantAggregates(varian)
+			updates = map[string]interface{}{
+				"stok":           newStok,
+				"harga_reseller": hargaReseller,
+				"harga_konsumen": hargaKonsumen,
+			}
+		}
+		entries = append(entries, models.MutasiStok{
+			IdProduk:    productID,
+			Jenis:       models.MutasiAdjustment,
+			Jumlah:      newStok - produk.Stok - variantDelta,
+			StokSetelah: newStok,
+			IdUser:      &userID,
+			Referensi:   "product:" + strconv.FormatUint(uint64(productID), 10),
+			Catatan:     "atur varian produk",
+		})
+		if err := recordMutasi(tx, entries...); err != nil {
+			return err
+		}
+
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(produk).Updates(updates).Error
+	})
+}
+
+// releaseSku mengosongkan SkuAktif varian yang cocok dengan query sebelum varian dihapus atau diganti
+func releaseSku(query *gorm.DB) error {
+	return query.Model(&models.VarianProduk{}).Update("sku_aktif", nil).Error
+}
+
+// skuError memetakan pelanggaran unique index SKU per toko menjadi ErrSkuTaken
+func skuError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return ErrSkuTaken
+	}
+	return err
+}
+
+// variantAggregates menghitung total stok dan harga termurah dari daftar varian
+func variantAggregates(varian []models.VarianProduk) (int, models.Money, models.Money) {
+	stok := 0
+	hargaReseller, hargaKonsumen := varian[0].HargaReseller, varian[0].HargaKonsumen
+	for _, v := range varian {
+		stok += v.Stok
+		hargaReseller = min(hargaReseller, v.HargaReseller)
+		hargaKonsumen = min(hargaKonsumen, v.HargaKonsumen)
+	}
+	return stok, hargaReseller, hargaKonsumen
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"evernos-api2/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WishlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) *WishlistRepository {
+	return &WishlistRepository{db: db}
+}
+
+// Wishlist diurutkan dari produk yang terakhir ditambahkan
+var wishlistNewestSort = SortKey{Name: "newest", ID: "wishlists.id", Desc: true}
+
+// Subscriber adalah user yang berlangganan notifikasi stok beserta email-nya
+type Subscriber struct {
+	IdUser uint
+	Email  string
+}
+
+// GetByUserID mengambil wishlist user beserta produknya dengan pagination. Produk yang sedang tidak
+// terbit (draft, arsip, terjadwal, atau belum lolos moderasi) tetap ada di wishlist tanpa detail produk.
+func (r *WishlistRepository) GetByUserID(userID uint, page PageRequest) ([]models.Wishlist, PageResult, error) {
+	query := r.db.Model(&models.Wishlist{}).
+		Joins("JOIN produks ON produks.id = wishlists.id_produk AND produks.deleted_at IS NULL").
+		Where("wishlists.id_user = ?", userID).
+		Preload("Produk", func(db *gorm.DB) *gorm.DB {
+			return db.Scopes(publishedProduk).Preload("FotoProduk", ReadyOrderedPhotos).Scopes(preloadVarian)
+		})
+	return findPage(query, page, wishlistNewestSort, func(w models.Wishlist) uint { return w.ID })
+}
+
+// Add menambahkan produk ke wishlist user; produk yang sudah ada di wishlist diabaikan
+func (r *WishlistRepository) Add(userID uint, productID uint) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Wishlist{IdUser: userID, IdProduk: productID}).Error
+}
+
+// Remove menghapus produk dari wishlist user; mengembalikan false jika produk tidak ada di wishlist
+func (r *WishlistRepository) Remove(userID uint, productID uint) (bool, error) {
+	result := r.db.Where("id_user = ? AND id_produk = ?", userID, productID).Delete(&models.Wishlist{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Subscribe mendaftarkan user untuk notifikasi stok produk; langganan yang sudah ada diabaikan
+func (r *WishlistRepository) Subscribe(userID uint, productID uint) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.LanggananStok{IdUser: userID, IdProduk: productID}).Error
+}
+
+// Unsubscribe membatalkan langganan stok; mengembalikan false jika user tidak berlangganan
+func (r *WishlistRepository) Unsubscribe(userID uint, productID uint) (bool, error) {
+	result := r.db.Where("id_user = ? AND id_produk = ?", userID, productID).Delete(&models.LanggananStok{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetNotifiableProductIDs mengambil ID produk yang punya langganan stok, sudah terbit, dan stoknya
+// tersedia. Dipakai untuk produk terjadwal yang terbit setelah stoknya diisi ulang.
+func (r *WishlistRepository) GetNotifiableProductIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.LanggananStok{}).
+		Distinct().
+		Joins("JOIN produks ON produks.id = langganan_stoks.id_produk AND produks.deleted_at IS NULL").
+		Where("produks.stok > 0").
+		Scopes(publishedProduk).
+		Pluck("langganan_stoks.id_produk", &ids).Error
+	return ids, err
+}
+
+// ClaimSubscribers mengambil lalu menghapus semua langganan stok produk dalam satu transaksi
+// sehingga setiap pelanggan hanya diberi tahu sekali meskipun stok diisi ulang bersamaan
+func (r *WishlistRepository) ClaimSubscribers(productID uint) ([]Subscriber, error) {
+	var subscribers []Subscriber
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Table("langganan_stoks").
+			Select("langganan_stoks.id_user, users.email").
+			Joins("JOIN users ON users.id = langganan_stoks.id_user").
+			Where("langganan_stoks.id_produk = ?", productID).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Scan(&subscribers).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id_produk = ?", productID).Delete(&models.LanggananStok{}).Error
+	})
+	return subscribers, err
+}
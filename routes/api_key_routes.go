@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"evernos-api2/handlers"
+	"evernos-api2/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupApiKeyRoutes(app *fiber.App, apiKeyHandler *handlers.ApiKeyHandler) {
+	// API key hanya bisa dikelola oleh pemilik toko yang login dengan JWT
+	apiKeys := app.Group("/toko/my/api-keys", middleware.AuthMiddleware)
+
+	// GET /toko/my/api-keys - Ambil semua API key toko
+	apiKeys.Get("/", apiKeyHandler.GetMyApiKeys)
+
+	// POST /toko/my/api-keys - Buat API key baru (key hanya ditampilkan sekali)
+	apiKeys.Post("/", apiKeyHandler.CreateApiKey)
+
+	// DELETE /toko/my/api-keys/:id - Cabut API key
+	apiKeys.Delete("/:id", apiKeyHandler.RevokeApiKey)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"evernos-api2/handlers"
+	"evernos-api2/middleware"
+	"evernos-api2/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupImportRoutes(app *fiber.App, importHandler *handlers.ImportHandler, apiKeyService *services.ApiKeyService) {
+	// Import/export produk toko sendiri (JWT atau API key dengan scope products:write)
+	products := app.Group("/toko/my/products", middleware.AuthOrApiKeyMiddleware(apiKeyService, services.ScopeProductsWrite))
+
+	// POST /toko/my/products/import - Upload CSV/XLSX, diproses di background (dry_run=true hanya validasi)
+	products.Post("/import", importHandler.ImportProducts)
+
+	// GET /toko/my/products/import/:id - Status dan error per baris job import
+	products.Get("/import/:id", importHandler.GetImportJob)
+
+	// GET /toko/my/products/export?format=csv|xlsx - Unduh produk dengan kolom yang sama seperti import
+	products.Get("/export", importHandler.ExportProducts)
+}
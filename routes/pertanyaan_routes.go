@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"evernos-api2/handlers"
+	"evernos-api2/middleware"
+	"evernos-api2/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupPertanyaanRoutes(app *fiber.App, pertanyaanHandler *handlers.PertanyaanHandler, apiKeyService *services.ApiKeyService) {
+	// GET /product/:id/questions - Pertanyaan produk (public, upvoted diisi untuk user yang login)
+	app.Get("/product/:id/questions", middleware.OptionalAuthMiddleware(apiKeyService, services.ScopeProductsWrite), pertanyaanHandler.GetProductQuestions)
+
+	// POST /product/:id/questions - Kirim pertanyaan tentang produk
+	app.Post("/product/:id/questions", middleware.AuthMiddleware, pertanyaanHandler.AskQuestion)
+
+	// PUT /questions/:id/answer - Jawaban pemilik toko (JWT atau API key dengan scope products:write)
+	app.Put("/questions/:id/answer", middleware.AuthOrApiKeyMiddleware(apiKeyService, services.ScopeProductsWrite), pertanyaanHandler.AnswerQuestion)
+
+	// POST/DELETE /questions/:id/upvote - Beri atau batalkan upvote
+	app.Post("/questions/:id/upvote", middleware.AuthMiddleware, pertanyaanHandler.UpvoteQuestion)
+	app.Delete("/questions/:id/upvote", middleware.AuthMiddleware, pertanyaanHandler.RemoveUpvoteQuestion)
+}
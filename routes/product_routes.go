@@ -3,17 +3,27 @@ package routes
 import (
 	"evernos-api2/handlers"
 	"evernos-api2/middleware"
+	"evernos-api2/services"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupProductRoutes(app *fiber.App, productHandler *handlers.ProductHandler) {
-	// Public routes - tidak memerlukan autentikasi
-	app.Get("/product", productHandler.GetAllProducts)
-	app.Get("/product/:id", productHandler.GetProductByID)
+func SetupProductRoutes(app *fiber.App, productHandler *handlers.ProductHandler, varianHandler *handlers.VarianHandler, stokHandler *handlers.StokHandler, apiKeyService *services.ApiKeyService) {
+	// Public routes - autentikasi opsional, pemilik toko juga bisa melihat produk draft/terjadwal/diarsipkan
+	optionalAuth := middleware.OptionalAuthMiddleware(apiKeyService, services.ScopeProductsWrite)
+	app.Get("/product", optionalAuth, productHandler.GetAllProducts)
+	app.Get("/product/:id", optionalAuth, productHandler.GetProductByID)
+	app.Get("/product/:id/variants", optionalAuth, varianHandler.GetVariants)
+	app.Get("/toko/:url_toko/product/:slug", optionalAuth, productHandler.GetProductBySlug)
 
-	// Protected routes - memerlukan autentikasi
-	app.Post("/product", middleware.AuthMiddleware, productHandler.CreateProduct)
-	app.Put("/product/:id", middleware.AuthMiddleware, productHandler.UpdateProduct)
-	app.Delete("/product/:id", middleware.AuthMiddleware, productHandler.DeleteProduct)
+	// Protected routes - memerlukan autentikasi (JWT atau API key dengan scope products:write)
+	productsWrite := middleware.AuthOrApiKeyMiddleware(apiKeyService, services.ScopeProductsWrite)
+	app.Post("/product", productsWrite, productHandler.CreateProduct)
+	app.Put("/product/:id", productsWrite, productHandler.UpdateProduct)
+	app.Delete("/product/:id", productsWrite, productHandler.DeleteProduct)
+	app.Put("/product/:id/variants", productsWrite, varianHandler.SetVariants)
+
+	// Riwayat stok hanya dibaca: JWT atau API key dengan scope products:read
+	productsRead := middleware.AuthOrApiKeyMiddleware(apiKeyService, services.ScopeProductsRead)
+	app.Get("/product/:id/stock-history", productsRead, stokHandler.GetStockHistory)
 }
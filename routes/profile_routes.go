@@ -2,16 +2,16 @@ package routes
 
 import (
 	"evernos-api2/handlers"
-	"evernos-api2/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupProfileRoutes(app *fiber.App, profileHandler *handlers.ProfileHandler) {
-	// Grup untuk rute yang butuh autentikasi (wajib pakai token)
-	api := app.Group("/api", middleware.AuthMiddleware)
-
+// SetupProfileRoutes mendaftarkan rute profile ke grup /api yang sudah memakai AuthMiddleware
+func SetupProfileRoutes(api fiber.Router, profileHandler *handlers.ProfileHandler) {
 	// Profile routes
 	api.Get("/profile", profileHandler.GetProfile)
 	api.Put("/profile", profileHandler.UpdateProfile)
-}
\ No newline at end of file
+	api.Put("/profile/password", profileHandler.ChangePassword)
+	api.Get("/profile/export", profileHandler.ExportProfile)
+	api.Delete("/profile", profileHandler.DeleteAccount)
+}
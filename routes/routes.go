@@ -1,20 +1,30 @@
 package routes
 
 import (
+	"log"
 	"evernos-api2/database"
 	"evernos-api2/handlers"
 	"evernos-api2/middleware"
 	"evernos-api2/repositories"
+	"evernos-api2/search"
 	"evernos-api2/services"
+	"evernos-api2/storage"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func SetupRoutes(app *fiber.App) {
 	// Setup dependencies
-	profileRepo := repositories.NewProfileRepository(database.DB)
-	profileService := services.NewProfileService(profileRepo)
-	profileHandler := handlers.NewProfileHandler(profileService)
+	// Session dependencies (dipakai oleh profile untuk mencabut sesi lain)
+	sessionRepo := repositories.NewSessionRepository(database.DB)
+	sessionService := services.NewSessionService(sessionRepo)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+
+	// Notifikasi dependencies (pemberitahuan untuk user, misalnya produk ditolak moderasi)
+	notifikasiRepo := repositories.NewNotifikasiRepository(database.DB)
+	notifikasiService := services.NewNotifikasiService(notifikasiRepo)
+	notifikasiHandler := handlers.NewNotifikasiHandler(notifikasiService)
 
 	// Category dependencies
 	categoryRepo := repositories.NewCategoryRepository(database.DB)
@@ -31,16 +41,80 @@ func SetupRoutes(app *fiber.App) {
 	tokoService := services.NewTokoService(tokoRepo)
 	tokoHandler := handlers.NewTokoHandler(tokoService)
 
-	// Product dependencies
+	// ApiKey dependencies
+	apiKeyRepo := repositories.NewApiKeyRepository(database.DB)
+	apiKeyService := services.NewApiKeyService(apiKeyRepo, tokoRepo)
+	apiKeyHandler := handlers.NewApiKeyHandler(apiKeyService)
+
+	// Product dependencies (perubahan stok dicatat di ledger mutasi stok)
 	productRepo := repositories.NewProductRepository(database.DB)
-	productService := services.NewProductService(productRepo)
+	mutasiStokRepo := repositories.NewMutasiStokRepository(database.DB)
 
-	// FotoProduk dependencies
+	// FotoProduk dependencies (foto diproses oleh worker pool di background, file identik memakai blob yang sama)
 	fotoProdukRepo := repositories.NewFotoProdukRepository(database.DB)
-	fotoProdukService := services.NewFotoProdukService(fotoProdukRepo, productRepo)
+	blobRepo := repositories.NewBlobRepository(database.DB)
+	imageProcessor := services.NewImageProcessor(fotoProdukRepo, blobRepo, storage.Default)
+	if err := imageProcessor.Start(); err != nil {
+		log.Fatal("Failed to start image processor: ", err)
+	}
+	fotoProdukService := services.NewFotoProdukService(fotoProdukRepo, productRepo, blobRepo, imageProcessor, storage.Default)
+
+	// Wishlist dependencies (wishlist dan notifikasi stok tersedia kembali, email opsional lewat SMTP)
+	wishlistRepo := repositories.NewWishlistRepository(database.DB)
+	wishlistService := services.NewWishlistService(wishlistRepo, productRepo, notifikasiService, services.NewEmailService())
+	wishlistService.Start()
+	wishlistHandler := handlers.NewWishlistHandler(wishlistService)
+
+	// Search engine (SEARCH_DRIVER); index in-memory dibangun dari database saat start
+	searchEngine, err := search.NewFromEnv(database.DB)
+	if err != nil {
+		log.Fatal("Failed to initialize search engine: ", err)
+	}
+	productService := services.NewProductService(productRepo, mutasiStokRepo, fotoProdukService, wishlistService, searchEngine)
+	if err := productService.RebuildSearchIndex(); err != nil {
+		log.Fatal("Failed to build search index: ", err)
+	}
+
+	// Profile dependencies (penghapusan akun ikut menghapus produk toko lewat productService)
+	profileRepo := repositories.NewProfileRepository(database.DB)
+	profileService := services.NewProfileService(profileRepo, sessionService, productService)
+	profileHandler := handlers.NewProfileHandler(profileService)
+
+	uploadService := services.NewUploadService(imageProcessor)
+
+	// Product handler (needs productService, fotoProdukService, tokoService, and uploadService)
+	productHandler := handlers.NewProductHandler(productService, fotoProdukService, tokoService, uploadService)
+
+	// Varian dependencies (opsi dan varian per produk dengan SKU, harga, dan stok sendiri)
+	varianRepo := repositories.NewVarianRepository(database.DB)
+	varianService := services.NewVarianService(varianRepo, productRepo, productService)
+	varianHandler := handlers.NewVarianHandler(varianService)
+
+	// Pertanyaan dependencies (tanya jawab produk antara pembeli dan penjual)
+	pertanyaanRepo := repositories.NewPertanyaanRepository(database.DB)
+	pertanyaanService := services.NewPertanyaanService(pertanyaanRepo, productRepo, productService, notifikasiService)
+	pertanyaanHandler := handlers.NewPertanyaanHandler(pertanyaanService)
+
+	// Moderation dependencies (antrian moderasi produk dan pertanyaan untuk admin)
+	moderationService := services.NewModerationService(productRepo, pertanyaanRepo, notifikasiService, wishlistService)
+	moderationHandler := handlers.NewModerationHandler(moderationService)
 
-	// Product handler (needs productService, fotoProdukService, and tokoService)
-	productHandler := handlers.NewProductHandler(productService, fotoProdukService, tokoService)
+	// Ulasan dependencies (ulasan pembeli, agregat rating produk dan toko)
+	ulasanRepo := repositories.NewUlasanRepository(database.DB)
+	ulasanService := services.NewUlasanService(ulasanRepo, productRepo, productService, fotoProdukService, notifikasiService)
+	ulasanHandler := handlers.NewUlasanHandler(ulasanService, uploadService)
+
+	// Stok dependencies (riwayat mutasi stok untuk pemilik produk)
+	stokService := services.NewStokService(mutasiStokRepo, productRepo)
+	stokHandler := handlers.NewStokHandler(stokService)
+
+	// Import dependencies (import/export produk massal, job diproses satu per satu di background)
+	importJobRepo := repositories.NewImportJobRepository(database.DB)
+	importService := services.NewImportService(importJobRepo, tokoRepo, productService)
+	if err := importService.Start(); err != nil {
+		log.Fatal("Failed to start product import worker: ", err)
+	}
+	importHandler := handlers.NewImportHandler(importService)
 
 	// LogProduk dependencies
 	logProdukRepo := repositories.NewLogProdukRepository(database.DB)
@@ -48,14 +122,26 @@ func SetupRoutes(app *fiber.App) {
 
 	// Trx dependencies
 	trxRepo := repositories.NewTrxRepository(database.DB)
-	trxService := services.NewTrxService(trxRepo, logProdukService)
+	trxService := services.NewTrxService(trxRepo, logProdukService, varianRepo)
 	trxHandler := handlers.NewTrxHandler(trxService)
 
 	// Upload dependencies
-	uploadHandler := handlers.NewUploadHandler(fotoProdukService)
+	uploadHandler := handlers.NewUploadHandler(fotoProdukService, productService, uploadService)
+
+	// Resumable upload dependencies (sesi kedaluwarsa dibersihkan setiap jam)
+	uploadSessionRepo := repositories.NewUploadSessionRepository(database.DB)
+	resumableUploadService := services.NewResumableUploadService(uploadSessionRepo, productRepo, fotoProdukService, uploadService, imageProcessor)
+	resumableUploadService.StartJanitor(time.Hour)
+	resumableUploadHandler := handlers.NewResumableUploadHandler(resumableUploadService, "/files")
 
-	// Static file serving untuk uploads
-	app.Static("/uploads", "./uploads")
+	// Static file serving untuk uploads, hanya jika file disimpan di disk lokal.
+	// Driver S3 menyajikan file langsung dari bucket/CDN.
+	if local, ok := storage.Default.(*storage.LocalStorage); ok {
+		app.Static("/uploads", local.Root)
+	}
+
+	// JWKS untuk verifikasi token oleh service lain (public)
+	app.Get("/.well-known/jwks.json", handlers.GetJWKS)
 
 	// Auth routes (public)
 	auth := app.Group("/auth")
@@ -78,23 +164,52 @@ func SetupRoutes(app *fiber.App) {
 	// Toko routes (mixed public and protected)
 	SetupTokoRoutes(app, tokoHandler)
 
+	// API key routes (authentication required)
+	SetupApiKeyRoutes(app, apiKeyHandler)
+
 	// Product routes (mixed public and protected)
-	SetupProductRoutes(app, productHandler)
+	SetupProductRoutes(app, productHandler, varianHandler, stokHandler, apiKeyService)
+
+	// Review routes (public GET, protected POST/PUT)
+	SetupUlasanRoutes(app, ulasanHandler, apiKeyService)
+
+	// Product Q&A routes (public GET, protected POST/PUT/DELETE)
+	SetupPertanyaanRoutes(app, pertanyaanHandler, apiKeyService)
+
+	// Wishlist routes (authentication required)
+	SetupWishlistRoutes(app, wishlistHandler)
+
+	// Product import/export routes (authentication required)
+	SetupImportRoutes(app, importHandler, apiKeyService)
 
 	// Trx routes (authentication required)
-	SetupTrxRoutes(app, trxHandler)
+	SetupTrxRoutes(app, trxHandler, apiKeyService)
 
 	// Upload routes (authentication required)
-	SetupUploadRoutes(app, uploadHandler)
+	SetupUploadRoutes(app, uploadHandler, resumableUploadHandler, apiKeyService)
 
 	// Protected routes
 	api := app.Group("/api", middleware.AuthMiddleware)
 
 	// Profile routes
-	api.Get("/profile", profileHandler.GetProfile)
-	api.Put("/profile", profileHandler.UpdateProfile)
+	SetupProfileRoutes(api, profileHandler)
+
+	// Session routes (perangkat yang sedang login)
+	api.Get("/sessions", sessionHandler.GetSessions)
+	api.Delete("/sessions/:id", sessionHandler.RevokeSession)
+
+	// Notification routes
+	api.Get("/notifications", notifikasiHandler.GetNotifications)
+	api.Put("/notifications/read", notifikasiHandler.MarkAllNotificationsRead)
+	api.Put("/notifications/:id/read", notifikasiHandler.MarkNotificationRead)
 
 	// Admin routes
+	api.Get("/admin/moderation/products", middleware.AdminMiddleware, moderationHandler.GetModerationQueue)
+	api.Post("/admin/moderation/products/:id/approve", middleware.AdminMiddleware, moderationHandler.ApproveProduct)
+	api.Post("/admin/moderation/products/:id/reject", middleware.AdminMiddleware, moderationHandler.RejectProduct)
+	api.Get("/admin/moderation/questions", middleware.AdminMiddleware, moderationHandler.GetQuestionModeration)
+	api.Post("/admin/moderation/questions/:id/hide", middleware.AdminMiddleware, moderationHandler.HideQuestion)
+	api.Post("/admin/moderation/questions/:id/unhide", middleware.AdminMiddleware, moderationHandler.UnhideQuestion)
 	api.Get("/admin/data", middleware.AdminMiddleware, func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
 			"message": "This is a secret admin data.",
@@ -3,11 +3,18 @@ package routes
 import (
 	"evernos-api2/handlers"
 	"evernos-api2/middleware"
+	"evernos-api2/services"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupTrxRoutes(app *fiber.App, trxHandler *handlers.TrxHandler) {
+func SetupTrxRoutes(app *fiber.App, trxHandler *handlers.TrxHandler, apiKeyService *services.ApiKeyService) {
+	// GET /toko/my/orders - Pesanan yang masuk ke toko user (JWT atau API key dengan scope orders:read)
+	app.Get("/toko/my/orders", middleware.AuthOrApiKeyMiddleware(apiKeyService, services.ScopeOrdersRead), trxHandler.GetTokoOrders)
+
+	// POST /toko/my/orders/items/:detail_id/return - Mencatat retur item pesanan toko dan mengembalikan stoknya
+	app.Post("/toko/my/orders/items/:detail_id/return", middleware.AuthMiddleware, trxHandler.ReturnItem)
+
 	// Semua endpoint transaksi memerlukan autentikasi
 	trx := app.Group("/trx", middleware.AuthMiddleware)
 
@@ -19,4 +26,7 @@ func SetupTrxRoutes(app *fiber.App, trxHandler *handlers.TrxHandler) {
 
 	// POST /trx - Membuat transaksi baru
 	trx.Post("/", trxHandler.CreateTrx)
+
+	// POST /trx/:id/cancel - Membatalkan transaksi dan mengembalikan stok
+	trx.Post("/:id/cancel", trxHandler.CancelTrx)
 }
\ No newline at end of file
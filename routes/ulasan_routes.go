@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"evernos-api2/handlers"
+	"evernos-api2/middleware"
+	"evernos-api2/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupUlasanRoutes(app *fiber.App, ulasanHandler *handlers.UlasanHandler, apiKeyService *services.ApiKeyService) {
+	// GET /product/:id/reviews - Ulasan produk dan ringkasan rating (public, pemilik toko juga bisa melihat produk draft)
+	app.Get("/product/:id/reviews", middleware.OptionalAuthMiddleware(apiKeyService, services.ScopeProductsWrite), ulasanHandler.GetProductReviews)
+
+	// POST /product/:id/reviews - Ulasan pembeli untuk produk yang pernah dibeli
+	app.Post("/product/:id/reviews", middleware.AuthMiddleware, ulasanHandler.CreateReview)
+
+	// PUT /reviews/:id/reply - Balasan penjual (JWT atau API key dengan scope products:write)
+	app.Put("/reviews/:id/reply", middleware.AuthOrApiKeyMiddleware(apiKeyService, services.ScopeProductsWrite), ulasanHandler.ReplyReview)
+}
@@ -3,13 +3,17 @@ package routes
 import (
 	"evernos-api2/handlers"
 	"evernos-api2/middleware"
+	"evernos-api2/services"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupUploadRoutes(app *fiber.App, uploadHandler *handlers.UploadHandler) {
+func SetupUploadRoutes(app *fiber.App, uploadHandler *handlers.UploadHandler, resumableUploadHandler *handlers.ResumableUploadHandler, apiKeyService *services.ApiKeyService) {
+	// Upload foto bisa dilakukan dengan JWT atau API key dengan scope products:write
+	productsWrite := middleware.AuthOrApiKeyMiddleware(apiKeyService, services.ScopeProductsWrite)
+
 	// Group untuk upload dengan authentication middleware
-	upload := app.Group("/upload", middleware.AuthMiddleware)
+	upload := app.Group("/upload", productsWrite)
 
 	// POST /upload/product/assign - Upload dan assign single foto ke produk
 	upload.Post("/product/assign", uploadHandler.UploadAndAssignToProduct)
@@ -18,8 +22,27 @@ func SetupUploadRoutes(app *fiber.App, uploadHandler *handlers.UploadHandler) {
 	upload.Post("/product/assign-multiple", uploadHandler.UploadMultipleAndAssignToProduct)
 
 	// DELETE /product/photo/:foto_id - Hapus foto produk berdasarkan ID foto
-	app.Delete("/product/photo/:foto_id", middleware.AuthMiddleware, uploadHandler.DeleteProductPhoto)
+	app.Delete("/product/photo/:foto_id", productsWrite, uploadHandler.DeleteProductPhoto)
+
+	// PUT /product/:id/photos/order - Atur ulang urutan semua foto produk
+	app.Put("/product/:id/photos/order", productsWrite, uploadHandler.ReorderProductPhotos)
+
+	// PUT /product/photo/:foto_id/primary - Jadikan foto sebagai foto utama produk
+	app.Put("/product/photo/:foto_id/primary", productsWrite, uploadHandler.SetPrimaryPhoto)
+
+	// PUT /product/photo/:foto_id - Perbarui alt text foto
+	app.Put("/product/photo/:foto_id", productsWrite, uploadHandler.UpdatePhotoAltText)
+
+	// GET /product/photos/:product_id - Ambil semua foto dari produk tertentu (public, foto produk
+	// draft/terjadwal/diarsipkan hanya untuk pemilik toko)
+	app.Get("/product/photos/:product_id", middleware.OptionalAuthMiddleware(apiKeyService, services.ScopeProductsWrite), uploadHandler.GetProductPhotos)
 
-	// GET /product/photos/:product_id - Ambil semua foto dari produk tertentu (public)
-	app.Get("/product/photos/:product_id", uploadHandler.GetProductPhotos)
+	// Resumable upload (protokol tus 1.0.0) untuk video dan foto besar.
+	// OPTIONS tidak memerlukan autentikasi agar client bisa membaca kemampuan server.
+	app.Options("/files", resumableUploadHandler.Options)
+	files := app.Group("/files", resumableUploadHandler.RequireTusResumable, productsWrite)
+	files.Post("/", resumableUploadHandler.Create)
+	files.Head("/:id", resumableUploadHandler.Head)
+	files.Patch("/:id", resumableUploadHandler.Patch)
+	files.Delete("/:id", resumableUploadHandler.Delete)
 }
\ No newline at end of file
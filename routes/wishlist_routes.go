@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"evernos-api2/handlers"
+	"evernos-api2/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupWishlistRoutes(app *fiber.App, wishlistHandler *handlers.WishlistHandler) {
+	// Semua endpoint wishlist memerlukan autentikasi
+	wishlist := app.Group("/wishlist", middleware.AuthMiddleware)
+
+	// GET /wishlist - Mengambil wishlist user dengan pagination
+	wishlist.Get("/", wishlistHandler.GetWishlist)
+
+	// POST /wishlist - Menambahkan produk ke wishlist
+	wishlist.Post("/", wishlistHandler.AddToWishlist)
+
+	// DELETE /wishlist/:product_id - Menghapus produk dari wishlist
+	wishlist.Delete("/:product_id", wishlistHandler.RemoveFromWishlist)
+
+	// POST/DELETE /product/:id/back-in-stock - Langganan notifikasi saat produk yang habis tersedia kembali
+	app.Post("/product/:id/back-in-stock", middleware.AuthMiddleware, wishlistHandler.SubscribeBackInStock)
+	app.Delete("/product/:id/back-in-stock", middleware.AuthMiddleware, wishlistHandler.UnsubscribeBackInStock)
+}
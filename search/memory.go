@@ -0,0 +1,152 @@
+// file: search/memory.go
+
+package search
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Bobot token per field dokumen
+const (
+	weightNama      = 3.0
+	weightCategory  = 1.5
+	weightToko      = 1.5
+	weightDeskripsi = 1.0
+)
+
+// MemoryEngine adalah inverted index di memori dengan pencocokan awalan dan toleransi typo
+// (jarak Levenshtein). Cocok untuk satu instance API atau katalog kecil; index dibangun
+// ulang dari database saat start dan diperbarui setiap kali produk berubah.
+type MemoryEngine struct {
+	mu       sync.RWMutex
+	docs     map[uint]Document
+	postings map[string]map[uint]float64
+}
+
+func NewMemoryEngine() *MemoryEngine {
+	return &MemoryEngine{
+		docs:     make(map[uint]Document),
+		postings: make(map[string]map[uint]float64),
+	}
+}
+
+// Search menilai setiap kata query terhadap seluruh kosakata index. Untuk tiap dokumen hanya
+// token terbaik per kata yang dihitung, lalu skor dikalikan proporsi kata query yang cocok
+// sehingga dokumen yang cocok dengan semua kata berada di atas.
+func (e *MemoryEngine) Search(ctx context.Context, q Query) ([]Hit, error) {
+	terms := Tokenize(q.Text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	e.mu.RLock()
+	scores := make(map[uint]float64)
+	matched := make(map[uint]int)
+	for _, term := range terms {
+		best := make(map[uint]float64)
+		for token, docs := range e.postings {
+			weight := matchWeight(term, token)
+			if weight == 0 {
+				continue
+			}
+			for id, tf := range docs {
+				if score := weight * tf; score > best[id] {
+					best[id] = score
+				}
+			}
+		}
+		for id, score := range best {
+			scores[id] += score
+			matched[id]++
+		}
+	}
+	e.mu.RUnlock()
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score * float64(matched[id]) / float64(len(terms))})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID > hits[j].ID
+	})
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	return hits, nil
+}
+
+func (e *MemoryEngine) Index(ctx context.Context, docs ...Document) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, doc := range docs {
+		e.remove(doc.ID)
+		e.add(doc)
+	}
+	return nil
+}
+
+func (e *MemoryEngine) Delete(ctx context.Context, ids ...uint) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, id := range ids {
+		e.remove(id)
+	}
+	return nil
+}
+
+func (e *MemoryEngine) Rebuild(ctx context.Context, load Loader) error {
+	docs, err := load()
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.docs = make(map[uint]Document, len(docs))
+	e.postings = make(map[string]map[uint]float64)
+	for _, doc := range docs {
+		e.add(doc)
+	}
+	return nil
+}
+
+func (e *MemoryEngine) add(doc Document) {
+	e.docs[doc.ID] = doc
+	for _, field := range []struct {
+		text   string
+		weight float64
+	}{
+		{doc.NamaProduk, weightNama},
+		{doc.NamaCategory, weightCategory},
+		{doc.NamaToko, weightToko},
+		{doc.Deskripsi, weightDeskripsi},
+	} {
+		for _, token := range Tokenize(field.text) {
+			if e.postings[token] == nil {
+				e.postings[token] = make(map[uint]float64)
+			}
+			e.postings[token][doc.ID] += field.weight
+		}
+	}
+}
+
+func (e *MemoryEngine) remove(id uint) {
+	doc, ok := e.docs[id]
+	if !ok {
+		return
+	}
+	delete(e.docs, id)
+	for _, text := range []string{doc.NamaProduk, doc.NamaCategory, doc.NamaToko, doc.Deskripsi} {
+		for _, token := range Tokenize(text) {
+			delete(e.postings[token], id)
+			if len(e.postings[token]) == 0 {
+				delete(e.postings, token)
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// file: search/mysql.go
+
+package search
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// MySQLEngine memakai FULLTEXT index MySQL (parser ngram) pada nama dan deskripsi produk,
+// nama kategori, dan nama toko. Index dikelola MySQL sendiri sehingga Index, Delete, dan
+// Rebuild tidak melakukan apa-apa. Parser ngram membuat pencarian tetap menemukan kata
+// dengan sedikit typo karena sebagian besar potongan 2 hurufnya masih sama.
+type MySQLEngine struct {
+	db *gorm.DB
+}
+
+func NewMySQLEngine(db *gorm.DB) *MySQLEngine {
+	return &MySQLEngine{db: db}
+}
+
+// Bobot relevansi per kolom: kecocokan di nama produk paling penting
+const mysqlScoreSQL = `
+	MATCH(produks.nama_produk) AGAINST (? IN NATURAL LANGUAGE MODE) * 3 +
+	MATCH(produks.deskripsi) AGAINST (? IN NATURAL LANGUAGE MODE) +
+	COALESCE(MATCH(categories.nama_category) AGAINST (? IN NATURAL LANGUAGE MODE), 0) * 1.5 +
+	COALESCE(MATCH(tokos.nama_toko) AGAINST (? IN NATURAL LANGUAGE MODE), 0) * 1.5`
+
+func (e *MySQLEngine) Search(ctx context.Context, q Query) ([]Hit, error) {
+	var hits []Hit
+	err := e.db.WithContext(ctx).Table("produks").
+		Select("produks.id AS id, ("+mysqlScoreSQL+") AS score", q.Text, q.Text, q.Text, q.Text).
+		Joins("LEFT JOIN categories ON categories.id = produks.id_category").
+		Joins("LEFT JOIN tokos ON tokos.id = produks.id_toko").
+		Where("produks.deleted_at IS NULL").
+		Having("score > 0").
+		Order("score DESC, produks.id DESC").
+		Limit(q.Limit).
+		Scan(&hits).Error
+	return hits, err
+}
+
+func (e *MySQLEngine) Index(ctx context.Context, docs ...Document) error {
+	return nil
+}
+
+func (e *MySQLEngine) Delete(ctx context.Context, ids ...uint) error {
+	return nil
+}
+
+func (e *MySQLEngine) Rebuild(ctx context.Context, load Loader) error {
+	return nil
+}
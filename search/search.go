@@ -0,0 +1,235 @@
+// file: search/search.go
+
+package search
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Engine adalah backend pencarian produk. Engine hanya mengembalikan ID produk yang cocok
+// beserta skor relevansinya; filter lain (kategori, toko, harga) dan pagination tetap
+// dilakukan di database.
+type Engine interface {
+	// Search mengembalikan produk yang cocok dengan query, diurutkan dari yang paling relevan
+	Search(ctx context.Context, q Query) ([]Hit, error)
+	// Index menambah atau memperbarui dokumen produk
+	Index(ctx context.Context, docs ...Document) error
+	// Delete menghapus produk dari index
+	Delete(ctx context.Context, ids ...uint) error
+	// Rebuild mengganti seluruh isi index dengan dokumen dari load.
+	// Engine yang membaca langsung dari database tidak memanggil load.
+	Rebuild(ctx context.Context, load Loader) error
+}
+
+// Loader memuat semua dokumen produk untuk Rebuild
+type Loader func() ([]Document, error)
+
+// Document adalah data produk yang bisa dicari
+type Document struct {
+	ID           uint
+	NamaProduk   string
+	Deskripsi    string
+	NamaCategory string
+	NamaToko     string
+}
+
+// Query adalah parameter pencarian. Limit membatasi jumlah hit yang dikembalikan.
+type Query struct {
+	Text  string
+	Limit int
+}
+
+// Hit adalah satu produk hasil pencarian
+type Hit struct {
+	ID    uint
+	Score float64
+}
+
+// NewFromEnv membuat engine sesuai SEARCH_DRIVER ("mysql" atau "memory")
+func NewFromEnv(db *gorm.DB) (Engine, error) {
+	switch driver := strings.ToLower(os.Getenv("SEARCH_DRIVER")); driver {
+	case "", "mysql":
+		return NewMySQLEngine(db), nil
+	case "memory":
+		return NewMemoryEngine(), nil
+	default:
+		return nil, fmt.Errorf("SEARCH_DRIVER tidak dikenal: %s", driver)
+	}
+}
+
+// MinTokenLength adalah panjang kata minimum yang bisa dicari lewat engine (ngram_token_size bawaan
+// MySQL). Query yang semua katanya lebih pendek dicari dengan LIKE oleh pemanggil.
+const MinTokenLength = 2
+
+// Searchable mengecek apakah query punya minimal satu kata yang cukup panjang untuk dicari lewat engine
+func Searchable(text string) bool {
+	for _, token := range Tokenize(text) {
+		if len([]rune(token)) >= MinTokenLength {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxHits adalah jumlah maksimum hit per pencarian (SEARCH_MAX_HITS, default 1000)
+func MaxHits() int {
+	if v, err := strconv.Atoi(os.Getenv("SEARCH_MAX_HITS")); err == nil && v > 0 {
+		return v
+	}
+	return 1000
+}
+
+// Tokenize memecah teks menjadi kata huruf kecil (huruf dan angka saja)
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// matchWeight menilai seberapa cocok token dokumen dengan kata query:
+// 1 untuk sama persis, 0.7 untuk awalan, 0.5 untuk typo kecil, 0 jika tidak cocok.
+func matchWeight(term, token string) float64 {
+	if term == token {
+		return 1
+	}
+	if len([]rune(term)) >= 3 && strings.HasPrefix(token, term) {
+		return 0.7
+	}
+	if maxEdits := allowedEdits(term); maxEdits > 0 && editDistance(term, token, maxEdits) <= maxEdits {
+		return 0.5
+	}
+	return 0
+}
+
+// allowedEdits adalah jumlah typo yang ditoleransi berdasarkan panjang kata
+func allowedEdits(term string) int {
+	switch n := len([]rune(term)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance menghitung jarak Levenshtein, berhenti lebih awal jika melebihi max
+func editDistance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > max || -diff > max {
+		return max + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// Highlight meng-escape teks untuk HTML dan membungkus kata yang cocok dengan query dalam <mark>
+func Highlight(text, query string) string {
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return html.EscapeString(text)
+	}
+
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			start := i
+			for i < len(runes) && !isWordRune(runes[i]) {
+				i++
+			}
+			b.WriteString(html.EscapeString(string(runes[start:i])))
+			continue
+		}
+
+		start := i
+		for i < len(runes) && isWordRune(runes[i]) {
+			i++
+		}
+		word := string(runes[start:i])
+		if matchesAny(strings.ToLower(word), terms) {
+			b.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(word))
+		}
+	}
+	return b.String()
+}
+
+// Snippet memotong teks panjang menjadi maksimal maxLen karakter di sekitar kata pertama yang
+// cocok dengan query, lalu menerapkan Highlight
+func Snippet(text, query string, maxLen int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLen {
+		return Highlight(text, query)
+	}
+
+	terms := Tokenize(query)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		if !isWordRune(runes[i]) || (i > 0 && isWordRune(runes[i-1])) {
+			continue
+		}
+		end := i
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+		if matchesAny(strings.ToLower(string(runes[i:end])), terms) {
+			start = max(0, i-maxLen/4)
+			break
+		}
+	}
+	end := min(len(runes), start+maxLen)
+	start = max(0, end-maxLen)
+
+	snippet := Highlight(string(runes[start:end]), query)
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+func matchesAny(word string, terms []string) bool {
+	for _, term := range terms {
+		if matchWeight(term, word) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"evernos-api2/models"
+	"evernos-api2/repositories"
+	"strings"
+	"time"
+)
+
+const (
+	ScopeProductsRead  = "products:read"
+	ScopeProductsWrite = "products:write"
+	ScopeOrdersRead    = "orders:read"
+
+	apiKeyPrefix = "evk_"
+	// Interval minimum antar update last_used_at agar tidak menulis ke database di setiap request
+	apiKeyTouchInterval = time.Minute
+)
+
+// AvailableScopes adalah daftar permission yang bisa diberikan ke API key
+var AvailableScopes = []string{ScopeProductsRead, ScopeProductsWrite, ScopeOrdersRead}
+
+type ApiKeyService struct {
+	apiKeyRepo *repositories.ApiKeyRepository
+	tokoRepo   *repositories.TokoRepository
+}
+
+func NewApiKeyService(apiKeyRepo *repositories.ApiKeyRepository, tokoRepo *repositories.TokoRepository) *ApiKeyService {
+	return &ApiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		tokoRepo:   tokoRepo,
+	}
+}
+
+// CreateApiKey membuat API key baru untuk toko milik user. Key asli hanya dikembalikan sekali.
+func (s *ApiKeyService) CreateApiKey(userID uint, data map[string]interface{}) (string, *models.ApiKey, error) {
+	toko, err := s.tokoRepo.GetByUserID(userID)
+	if err != nil {
+		return "", nil, errors.New("user belum memiliki toko")
+	}
+
+	nama, ok := data["nama"].(string)
+	if !ok || strings.TrimSpace(nama) == "" {
+		return "", nil, errors.New("nama API key tidak boleh kosong")
+	}
+	if len(nama) > 255 {
+		return "", nil, errors.New("nama API key maksimal 255 karakter")
+	}
+
+	scopes, err := s.parseScopes(data["scopes"])
+	if err != nil {
+		return "", nil, err
+	}
+
+	rawKey, err := generateApiKey()
+	if err != nil {
+		return "", nil, errors.New("gagal membuat API key")
+	}
+
+	apiKey := &models.ApiKey{
+		IdToko:  toko.ID,
+		IdUser:  userID,
+		Nama:    strings.TrimSpace(nama),
+		Prefix:  rawKey[:len(apiKeyPrefix)+8],
+		KeyHash: hashApiKey(rawKey),
+		Scopes:  strings.Join(scopes, ","),
+	}
+
+	if err := s.apiKeyRepo.Create(apiKey); err != nil {
+		return "", nil, errors.New("gagal menyimpan API key")
+	}
+
+	return rawKey, apiKey, nil
+}
+
+// GetMyApiKeys mengambil semua API key toko milik user
+func (s *ApiKeyService) GetMyApiKeys(userID uint) ([]models.ApiKey, error) {
+	toko, err := s.tokoRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, errors.New("user belum memiliki toko")
+	}
+	return s.apiKeyRepo.GetByTokoID(toko.ID)
+}
+
+// RevokeApiKey mencabut API key milik toko user
+func (s *ApiKeyService) RevokeApiKey(id uint, userID uint) error {
+	toko, err := s.tokoRepo.GetByUserID(userID)
+	if err != nil {
+		return errors.New("user belum memiliki toko")
+	}
+
+	apiKey, err := s.apiKeyRepo.GetByID(id, toko.ID)
+	if err != nil {
+		return errors.New("API key tidak ditemukan")
+	}
+	if apiKey.RevokedAt != nil {
+		return errors.New("API key sudah dicabut")
+	}
+
+	if err := s.apiKeyRepo.Revoke(id, toko.ID); err != nil {
+		return errors.New("gagal mencabut API key")
+	}
+	return nil
+}
+
+// Authenticate memvalidasi API key dan mengembalikan data key beserta toko pemiliknya
+func (s *ApiKeyService) Authenticate(rawKey string) (*models.ApiKey, *models.Toko, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, nil, errors.New("API key tidak valid")
+	}
+
+	apiKey, err := s.apiKeyRepo.GetActiveByHash(hashApiKey(rawKey))
+	if err != nil {
+		return nil, nil, errors.New("API key tidak valid")
+	}
+
+	toko, err := s.tokoRepo.GetByID(apiKey.IdToko)
+	if err != nil {
+		return nil, nil, errors.New("API key tidak valid")
+	}
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.apiKeyRepo.TouchLastUsed(apiKey.ID, now); err == nil {
+			apiKey.LastUsedAt = &now
+		}
+	}
+
+	return apiKey, toko, nil
+}
+
+// HasScope mengecek apakah API key memiliki permission tertentu
+func (s *ApiKeyService) HasScope(apiKey *models.ApiKey, scope string) bool {
+	for _, granted := range strings.Split(apiKey.Scopes, ",") {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// parseScopes memvalidasi daftar scope dari request body
+func (s *ApiKeyService) parseScopes(raw interface{}) ([]string, error) {
+	items, ok := raw.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, errors.New("scopes harus berupa array dan tidak boleh kosong")
+	}
+
+	seen := map[string]bool{}
+	var scopes []string
+	for _, item := range items {
+		scope, ok := item.(string)
+		if !ok || !isAvailableScope(scope) {
+			return nil, errors.New("scope tidak valid. Gunakan salah satu dari: " + strings.Join(AvailableScopes, ", "))
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes, nil
+}
+
+func isAvailableScope(scope string) bool {
+	for _, available := range AvailableScopes {
+		if scope == available {
+			return true
+		}
+	}
+	return false
+}
+
+// generateApiKey membuat key acak dengan prefix evk_
+func generateApiKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashApiKey menghitung hash SHA-256 dari API key. Key sudah acak 256-bit sehingga tidak perlu bcrypt.
+func hashApiKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -25,15 +25,16 @@ func (s *CategoryService) GetCategoryByID(id uint) (*models.Category, error) {
 	return s.categoryRepo.GetByID(id)
 }
 
-// CreateCategory creates a new category
-func (s *CategoryService) CreateCategory(namaCategory string) (*models.Category, error) {
+// CreateCategory creates a new category. requiresModeration mewajibkan produk di kategori ini dimoderasi admin.
+func (s *CategoryService) CreateCategory(namaCategory string, requiresModeration bool) (*models.Category, error) {
 	// Validate input
 	if strings.TrimSpace(namaCategory) == "" {
 		return nil, errors.New("nama category tidak boleh kosong")
 	}
 
 	category := &models.Category{
-		NamaCategory: strings.TrimSpace(namaCategory),
+		NamaCategory:       strings.TrimSpace(namaCategory),
+		RequiresModeration: requiresModeration,
 	}
 
 	err := s.categoryRepo.Create(category)
@@ -44,8 +45,9 @@ func (s *CategoryService) CreateCategory(namaCategory string) (*models.Category,
 	return category, nil
 }
 
-// UpdateCategory updates an existing category
-func (s *CategoryService) UpdateCategory(id uint, namaCategory string) (*models.Category, error) {
+// UpdateCategory updates an existing category. requiresModeration nil berarti tidak diubah; mengaktifkan
+// moderasi hanya berlaku untuk produk baru atau yang diubah setelahnya.
+func (s *CategoryService) UpdateCategory(id uint, namaCategory string, requiresModeration *bool) (*models.Category, error) {
 	// Validate input
 	if strings.TrimSpace(namaCategory) == "" {
 		return nil, errors.New("nama category tidak boleh kosong")
@@ -59,6 +61,9 @@ func (s *CategoryService) UpdateCategory(id uint, namaCategory string) (*models.
 
 	// Update category
 	category.NamaCategory = strings.TrimSpace(namaCategory)
+	if requiresModeration != nil {
+		category.RequiresModeration = *requiresModeration
+	}
 	err = s.categoryRepo.Update(category)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// EmailService mengirim email lewat SMTP. Pengiriman email nonaktif jika SMTP_HOST kosong.
+type EmailService struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func NewEmailService() *EmailService {
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = os.Getenv("SMTP_USERNAME")
+	}
+
+	return &EmailService{
+		host:     strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		port:     port,
+		username: os.Getenv("SMTP_USERNAME"),
+		password: os.Getenv("SMTP_PASSWORD"),
+		from:     from,
+	}
+}
+
+// Enabled bernilai true jika SMTP sudah dikonfigurasi
+func (s *EmailService) Enabled() bool {
+	return s.host != "" && s.from != ""
+}
+
+// Send mengirim email teks biasa ke satu penerima
+func (s *EmailService) Send(to, subject, body string) error {
+	if !s.Enabled() {
+		return nil
+	}
+	// Cegah header injection dari alamat atau subjek yang berisi baris baru
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return fmt.Errorf("alamat atau subjek email tidak valid")
+	}
+
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	msg := "From: " + s.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+	return smtp.SendMail(net.JoinHostPort(s.host, s.port), auth, s.from, []string{to}, []byte(msg))
+}
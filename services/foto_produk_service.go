@@ -1,107 +1,311 @@
 package services
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"evernos-api2/models"
 	"evernos-api2/repositories"
+	"evernos-api2/storage"
 	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 type FotoProdukService struct {
 	fotoProdukRepo *repositories.FotoProdukRepository
 	productRepo    *repositories.ProductRepository
+	blobRepo       *repositories.BlobRepository
+	imageProcessor *ImageProcessor
+	storage        storage.Storage
 }
 
-func NewFotoProdukService(fotoProdukRepo *repositories.FotoProdukRepository, productRepo *repositories.ProductRepository) *FotoProdukService {
+func NewFotoProdukService(fotoProdukRepo *repositories.FotoProdukRepository, productRepo *repositories.ProductRepository, blobRepo *repositories.BlobRepository, imageProcessor *ImageProcessor, store storage.Storage) *FotoProdukService {
 	return &FotoProdukService{
 		fotoProdukRepo: fotoProdukRepo,
 		productRepo:    productRepo,
+		blobRepo:       blobRepo,
+		imageProcessor: imageProcessor,
+		storage:        store,
 	}
 }
 
-// AddPhotoToProduct menambahkan foto ke produk
-func (s *FotoProdukService) AddPhotoToProduct(productID uint, userID uint, photoURL string) (*models.FotoProduk, error) {
-	// Cek apakah produk ada
-	productExists, err := s.productRepo.CheckExists(productID)
+// AddPhotoToProduct menambahkan foto ke produk. File mentah di sourcePath diproses
+// di background; foto berstatus processing sampai semua rendisi selesai dibuat.
+// Foto dengan isi yang sama (SHA-256) memakai blob yang sudah ada tanpa diproses ulang.
+func (s *FotoProdukService) AddPhotoToProduct(productID uint, userID uint, sourcePath string) (*models.FotoProduk, error) {
+	if err := s.checkProductAccess(productID, userID); err != nil {
+		return nil, err
+	}
+
+	// Foto baru ditaruh di urutan terakhir; foto pertama produk otomatis menjadi foto utama
+	urutan, hasPrimary, err := s.nextPosition(productID)
 	if err != nil {
-		return nil, errors.New("gagal mengecek produk")
+		return nil, err
 	}
-	if !productExists {
-		return nil, errors.New("produk tidak ditemukan")
+
+	fotoProduk, err := s.attachImage(productID, sourcePath, urutan, !hasPrimary)
+	if err != nil {
+		return nil, err
+	}
+	// Foto baru belum pernah diperiksa admin
+	if err := resubmitModeration(s.productRepo, productID); err != nil {
+		return nil, err
 	}
+	return fotoProduk, nil
+}
 
-	// Cek ownership (user harus pemilik toko yang memiliki produk)
-	isOwner, err := s.productRepo.CheckOwnership(productID, userID)
+// AddMultiplePhotosToProduct menambahkan multiple foto ke produk untuk diproses di background
+func (s *FotoProdukService) AddMultiplePhotosToProduct(productID uint, userID uint, sourcePaths []string) ([]models.FotoProduk, error) {
+	if err := s.checkProductAccess(productID, userID); err != nil {
+		return nil, err
+	}
+
+	urutan, hasPrimary, err := s.nextPosition(productID)
 	if err != nil {
-		return nil, errors.New("gagal mengecek kepemilikan produk")
+		return nil, err
 	}
-	if !isOwner {
-		return nil, errors.New("anda tidak memiliki akses untuk menambahkan foto ke produk ini")
+
+	// Lampirkan sesuai urutan upload; jika gagal (misalnya antrian penuh), foto sisanya dibatalkan
+	var fotoProduks []models.FotoProduk
+	for i, sourcePath := range sourcePaths {
+		fotoProduk, err := s.attachImage(productID, sourcePath, urutan+i, !hasPrimary && i == 0)
+		if err != nil {
+			for _, remaining := range sourcePaths[i+1:] {
+				os.Remove(remaining)
+			}
+			if i == 0 {
+				return nil, err
+			}
+			break
+		}
+		fotoProduks = append(fotoProduks, *fotoProduk)
+	}
+
+	if err := resubmitModeration(s.productRepo, productID); err != nil {
+		return nil, err
+	}
+	return fotoProduks, nil
+}
+
+// AddVideoToProduct melampirkan video hasil resumable upload ke produk. Video tidak diproses
+// ulang, file langsung disimpan ke storage dan foto berstatus ready. Video tidak pernah
+// otomatis menjadi foto utama.
+func (s *FotoProdukService) AddVideoToProduct(productID uint, userID uint, sourcePath string, fileName string, contentType string) (*models.FotoProduk, error) {
+	if err := s.checkProductAccess(productID, userID); err != nil {
+		return nil, err
+	}
+
+	urutan, _, err := s.nextPosition(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	sha, size, err := fileSHA256(sourcePath)
+	if err != nil {
+		return nil, errors.New("gagal membaca file video")
+	}
+
+	blob, needsUpload, err := s.blobRepo.Acquire(&models.Blob{Sha256: sha, MediaType: models.MediaTypeVideo, Size: size, Owner: s.imageProcessor.instanceID})
+	if err != nil {
+		return nil, errors.New("gagal menyimpan foto produk")
+	}
+
+	ctx := context.Background()
+	if needsUpload {
+		key := "products/" + sha + strings.ToLower(filepath.Ext(fileName))
+		if err := s.putFile(ctx, key, sourcePath, size, contentType); err != nil {
+			s.failBlob(blob.ID)
+			s.releaseBlob(blob.ID)
+			return nil, errors.New("gagal menyimpan video")
+		}
+		if err := s.blobRepo.MarkReady(blob.ID, models.Blob{Key: key}); err != nil {
+			s.failBlob(blob.ID)
+			s.releaseBlob(blob.ID)
+			return nil, errors.New("gagal menyimpan video")
+		}
+		blob.Key = key
 	}
 
-	// Buat foto produk baru
 	fotoProduk := &models.FotoProduk{
-		IdProduk: productID,
-		Url:      photoURL,
+		IdProduk:  productID,
+		IdBlob:    &blob.ID,
+		Status:    models.FotoStatusReady,
+		MediaType: models.MediaTypeVideo,
+		Key:       blob.Key,
+		Urutan:    urutan,
+	}
+	if err := s.fotoProdukRepo.Create(fotoProduk); err != nil {
+		s.releaseBlob(blob.ID)
+		return nil, errors.New("gagal menyimpan foto produk")
+	}
+	if err := resubmitModeration(s.productRepo, productID); err != nil {
+		return nil, err
+	}
+
+	fillPhotoURL(fotoProduk)
+	return fotoProduk, nil
+}
+
+// attachImage membuat FotoProduk yang merujuk blob dari isi sourcePath. Blob baru (atau yang
+// sebelumnya gagal) dimasukkan ke antrian pemrosesan; blob yang sudah ada langsung dipakai
+// dan file staging dihapus.
+func (s *FotoProdukService) attachImage(productID uint, sourcePath string, urutan int, isPrimary bool) (*models.FotoProduk, error) {
+	sha, size, err := fileSHA256(sourcePath)
+	if err != nil {
+		os.Remove(sourcePath)
+		return nil, errors.New("gagal membaca file foto")
 	}
 
-	err = s.fotoProdukRepo.Create(fotoProduk)
+	blob, needsProcessing, err := s.blobRepo.Acquire(&models.Blob{Sha256: sha, MediaType: models.MediaTypeImage, Size: size, Owner: s.imageProcessor.instanceID})
 	if err != nil {
+		os.Remove(sourcePath)
 		return nil, errors.New("gagal menyimpan foto produk")
 	}
 
+	fotoProduk := &models.FotoProduk{
+		IdProduk:     productID,
+		IdBlob:       &blob.ID,
+		Status:       blob.Status,
+		Key:          blob.Key,
+		KeyMedium:    blob.KeyMedium,
+		KeyThumbnail: blob.KeyThumbnail,
+		Urutan:       urutan,
+		IsPrimary:    isPrimary,
+	}
+	if err := s.fotoProdukRepo.Create(fotoProduk); err != nil {
+		if needsProcessing {
+			s.failBlob(blob.ID)
+		}
+		s.releaseBlob(blob.ID)
+		os.Remove(sourcePath)
+		return nil, errors.New("gagal menyimpan foto produk")
+	}
+
+	if !needsProcessing {
+		os.Remove(sourcePath)
+		if blob.Status == models.FotoStatusProcessing {
+			if err := s.fotoProdukRepo.SyncFromBlob(fotoProduk); err != nil {
+				log.Printf("failed to sync foto %d with blob %d: %v", fotoProduk.ID, blob.ID, err)
+			}
+		}
+		fillPhotoURL(fotoProduk)
+		return fotoProduk, nil
+	}
+
+	if err := s.imageProcessor.Enqueue(blob.ID, sha, sourcePath); err != nil {
+		s.failBlob(blob.ID)
+		s.fotoProdukRepo.DeleteByID(fotoProduk.ID)
+		s.releaseBlob(blob.ID)
+		os.Remove(sourcePath)
+		return nil, err
+	}
+
 	return fotoProduk, nil
 }
 
-// AddMultiplePhotosToProduct menambahkan multiple foto ke produk
-func (s *FotoProdukService) AddMultiplePhotosToProduct(productID uint, userID uint, photoURLs []string) ([]models.FotoProduk, error) {
+// StagedImage adalah file foto di staging yang sudah mendapat blob, dipakai oleh tabel lain
+// yang merujuk blob langsung (misalnya foto ulasan)
+type StagedImage struct {
+	Blob            *models.Blob
+	sha256          string
+	sourcePath      string
+	needsProcessing bool
+}
+
+// AcquireImages membuat blob (atau memakai blob dengan isi yang sama) untuk setiap file staging.
+// Jika salah satu gagal, blob yang sudah didapat dilepas dan semua file staging dihapus.
+// Panggil ProcessImages setelah row yang merujuk blob tersimpan, atau DiscardImages jika batal.
+func (s *FotoProdukService) AcquireImages(sourcePaths []string) ([]StagedImage, error) {
+	images := make([]StagedImage, 0, len(sourcePaths))
+	for i, sourcePath := range sourcePaths {
+		sha, size, err := fileSHA256(sourcePath)
+		if err == nil {
+			var blob *models.Blob
+			var needsProcessing bool
+			blob, needsProcessing, err = s.blobRepo.Acquire(&models.Blob{Sha256: sha, MediaType: models.MediaTypeImage, Size: size, Owner: s.imageProcessor.instanceID})
+			if err == nil {
+				images = append(images, StagedImage{Blob: blob, sha256: sha, sourcePath: sourcePath, needsProcessing: needsProcessing})
+				continue
+			}
+		}
+
+		s.DiscardImages(images)
+		for _, remaining := range sourcePaths[i:] {
+			os.Remove(remaining)
+		}
+		return nil, errors.New("gagal menyimpan foto")
+	}
+	return images, nil
+}
+
+// ProcessImages memasukkan blob baru ke antrian pemrosesan dan menghapus file staging blob yang
+// sudah ada. Blob yang gagal masuk antrian ditandai failed agar upload berikutnya diproses ulang.
+func (s *FotoProdukService) ProcessImages(images []StagedImage) {
+	for _, image := range images {
+		if !image.needsProcessing {
+			os.Remove(image.sourcePath)
+			continue
+		}
+		if err := s.imageProcessor.Enqueue(image.Blob.ID, image.sha256, image.sourcePath); err != nil {
+			log.Printf("failed to enqueue blob %d: %v", image.Blob.ID, err)
+			s.failBlob(image.Blob.ID)
+			os.Remove(image.sourcePath)
+		}
+	}
+}
+
+// DiscardImages melepas blob dan menghapus file staging jika row yang merujuknya batal disimpan
+func (s *FotoProdukService) DiscardImages(images []StagedImage) {
+	for _, image := range images {
+		if image.needsProcessing {
+			s.failBlob(image.Blob.ID)
+		}
+		s.releaseBlob(image.Blob.ID)
+		os.Remove(image.sourcePath)
+	}
+}
+
+// checkProductAccess memastikan produk ada dan user adalah pemilik toko yang memiliki produk
+func (s *FotoProdukService) checkProductAccess(productID uint, userID uint) error {
 	// Cek apakah produk ada
 	productExists, err := s.productRepo.CheckExists(productID)
 	if err != nil {
-		return nil, errors.New("gagal mengecek produk")
+		return errors.New("gagal mengecek produk")
 	}
 	if !productExists {
-		return nil, errors.New("produk tidak ditemukan")
+		return errors.New("produk tidak ditemukan")
 	}
 
 	// Cek ownership (user harus pemilik toko yang memiliki produk)
 	isOwner, err := s.productRepo.CheckOwnership(productID, userID)
 	if err != nil {
-		return nil, errors.New("gagal mengecek kepemilikan produk")
+		return errors.New("gagal mengecek kepemilikan produk")
 	}
 	if !isOwner {
-		return nil, errors.New("anda tidak memiliki akses untuk menambahkan foto ke produk ini")
-	}
-
-	// Buat array foto produk
-	var fotoProduks []models.FotoProduk
-	for _, url := range photoURLs {
-		fotoProduks = append(fotoProduks, models.FotoProduk{
-			IdProduk: productID,
-			Url:      url,
-		})
-	}
-
-	err = s.fotoProdukRepo.CreateMultiple(fotoProduks)
-	if err != nil {
-		return nil, errors.New("gagal menyimpan foto produk")
+		return errors.New("anda tidak memiliki akses untuk menambahkan foto ke produk ini")
 	}
-
-	return fotoProduks, nil
+	return nil
 }
 
 // GetPhotosByProductID mengambil semua foto berdasarkan product ID
 func (s *FotoProdukService) GetPhotosByProductID(productID uint) ([]models.FotoProduk, error) {
-	return s.fotoProdukRepo.GetByProductID(productID)
+	fotoProduks, err := s.fotoProdukRepo.GetByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+	fillPhotoURLs(fotoProduks)
+	return fotoProduks, nil
 }
 
 // DeletePhoto menghapus foto produk
 func (s *FotoProdukService) DeletePhoto(fotoID uint, userID uint) error {
 	// Cek apakah foto ada
-	fotoExists, err := s.fotoProdukRepo.CheckExists(fotoID)
+	fotoProduk, err := s.fotoProdukRepo.GetByID(fotoID)
 	if err != nil {
-		return errors.New("gagal mengecek foto")
-	}
-	if !fotoExists {
 		return errors.New("foto tidak ditemukan")
 	}
 
@@ -119,5 +323,191 @@ func (s *FotoProdukService) DeletePhoto(fotoID uint, userID uint) error {
 		return errors.New("gagal menghapus foto")
 	}
 
+	// Foto utama dihapus, jadikan foto berikutnya sebagai foto utama
+	if fotoProduk.IsPrimary {
+		if err := s.fotoProdukRepo.PromoteFirst(fotoProduk.IdProduk); err != nil {
+			log.Printf("failed to promote primary photo for product %d: %v", fotoProduk.IdProduk, err)
+		}
+	}
+
+	s.ReleasePhotos([]models.FotoProduk{*fotoProduk})
 	return nil
-}
\ No newline at end of file
+}
+
+// ReorderPhotos mengatur ulang urutan foto produk. fotoIDs harus berisi semua foto produk tepat satu kali.
+func (s *FotoProdukService) ReorderPhotos(productID uint, userID uint, fotoIDs []uint) ([]models.FotoProduk, error) {
+	isOwner, err := s.productRepo.CheckOwnership(productID, userID)
+	if err != nil {
+		return nil, errors.New("gagal mengecek kepemilikan produk")
+	}
+	if !isOwner {
+		return nil, errors.New("anda tidak memiliki akses untuk mengubah foto produk ini")
+	}
+
+	fotoProduks, err := s.fotoProdukRepo.GetByProductID(productID)
+	if err != nil {
+		return nil, errors.New("gagal mengambil foto produk")
+	}
+
+	existing := make(map[uint]bool, len(fotoProduks))
+	for _, fotoProduk := range fotoProduks {
+		existing[fotoProduk.ID] = true
+	}
+	if len(fotoIDs) != len(existing) {
+		return nil, errors.New("foto_ids harus berisi semua foto produk")
+	}
+	seen := make(map[uint]bool, len(fotoIDs))
+	for _, id := range fotoIDs {
+		if !existing[id] {
+			return nil, errors.New("foto_ids berisi foto yang bukan milik produk ini")
+		}
+		if seen[id] {
+			return nil, errors.New("foto_ids tidak boleh berisi ID yang sama")
+		}
+		seen[id] = true
+	}
+
+	if err := s.fotoProdukRepo.Reorder(productID, fotoIDs); err != nil {
+		return nil, errors.New("gagal menyimpan urutan foto")
+	}
+
+	return s.GetPhotosByProductID(productID)
+}
+
+// SetPrimaryPhoto menjadikan foto sebagai foto utama (cover) produk
+func (s *FotoProdukService) SetPrimaryPhoto(fotoID uint, userID uint) (*models.FotoProduk, error) {
+	fotoProduk, err := s.getOwnedPhoto(fotoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if fotoProduk.MediaType == models.MediaTypeVideo {
+		return nil, errors.New("video tidak bisa dijadikan foto utama")
+	}
+
+	if err := s.fotoProdukRepo.SetPrimary(fotoID, fotoProduk.IdProduk); err != nil {
+		return nil, errors.New("gagal menjadikan foto utama")
+	}
+
+	fotoProduk.IsPrimary = true
+	fillPhotoURL(fotoProduk)
+	return fotoProduk, nil
+}
+
+// UpdatePhotoAltText memperbarui teks alternatif foto
+func (s *FotoProdukService) UpdatePhotoAltText(fotoID uint, userID uint, altText string) (*models.FotoProduk, error) {
+	altText = strings.TrimSpace(altText)
+	if len(altText) > 255 {
+		return nil, errors.New("alt_text maksimal 255 karakter")
+	}
+
+	fotoProduk, err := s.getOwnedPhoto(fotoID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.fotoProdukRepo.UpdateAltText(fotoID, altText); err != nil {
+		return nil, errors.New("gagal memperbarui alt text foto")
+	}
+
+	fotoProduk.AltText = altText
+	fillPhotoURL(fotoProduk)
+	return fotoProduk, nil
+}
+
+// getOwnedPhoto mengambil foto dan memastikan user adalah pemilik toko produknya
+func (s *FotoProdukService) getOwnedPhoto(fotoID uint, userID uint) (*models.FotoProduk, error) {
+	fotoProduk, err := s.fotoProdukRepo.GetByID(fotoID)
+	if err != nil {
+		return nil, errors.New("foto tidak ditemukan")
+	}
+
+	isOwner, err := s.fotoProdukRepo.CheckOwnership(fotoID, userID)
+	if err != nil {
+		return nil, errors.New("gagal mengecek kepemilikan foto")
+	}
+	if !isOwner {
+		return nil, errors.New("anda tidak memiliki akses untuk mengubah foto ini")
+	}
+
+	return fotoProduk, nil
+}
+
+// nextPosition mengembalikan urutan untuk foto baru dan apakah produk sudah punya foto utama
+func (s *FotoProdukService) nextPosition(productID uint) (int, bool, error) {
+	urutan, err := s.fotoProdukRepo.NextUrutan(productID)
+	if err != nil {
+		return 0, false, errors.New("gagal menentukan urutan foto")
+	}
+	hasPrimary, err := s.fotoProdukRepo.HasPrimary(productID)
+	if err != nil {
+		return 0, false, errors.New("gagal mengecek foto utama")
+	}
+	return urutan, hasPrimary, nil
+}
+
+// ReleasePhotos melepas file milik foto yang row database-nya sudah terhapus. File blob hanya
+// dihapus dari storage jika tidak ada foto lain yang masih memakainya; foto lama tanpa blob
+// langsung dihapus file-nya. File yang gagal dihapus hanya di-log dan akan dibersihkan oleh storage-gc.
+func (s *FotoProdukService) ReleasePhotos(fotoProduks []models.FotoProduk) {
+	for _, fotoProduk := range fotoProduks {
+		if fotoProduk.IdBlob != nil {
+			s.releaseBlob(*fotoProduk.IdBlob)
+			continue
+		}
+		s.deleteFiles(fotoProduk.StorageKeys())
+	}
+}
+
+// releaseBlob mengurangi referensi blob dan menghapus file-nya jika sudah tidak dipakai.
+// Jika blob tidak ditemukan, file dibiarkan untuk storage-gc.
+func (s *FotoProdukService) releaseBlob(blobID uint) {
+	blob, removed, err := s.blobRepo.Release(blobID)
+	if err != nil {
+		log.Printf("failed to release blob %d: %v", blobID, err)
+		return
+	}
+	if removed {
+		s.deleteFiles(blob.StorageKeys())
+	}
+}
+
+// failBlob menandai blob gagal agar upload berikutnya dengan isi yang sama memprosesnya ulang
+func (s *FotoProdukService) failBlob(blobID uint) {
+	if err := s.blobRepo.UpdateStatus(blobID, models.FotoStatusFailed); err != nil {
+		log.Printf("failed to mark blob %d as failed: %v", blobID, err)
+	}
+}
+
+func (s *FotoProdukService) deleteFiles(keys []string) {
+	ctx := context.Background()
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("failed to delete %s from storage: %v", key, err)
+		}
+	}
+}
+
+func (s *FotoProdukService) putFile(ctx context.Context, key string, path string, size int64, contentType string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return s.storage.Put(ctx, key, file, size, contentType)
+}
+
+// fileSHA256 menghitung hash SHA-256 (hex) dan ukuran file
+func fileSHA256(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
@@ -0,0 +1,432 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"evernos-api2/models"
+	"evernos-api2/repositories"
+	"evernos-api2/storage"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ErrImageQueueFull dikembalikan jika antrian pemrosesan gambar sedang penuh
+var ErrImageQueueFull = errors.New("server sedang sibuk memproses foto, coba lagi beberapa saat")
+
+// imageVariant adalah ukuran rendisi yang dibuat untuk setiap foto (sisi terpanjang dalam piksel)
+type imageVariant struct {
+	suffix  string
+	maxSize int
+}
+
+type imageJob struct {
+	blobID     uint
+	sha256     string
+	sourcePath string
+}
+
+// ImageProcessor memproses foto produk di background dengan worker pool terbatas:
+// decode, koreksi orientasi, encode ulang (membuang metadata EXIF/GPS), lalu membuat
+// rendisi large/medium/thumbnail ke storage. Pemrosesan dilakukan per Blob; key rendisi
+// disalin ke semua FotoProduk yang merujuk blob tersebut.
+type ImageProcessor struct {
+	fotoProdukRepo *repositories.FotoProdukRepository
+	blobRepo       *repositories.BlobRepository
+	storage        storage.Storage
+	jobs           chan imageJob
+	workers        int
+	stagingDir     string
+	instanceID     string
+	keyPrefix      string
+	variants       []imageVariant
+}
+
+func NewImageProcessor(fotoProdukRepo *repositories.FotoProdukRepository, blobRepo *repositories.BlobRepository, store storage.Storage) *ImageProcessor {
+	workers := envInt("IMAGE_WORKERS", runtime.NumCPU())
+	queueSize := envInt("IMAGE_QUEUE_SIZE", 100)
+	maxDimension := envInt("IMAGE_MAX_DIMENSION", 1600)
+
+	stagingDir := os.Getenv("UPLOAD_STAGING_DIR")
+	if stagingDir == "" {
+		stagingDir = filepath.Join(os.TempDir(), "evernos-staging")
+	}
+
+	// File staging ada di disk lokal, jadi blob yang sedang diproses dicatat milik instance ini
+	instanceID := os.Getenv("INSTANCE_ID")
+	if instanceID == "" {
+		instanceID, _ = os.Hostname()
+	}
+
+	return &ImageProcessor{
+		fotoProdukRepo: fotoProdukRepo,
+		blobRepo:       blobRepo,
+		storage:        store,
+		jobs:           make(chan imageJob, queueSize),
+		workers:        workers,
+		stagingDir:     stagingDir,
+		instanceID:     instanceID,
+		keyPrefix:      "products/",
+		variants: []imageVariant{
+			{suffix: "lg", maxSize: maxDimension},
+			{suffix: "md", maxSize: 600},
+			{suffix: "th", maxSize: 200},
+		},
+	}
+}
+
+// Start menjalankan worker pool lalu memulihkan antrian yang hilang saat server berhenti
+func (p *ImageProcessor) Start() error {
+	if err := os.MkdirAll(p.stagingDir, 0o700); err != nil {
+		return fmt.Errorf("gagal membuat direktori staging: %v", err)
+	}
+	for i := 0; i < p.workers; i++ {
+		go p.worker()
+	}
+	return p.recoverQueue()
+}
+
+// recoverQueue memasukkan kembali blob berstatus processing yang file staging-nya masih ada
+// (dicocokkan lewat SHA-256), menghapus file staging yatim, dan menandai failed blob yang
+// file-nya sudah hilang agar upload berikutnya diproses ulang. Hanya blob milik instance ini dan
+// blob instance lain yang macet lebih lama dari BlobProcessingTimeout yang dipulihkan, karena
+// instance lain bisa sedang menerima atau memproses upload. File staging yang tidak cocok hanya
+// dihapus jika lebih lama dari batas waktu yang sama (direktori staging bisa dipakai bersama).
+// File resumable upload (tus_*.part) dibiarkan karena dikelola oleh ResumableUploadService.
+func (p *ImageProcessor) recoverQueue() error {
+	blobs, err := p.blobRepo.GetRecoverable(p.instanceID)
+	if err != nil {
+		return fmt.Errorf("gagal mengambil blob yang sedang diproses: %v", err)
+	}
+	pending := make(map[string]uint, len(blobs))
+	for _, blob := range blobs {
+		if blob.MediaType == models.MediaTypeImage {
+			pending[blob.Sha256] = blob.ID
+		}
+	}
+
+	entries, err := os.ReadDir(p.stagingDir)
+	if err != nil {
+		return fmt.Errorf("gagal membaca direktori staging: %v", err)
+	}
+	staleBefore := time.Now().Add(-repositories.BlobProcessingTimeout)
+	var jobs []imageJob
+	requeued := make(map[uint]bool)
+	orphans := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, "tus_") {
+			continue
+		}
+		path := filepath.Join(p.stagingDir, name)
+
+		sha, _, err := fileSHA256(path)
+		blobID, ok := pending[sha]
+		if err == nil && ok && !requeued[blobID] {
+			jobs = append(jobs, imageJob{blobID: blobID, sha256: sha, sourcePath: path})
+			requeued[blobID] = true
+			continue
+		}
+		if info, err := entry.Info(); err == nil && info.ModTime().Before(staleBefore) {
+			os.Remove(path)
+			orphans++
+		}
+	}
+
+	// Blob yang file mentahnya sudah hilang (termasuk video yang upload-nya terputus) tidak bisa diproses lagi
+	for _, blob := range blobs {
+		if requeued[blob.ID] {
+			continue
+		}
+		if err := p.blobRepo.UpdateStatus(blob.ID, models.FotoStatusFailed); err != nil {
+			log.Printf("failed to mark blob %d as failed: %v", blob.ID, err)
+		}
+		if err := p.fotoProdukRepo.UpdateStatusByBlob(blob.ID, models.FotoStatusFailed); err != nil {
+			log.Printf("failed to mark fotos of blob %d as failed: %v", blob.ID, err)
+		}
+	}
+
+	// Antrian bisa lebih kecil dari jumlah blob yang dipulihkan; tunggu slot kosong alih-alih membuang file
+	go func() {
+		for _, job := range jobs {
+			p.jobs <- job
+		}
+	}()
+
+	if len(requeued) > 0 || orphans > 0 {
+		log.Printf("image processor recovered %d blob(s) and removed %d orphaned staging file(s)", len(requeued), orphans)
+	}
+	return nil
+}
+
+// StagingPath mengembalikan path file mentah di luar direktori publik /uploads
+func (p *ImageProcessor) StagingPath(fileName string) string {
+	return filepath.Join(p.stagingDir, filepath.Base(fileName))
+}
+
+// Enqueue memasukkan blob ke antrian tanpa memblokir request
+func (p *ImageProcessor) Enqueue(blobID uint, sha256 string, sourcePath string) error {
+	select {
+	case p.jobs <- imageJob{blobID: blobID, sha256: sha256, sourcePath: sourcePath}:
+		return nil
+	default:
+		return ErrImageQueueFull
+	}
+}
+
+func (p *ImageProcessor) worker() {
+	for job := range p.jobs {
+		if err := p.process(job); err != nil {
+			log.Printf("image processing failed for blob %d: %v", job.blobID, err)
+			if updateErr := p.blobRepo.UpdateStatus(job.blobID, models.FotoStatusFailed); updateErr != nil {
+				log.Printf("failed to mark blob %d as failed: %v", job.blobID, updateErr)
+			}
+			if updateErr := p.fotoProdukRepo.UpdateStatusByBlob(job.blobID, models.FotoStatusFailed); updateErr != nil {
+				log.Printf("failed to mark fotos of blob %d as failed: %v", job.blobID, updateErr)
+			}
+		}
+		os.Remove(job.sourcePath)
+	}
+}
+
+// process membuat semua rendisi dari satu file mentah
+func (p *ImageProcessor) process(job imageJob) error {
+	data, err := os.ReadFile(job.sourcePath)
+	if err != nil {
+		return err
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("decode: %v", err)
+	}
+	if format == "jpeg" {
+		img = applyExifOrientation(img, jpegOrientation(data))
+	}
+
+	// Gambar dengan transparansi disimpan sebagai PNG, selainnya JPEG
+	ext := ".jpg"
+	if hasAlpha(img) {
+		ext = ".png"
+	}
+
+	// Nama file diturunkan dari hash isi sehingga upload identik selalu memakai key yang sama
+	ctx := context.Background()
+	baseName := job.sha256
+	keys := make(map[string]string, len(p.variants))
+	var written []string
+
+	for _, variant := range p.variants {
+		key := fmt.Sprintf("%s%s_%s%s", p.keyPrefix, baseName, variant.suffix, ext)
+
+		if err := p.putImage(ctx, key, resizeToFit(img, variant.maxSize), ext); err != nil {
+			for _, writtenKey := range written {
+				p.storage.Delete(ctx, writtenKey)
+			}
+			return fmt.Errorf("store %s: %v", variant.suffix, err)
+		}
+
+		written = append(written, key)
+		keys[variant.suffix] = key
+	}
+
+	blob := models.Blob{
+		Key:          keys["lg"],
+		KeyMedium:    keys["md"],
+		KeyThumbnail: keys["th"],
+	}
+	if err := p.blobRepo.MarkReady(job.blobID, blob); err != nil {
+		return err
+	}
+	return p.fotoProdukRepo.UpdateVariantsByBlob(job.blobID, blob)
+}
+
+// resizeToFit memperkecil gambar agar sisi terpanjangnya tidak melebihi maxSize
+func resizeToFit(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSize && height <= maxSize {
+		return img
+	}
+
+	if width >= height {
+		height = height * maxSize / width
+		width = maxSize
+	} else {
+		width = width * maxSize / height
+		height = maxSize
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// putImage meng-encode gambar lalu menyimpannya ke storage
+func (p *ImageProcessor) putImage(ctx context.Context, key string, img image.Image, ext string) error {
+	var buf bytes.Buffer
+	contentType := "image/jpeg"
+	var err error
+	if ext == ".png" {
+		contentType = "image/png"
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, flattenToRGB(img), &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return err
+	}
+
+	return p.storage.Put(ctx, key, &buf, int64(buf.Len()), contentType)
+}
+
+// hasAlpha mengecek apakah gambar memiliki piksel transparan
+func hasAlpha(img image.Image) bool {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok {
+		return !opaque.Opaque()
+	}
+	return false
+}
+
+// flattenToRGB menempatkan gambar di atas latar putih sebelum di-encode ke JPEG
+func flattenToRGB(img image.Image) image.Image {
+	if !hasAlpha(img) {
+		return img
+	}
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
+
+// jpegOrientation membaca tag Orientation (0x0112) dari segmen EXIF APP1. Mengembalikan 1 jika tidak ada.
+func jpegOrientation(data []byte) int {
+	r := bytes.NewReader(data)
+	var marker [2]byte
+	if _, err := io.ReadFull(r, marker[:]); err != nil || marker != [2]byte{0xFF, 0xD8} {
+		return 1
+	}
+
+	for {
+		if _, err := io.ReadFull(r, marker[:]); err != nil || marker[0] != 0xFF {
+			return 1
+		}
+		// Start of scan: metadata sudah lewat
+		if marker[1] == 0xDA {
+			return 1
+		}
+
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil || length < 2 {
+			return 1
+		}
+		segment := make([]byte, length-2)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return 1
+		}
+
+		if marker[1] == 0xE1 && len(segment) > 14 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+	}
+}
+
+// exifOrientation mencari tag Orientation di IFD0 dari header TIFF
+func exifOrientation(tiff []byte) int {
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[offset : offset+2]))
+	for i := 0; i < entries; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			value := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if value >= 1 && value <= 8 {
+				return value
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyExifOrientation memutar/membalik gambar sesuai orientasi EXIF karena metadata akan dibuang
+func applyExifOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if orientation >= 5 {
+		width, height = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = bounds.Dx()-1-x, y
+			case 3:
+				dx, dy = bounds.Dx()-1-x, bounds.Dy()-1-y
+			case 4:
+				dx, dy = x, bounds.Dy()-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = bounds.Dy()-1-y, x
+			case 7:
+				dx, dy = bounds.Dy()-1-y, bounds.Dx()-1-x
+			case 8:
+				dx, dy = y, bounds.Dx()-1-x
+			}
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+
+	return dst
+}
+
+// envInt membaca environment variable bertipe int dengan nilai default
+func envInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"evernos-api2/models"
+	"evernos-api2/repositories"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"mime/multipart"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var ErrImportQueueFull = errors.New("antrian import sedang penuh, coba lagi beberapa saat")
+
+// Kolom file import/export produk. Kolom wajib mengikuti aturan validasi CreateProduct;
+// sku dan slug opsional dan dipakai untuk mencocokkan produk yang sudah ada.
+var (
+	importColumns         = []string{"sku", "slug", "nama_produk", "harga_reseller", "harga_konsumen", "stok", "deskripsi", "id_category"}
+	importRequiredColumns = []string{"nama_produk", "harga_reseller", "harga_konsumen", "stok", "deskripsi", "id_category"}
+)
+
+// Batas jumlah error baris yang disimpan per job; baris gagal setelahnya tetap dihitung di Failed
+const maxImportErrors = 500
+
+// ImportService menangani import produk massal dari CSV/XLSX dan export dengan format yang sama.
+// File import disimpan di database lalu diproses satu per satu oleh satu worker per instance. Setiap
+// job diklaim dulu sehingga hanya satu instance yang memprosesnya, dan progresnya disimpan per baris
+// agar job yang terhenti (server restart) dilanjutkan dari baris berikutnya, bukan diulang dari awal.
+type ImportService struct {
+	importJobRepo  *repositories.ImportJobRepository
+	tokoRepo       *repositories.TokoRepository
+	productService *ProductService
+	jobs           chan uint
+	maxRows        int
+}
+
+func NewImportService(importJobRepo *repositories.ImportJobRepository, tokoRepo *repositories.TokoRepository, productService *ProductService) *ImportService {
+	return &ImportService{
+		importJobRepo:  importJobRepo,
+		tokoRepo:       tokoRepo,
+		productService: productService,
+		jobs:           make(chan uint, envInt("IMPORT_QUEUE_SIZE", 100)),
+		maxRows:        envInt("IMPORT_MAX_ROWS", 5000),
+	}
+}
+
+// Start menjalankan worker dan memasukkan job yang belum selesai ke antrian. Job yang belum selesai
+// dicek ulang secara berkala untuk mengambil alih job yang ditinggalkan instance lain yang berhenti.
+func (s *ImportService) Start() error {
+	unfinished, err := s.importJobRepo.GetUnfinished()
+	if err != nil {
+		return err
+	}
+
+	go s.worker()
+	go func() {
+		for {
+			// Antrian penuh tidak masalah, job yang terlewat masuk lagi di pengecekan berikutnya
+			for _, job := range unfinished {
+				select {
+				case s.jobs <- job.ID:
+				default:
+				}
+			}
+			time.Sleep(repositories.ImportJobTimeout)
+			jobs, err := s.importJobRepo.GetUnfinished()
+			if err != nil {
+				log.Printf("failed to fetch unfinished import jobs: %v", err)
+				continue
+			}
+			unfinished = jobs
+		}
+	}()
+	return nil
+}
+
+// StartImport menyimpan file import lalu membuat job yang diproses di background
+func (s *ImportService) StartImport(userID uint, file *multipart.FileHeader, dryRun bool) (*models.ImportJob, error) {
+	toko, err := s.tokoRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, errors.New("user belum memiliki toko")
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+	if format != "csv" && format != "xlsx" {
+		return nil, errors.New("format file harus CSV atau XLSX")
+	}
+
+	data, err := readUpload(file)
+	if err != nil {
+		return nil, errors.New("gagal menyimpan file import")
+	}
+
+	job := &models.ImportJob{
+		IdToko:   toko.ID,
+		IdUser:   userID,
+		FileName: filepath.Base(file.Filename),
+		Format:   format,
+		DryRun:   dryRun,
+		Status:   models.ImportStatusPending,
+		Errors:   []models.ImportRowError{},
+	}
+	if err := s.importJobRepo.Create(job, data); err != nil {
+		return nil, errors.New("gagal membuat job import")
+	}
+
+	select {
+	case s.jobs <- job.ID:
+	default:
+		s.fail(job, ErrImportQueueFull.Error())
+		return nil, ErrImportQueueFull
+	}
+
+	return job, nil
+}
+
+// GetJob mengambil status job import milik toko user
+func (s *ImportService) GetJob(userID, jobID uint) (*models.ImportJob, error) {
+	toko, err := s.tokoRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, errors.New("user belum memiliki toko")
+	}
+	job, err := s.importJobRepo.GetByID(jobID, toko.ID)
+	if err != nil {
+		return nil, errors.New("job import tidak ditemukan")
+	}
+	return job, nil
+}
+
+// ExportProducts menulis semua produk toko user ke CSV atau XLSX dengan kolom yang sama seperti
+// file import, sehingga hasil export bisa diubah lalu diimport kembali
+func (s *ImportService) ExportProducts(userID uint, format string) ([]byte, error) {
+	toko, err := s.tokoRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, errors.New("user belum memiliki toko")
+	}
+	if format != "csv" && format != "xlsx" {
+		return nil, errors.New("format export harus csv atau xlsx")
+	}
+
+	products, err := s.productService.GetProductsByTokoID(toko.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{importColumns}
+	for _, p := range products {
+		rows = append(rows, []string{
+			p.Sku,
+			p.Slug,
+			p.NamaProduk,
+			p.HargaReseller.String(),
+			p.HargaKonsumen.String(),
+			strconv.Itoa(p.Stok),
+			p.Deskripsi,
+			strconv.FormatUint(uint64(p.IdCategory), 10),
+		})
+	}
+
+	if format == "xlsx" {
+		return writeXLSX(rows)
+	}
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf).WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *ImportService) worker() {
+	for jobID := range s.jobs {
+		if err := s.process(jobID); err != nil {
+			log.Printf("product import job %d failed: %v", jobID, err)
+		}
+	}
+}
+
+// process mengklaim job, membaca filenya, lalu mengimport setiap baris mulai dari checkpoint terakhir.
+// Checkpoint disimpan setelah setiap baris; jika server berhenti di antara baris yang sudah tersimpan
+// dan checkpoint-nya, hanya baris itu yang diproses ulang (baris tanpa SKU/slug bisa menjadi produk ganda).
+func (s *ImportService) process(jobID uint) error {
+	claimed, err := s.importJobRepo.Claim(jobID)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		// Sudah selesai atau sedang diproses instance lain
+		return nil
+	}
+	job, err := s.importJobRepo.FindByID(jobID)
+	if err != nil {
+		return err
+	}
+
+	data, err := s.importJobRepo.GetFile(job.ID)
+	if err != nil {
+		s.fail(job, "file import tidak ditemukan")
+		return err
+	}
+	rows, err := readImportFile(data, job.Format)
+	if err != nil {
+		s.fail(job, "file tidak bisa dibaca: "+err.Error())
+		return err
+	}
+	if len(rows) == 0 {
+		s.fail(job, "file kosong")
+		return nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	var missing []string
+	for _, name := range importRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		s.fail(job, "kolom wajib tidak ada: "+strings.Join(missing, ", "))
+		return nil
+	}
+
+	type dataRow struct {
+		line   int
+		values []string
+	}
+	var dataRows []dataRow
+	for i, values := range rows[1:] {
+		if strings.TrimSpace(strings.Join(values, "")) != "" {
+			dataRows = append(dataRows, dataRow{line: i + 2, values: values})
+		}
+	}
+	if len(dataRows) > s.maxRows {
+		s.fail(job, fmt.Sprintf("maksimal %d baris per file", s.maxRows))
+		return nil
+	}
+	job.TotalRows = len(dataRows)
+
+	referensi := "import:" + strconv.FormatUint(uint64(job.ID), 10)
+	seen := make(map[string]int)
+	for i, row := range dataRows {
+		cell := func(name string) string {
+			if idx, ok := columns[name]; ok && idx < len(row.values) {
+				return strings.TrimSpace(row.values[idx])
+			}
+			return ""
+		}
+
+		// Baris dicocokkan lewat SKU atau slug, jadi kunci yang sama dua kali di satu file ditolak
+		key, dedupKey := cell("sku"), "sku:"+strings.ToLower(cell("sku"))
+		if key == "" {
+			key, dedupKey = cell("slug"), "slug:"+strings.ToLower(cell("slug"))
+		}
+		data, err := importRowData(cell)
+		if err == nil && key != "" {
+			if line, dup := seen[dedupKey]; dup {
+				err = fmt.Errorf("duplikat dengan baris %d", line)
+			} else {
+				seen[dedupKey] = row.line
+			}
+		}
+
+		// Baris sebelum checkpoint sudah diproses; kuncinya tetap dicatat untuk pengecekan duplikat
+		if i < job.Processed {
+			continue
+		}
+
+		var action string
+		if err == nil {
+			action, err = s.productService.ImportProduct(job.IdUser, job.IdToko, data, job.DryRun, referensi)
+		}
+		switch {
+		case err != nil:
+			job.Failed++
+			if len(job.Errors) < maxImportErrors {
+				job.Errors = append(job.Errors, models.ImportRowError{Row: row.line, Key: key, Error: err.Error()})
+			}
+		case action == ImportActionCreated:
+			job.Created++
+		default:
+			job.Updated++
+		}
+
+		// Checkpoint juga memperbarui updated_at sehingga job tidak diklaim instance lain
+		job.Processed = i + 1
+		if err := s.importJobRepo.Update(job); err != nil {
+			return fmt.Errorf("gagal menyimpan progres: %v", err)
+		}
+	}
+
+	now := time.Now()
+	job.Status = models.ImportStatusCompleted
+	job.FinishedAt = &now
+	if err := s.importJobRepo.Update(job); err != nil {
+		return err
+	}
+	return s.importJobRepo.DeleteFile(job.ID)
+}
+
+// importRowData mengubah satu baris file menjadi data produk seperti form CreateProduct
+func importRowData(cell func(string) string) (map[string]interface{}, error) {
+	data := map[string]interface{}{
+		"nama_produk":    cell("nama_produk"),
+		"harga_reseller": cell("harga_reseller"),
+		"harga_konsumen": cell("harga_konsumen"),
+		"deskripsi":      cell("deskripsi"),
+		"sku":            cell("sku"),
+		"slug":           cell("slug"),
+	}
+
+	if stokStr := cell("stok"); stokStr != "" {
+		stok, err := strconv.ParseFloat(stokStr, 64)
+		if err != nil || stok != math.Trunc(stok) {
+			return nil, errors.New("stok harus berupa bilangan bulat")
+		}
+		data["stok"] = stok
+	}
+	if idCategoryStr := cell("id_category"); idCategoryStr != "" {
+		if idCategory, err := strconv.ParseFloat(idCategoryStr, 64); err == nil {
+			data["id_category"] = idCategory
+		}
+	}
+	return data, nil
+}
+
+// readImportFile membaca semua baris dari CSV (pemisah koma atau titik koma) atau sheet pertama XLSX
+func readImportFile(data []byte, format string) ([][]string, error) {
+	if format == "xlsx" {
+		f, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, nil
+		}
+		return f.GetRows(sheets[0])
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	// Excel dengan locale Indonesia menyimpan CSV dengan pemisah titik koma
+	header, _, _ := strings.Cut(string(data), "\n")
+	if strings.Count(header, ";") > strings.Count(header, ",") {
+		reader.Comma = ';'
+	}
+	return reader.ReadAll()
+}
+
+func writeXLSX(rows [][]string) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := f.GetSheetName(0)
+	for i, row := range rows {
+		cells := make([]interface{}, len(row))
+		for j, value := range row {
+			cells[j] = value
+		}
+		cellName, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return nil, err
+		}
+		if err := f.SetSheetRow(sheet, cellName, &cells); err != nil {
+			return nil, err
+		}
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func readUpload(file *multipart.FileHeader) ([]byte, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	return io.ReadAll(src)
+}
+
+// fail menandai job gagal secara keseluruhan (bukan error per baris)
+func (s *ImportService) fail(job *models.ImportJob, message string) {
+	now := time.Now()
+	job.Status = models.ImportStatusFailed
+	job.Message = message
+	job.FinishedAt = &now
+	if err := s.importJobRepo.Update(job); err != nil {
+		log.Printf("failed to mark import job %d as failed: %v", job.ID, err)
+	}
+	if err := s.importJobRepo.DeleteFile(job.ID); err != nil {
+		log.Printf("failed to delete file of import job %d: %v", job.ID, err)
+	}
+}
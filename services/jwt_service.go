@@ -0,0 +1,306 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Konfigurasi JWT diambil dari environment:
+//   JWT_ALGORITHM  - HS256 (default), RS256, atau EdDSA
+//   JWT_SECRET     - secret HS256; jika diisi, token HS256 tetap diterima sebagai fallback
+//   JWT_KEYS_DIR   - direktori berisi key PEM, nama file (tanpa ekstensi) dipakai sebagai kid.
+//                    "<kid>.pem" berisi private key (bisa dipakai signing),
+//                    "<kid>.pub.pem" berisi public key saja (key lama yang hanya untuk verifikasi)
+//   JWT_ACTIVE_KID - kid yang dipakai untuk signing token baru
+//
+// Rotasi key: taruh key baru di JWT_KEYS_DIR, arahkan JWT_ACTIVE_KID ke key baru, lalu
+// reload (restart atau SIGHUP). Key lama tetap di direktori (boleh hanya public key-nya)
+// sampai semua token yang ditandatangani dengan key tersebut kedaluwarsa.
+
+type jwtKey struct {
+	kid        string
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+	publicKey  crypto.PublicKey
+}
+
+type jwtKeySet struct {
+	algorithm  string
+	activeKid  string
+	keys       map[string]*jwtKey
+	hmacSecret []byte
+}
+
+var (
+	jwtKeysMu sync.RWMutex
+	jwtKeys   = &jwtKeySet{algorithm: "HS256", keys: map[string]*jwtKey{}}
+)
+
+// JWK adalah representasi public key dalam format JSON Web Key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// LoadJWTKeys memuat konfigurasi dan key JWT dari environment. Aman dipanggil ulang untuk reload.
+func LoadJWTKeys() error {
+	algorithm := strings.TrimSpace(os.Getenv("JWT_ALGORITHM"))
+	if algorithm == "" {
+		algorithm = "HS256"
+	}
+
+	set := &jwtKeySet{
+		algorithm:  algorithm,
+		activeKid:  strings.TrimSpace(os.Getenv("JWT_ACTIVE_KID")),
+		keys:       map[string]*jwtKey{},
+		hmacSecret: []byte(os.Getenv("JWT_SECRET")),
+	}
+
+	switch algorithm {
+	case "HS256":
+		if len(set.hmacSecret) == 0 {
+			return errors.New("JWT_SECRET wajib diisi untuk JWT_ALGORITHM=HS256")
+		}
+	case "RS256", "EdDSA":
+	default:
+		return fmt.Errorf("JWT_ALGORITHM %q tidak didukung", algorithm)
+	}
+
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		if err := set.loadDir(dir); err != nil {
+			return err
+		}
+	}
+
+	if algorithm != "HS256" {
+		if set.activeKid == "" {
+			set.activeKid = set.defaultActiveKid()
+		}
+		active, ok := set.keys[set.activeKid]
+		if !ok || active.privateKey == nil {
+			return fmt.Errorf("private key untuk kid %q tidak ditemukan di JWT_KEYS_DIR", set.activeKid)
+		}
+		if active.method.Alg() != algorithm {
+			return fmt.Errorf("key aktif %q bukan key %s", set.activeKid, algorithm)
+		}
+	}
+
+	jwtKeysMu.Lock()
+	jwtKeys = set
+	jwtKeysMu.Unlock()
+	return nil
+}
+
+// loadDir membaca semua file key PEM di direktori
+func (s *jwtKeySet) loadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		base := filepath.Base(file)
+		publicOnly := strings.HasSuffix(base, ".pub.pem")
+		kid := strings.TrimSuffix(strings.TrimSuffix(base, ".pem"), ".pub")
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("gagal membaca key %s: %v", base, err)
+		}
+
+		key, err := parseJWTKey(kid, data, publicOnly)
+		if err != nil {
+			return fmt.Errorf("gagal parsing key %s: %v", base, err)
+		}
+
+		// Private key lebih diutamakan jika ada public dan private dengan kid yang sama
+		if existing, ok := s.keys[kid]; ok && existing.privateKey != nil {
+			continue
+		}
+		s.keys[kid] = key
+	}
+
+	return nil
+}
+
+// defaultActiveKid memilih kid terakhir (urutan nama) yang punya private key sesuai algoritma
+func (s *jwtKeySet) defaultActiveKid() string {
+	var kids []string
+	for kid, key := range s.keys {
+		if key.privateKey != nil && key.method.Alg() == s.algorithm {
+			kids = append(kids, kid)
+		}
+	}
+	if len(kids) == 0 {
+		return ""
+	}
+	sort.Strings(kids)
+	return kids[len(kids)-1]
+}
+
+// parseJWTKey mengubah isi file PEM menjadi jwtKey
+func parseJWTKey(kid string, data []byte, publicOnly bool) (*jwtKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("format PEM tidak valid")
+	}
+
+	key := &jwtKey{kid: kid}
+
+	if publicOnly {
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			rsaPub, rsaErr := x509.ParsePKCS1PublicKey(block.Bytes)
+			if rsaErr != nil {
+				return nil, err
+			}
+			pub = rsaPub
+		}
+		key.publicKey = pub
+	} else {
+		var priv interface{}
+		var err error
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			priv, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		default:
+			priv, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		}
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("tipe private key tidak didukung")
+		}
+		key.privateKey = signer
+		key.publicKey = signer.Public()
+	}
+
+	switch pub := key.publicKey.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("tipe key %T tidak didukung", pub)
+	}
+
+	return key, nil
+}
+
+// GenerateToken menandatangani claims dengan key aktif sesuai JWT_ALGORITHM
+func GenerateToken(claims jwt.MapClaims) (string, error) {
+	jwtKeysMu.RLock()
+	set := jwtKeys
+	jwtKeysMu.RUnlock()
+
+	if set.algorithm == "HS256" {
+		if len(set.hmacSecret) == 0 {
+			return "", errors.New("JWT_SECRET belum dikonfigurasi")
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(set.hmacSecret)
+	}
+
+	key, ok := set.keys[set.activeKid]
+	if !ok || key.privateKey == nil {
+		return "", errors.New("key signing aktif tidak tersedia")
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.privateKey)
+}
+
+// ParseToken memvalidasi token dan mengembalikan claims-nya.
+// Token asimetris dicocokkan berdasarkan kid, token HS256 hanya diterima jika JWT_SECRET diisi.
+func ParseToken(tokenString string) (jwt.MapClaims, error) {
+	jwtKeysMu.RLock()
+	set := jwtKeys
+	jwtKeysMu.RUnlock()
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			if token.Method.Alg() != "HS256" || len(set.hmacSecret) == 0 {
+				return nil, errors.New("unexpected signing method")
+			}
+			return set.hmacSecret, nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, ok := set.keys[kid]
+		if !ok {
+			return nil, errors.New("unknown key id")
+		}
+		if key.method.Alg() != token.Method.Alg() {
+			return nil, errors.New("unexpected signing method")
+		}
+		return key.publicKey, nil
+	}, jwt.WithValidMethods([]string{"HS256", "RS256", "EdDSA"}))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+// GetJWKS mengembalikan semua public key asimetris yang masih berlaku untuk verifikasi
+func GetJWKS() []JWK {
+	jwtKeysMu.RLock()
+	set := jwtKeys
+	jwtKeysMu.RUnlock()
+
+	kids := make([]string, 0, len(set.keys))
+	for kid := range set.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	keys := make([]JWK, 0, len(kids))
+	for _, kid := range kids {
+		key := set.keys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.method.Alg()}
+
+		switch pub := key.publicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+
+		keys = append(keys, jwk)
+	}
+
+	return keys
+}
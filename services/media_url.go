@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"evernos-api2/models"
+	"evernos-api2/storage"
+)
+
+// fillPhotoURL mengisi URL foto dari key storage saat data dikirim ke client agar mengikuti
+// STORAGE_PUBLIC_URL terbaru atau presigned URL yang masih berlaku. Foto lama tanpa key tetap
+// memakai URL tersimpan.
+func fillPhotoURL(foto *models.FotoProduk) {
+	ctx := context.Background()
+	if foto.Key != "" {
+		foto.Url = storage.PublicURL(ctx, foto.Key)
+	}
+	if foto.KeyMedium != "" {
+		foto.UrlMedium = storage.PublicURL(ctx, foto.KeyMedium)
+	}
+	if foto.KeyThumbnail != "" {
+		foto.UrlThumbnail = storage.PublicURL(ctx, foto.KeyThumbnail)
+	}
+}
+
+// fillPhotoURLs mengisi URL untuk setiap foto
+func fillPhotoURLs(fotoProduks []models.FotoProduk) {
+	for i := range fotoProduks {
+		fillPhotoURL(&fotoProduks[i])
+	}
+}
+
+// fillProductPhotoURLs mengisi URL foto untuk setiap produk
+func fillProductPhotoURLs(products []models.Produk) {
+	for i := range products {
+		fillPhotoURLs(products[i].FotoProduk)
+	}
+}
+
+// fillTrxPhotoURLs mengisi URL foto produk di setiap detail transaksi
+func fillTrxPhotoURLs(trxs []models.Trx) {
+	for i := range trxs {
+		for j := range trxs[i].DetailTrx {
+			fillPhotoURLs(trxs[i].DetailTrx[j].Produk.FotoProduk)
+		}
+	}
+}
+
+// fillReviewPhotoURL mengisi status dan URL foto ulasan dari blob yang ikut di-preload
+func fillReviewPhotoURL(ulasan *models.Ulasan) {
+	ctx := context.Background()
+	for i := range ulasan.Foto {
+		foto := &ulasan.Foto[i]
+		if foto.Blob == nil {
+			continue
+		}
+		foto.Status = foto.Blob.Status
+		if foto.Blob.Key != "" {
+			foto.Url = storage.PublicURL(ctx, foto.Blob.Key)
+		}
+		if foto.Blob.KeyMedium != "" {
+			foto.UrlMedium = storage.PublicURL(ctx, foto.Blob.KeyMedium)
+		}
+		if foto.Blob.KeyThumbnail != "" {
+			foto.UrlThumbnail = storage.PublicURL(ctx, foto.Blob.KeyThumbnail)
+		}
+	}
+}
+
+// fillReviewPhotoURLs mengisi status dan URL foto untuk setiap ulasan
+func fillReviewPhotoURLs(ulasans []models.Ulasan) {
+	for i := range ulasans {
+		fillReviewPhotoURL(&ulasans[i])
+	}
+}
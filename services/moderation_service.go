@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+	"evernos-api2/models"
+	"evernos-api2/repositories"
+	"strconv"
+	"strings"
+)
+
+type ModerationService struct {
+	productRepo       *repositories.ProductRepository
+	pertanyaanRepo    *repositories.PertanyaanRepository
+	notifikasiService *NotifikasiService
+	wishlistService   *WishlistService
+}
+
+func NewModerationService(productRepo *repositories.ProductRepository, pertanyaanRepo *repositories.PertanyaanRepository, notifikasiService *NotifikasiService, wishlistService *WishlistService) *ModerationService {
+	return &ModerationService{
+		productRepo:       productRepo,
+		pertanyaanRepo:    pertanyaanRepo,
+		notifikasiService: notifikasiService,
+		wishlistService:   wishlistService,
+	}
+}
+
+// GetQueue mengambil produk berdasarkan status moderasi (default pending), paling lama menunggu lebih dulu
+func (s *ModerationService) GetQueue(status, limitStr, pageStr, cursor string) ([]models.Produk, map[string]interface{}, error) {
+	if status == "" {
+		status = models.ModerasiPending
+	}
+	if !containsString([]string{models.ModerasiPending, models.ModerasiApproved, models.ModerasiRejected}, status) {
+		return nil, nil, errors.New("status moderasi tidak valid")
+	}
+
+	page := repositories.NewPageRequest(limitStr, pageStr, cursor)
+	products, result, err := s.productRepo.GetByModerationStatus(status, page)
+	if err == repositories.ErrInvalidCursor {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, errors.New("gagal mengambil antrian moderasi")
+	}
+
+	fillProductPhotoURLs(products)
+	return products, paginationInfo(page, result), nil
+}
+
+// Approve menyetujui produk sehingga bisa tampil untuk publik
+func (s *ModerationService) Approve(productID, adminID uint) (*models.Produk, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, errors.New("produk tidak ditemukan")
+	}
+	if product.ModerationStatus == models.ModerasiApproved {
+		return nil, errors.New("produk sudah disetujui")
+	}
+
+	if err := s.productRepo.SetModeration(productID, models.ModerasiApproved, "", adminID); err != nil {
+		return nil, errors.New("gagal menyimpan hasil moderasi")
+	}
+	// Produk bisa langsung terbit setelah disetujui; beri tahu pelanggan stok yang masih menunggu
+	go s.wishlistService.NotifyBackInStock(productID)
+	return s.getProduct(productID)
+}
+
+// Reject menolak produk dengan alasan dan memberi tahu penjual lewat notifikasi
+func (s *ModerationService) Reject(productID, adminID uint, reason string) (*models.Produk, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("alasan penolakan wajib diisi")
+	}
+	if len(reason) > 255 {
+		return nil, errors.New("alasan penolakan maksimal 255 karakter")
+	}
+
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, errors.New("produk tidak ditemukan")
+	}
+	if product.ModerationStatus == models.ModerasiRejected {
+		return nil, errors.New("produk sudah ditolak")
+	}
+
+	if err := s.productRepo.SetModeration(productID, models.ModerasiRejected, reason, adminID); err != nil {
+		return nil, errors.New("gagal menyimpan hasil moderasi")
+	}
+
+	if ownerID, err := s.productRepo.GetOwnerUserID(productID); err == nil {
+		s.notifikasiService.Notify(ownerID, models.NotifikasiProdukDitolak,
+			"Produk ditolak",
+			"Produk \""+product.NamaProduk+"\" ditolak oleh admin: "+reason+". Perbaiki produk untuk mengajukan ulang.",
+			"product:"+strconv.FormatUint(uint64(productID), 10))
+	}
+
+	return s.getProduct(productID)
+}
+
+// GetQuestions mengambil pertanyaan produk berdasarkan status (visible atau hidden, default visible), terbaru lebih dulu
+func (s *ModerationService) GetQuestions(status, limitStr, pageStr, cursor string) ([]models.Pertanyaan, map[string]interface{}, error) {
+	if status == "" {
+		status = "visible"
+	}
+	if status != "visible" && status != "hidden" {
+		return nil, nil, errors.New("status moderasi tidak valid")
+	}
+
+	page := repositories.NewPageRequest(limitStr, pageStr, cursor)
+	pertanyaans, result, err := s.pertanyaanRepo.GetByHidden(status == "hidden", page)
+	if err == repositories.ErrInvalidCursor {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, errors.New("gagal mengambil data pertanyaan")
+	}
+
+	return pertanyaans, paginationInfo(page, result), nil
+}
+
+// HideQuestion menyembunyikan pertanyaan (beserta jawabannya) yang berisi konten kasar dari publik
+func (s *ModerationService) HideQuestion(id, adminID uint, reason string) (*models.Pertanyaan, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("alasan wajib diisi")
+	}
+	if len(reason) > 255 {
+		return nil, errors.New("alasan maksimal 255 karakter")
+	}
+
+	pertanyaan, err := s.pertanyaanRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("pertanyaan tidak ditemukan")
+	}
+	if pertanyaan.Disembunyikan {
+		return nil, errors.New("pertanyaan sudah disembunyikan")
+	}
+
+	if err := s.pertanyaanRepo.SetHidden(id, true, reason, adminID); err != nil {
+		return nil, errors.New("gagal menyimpan hasil moderasi")
+	}
+	return s.pertanyaanRepo.GetByID(id)
+}
+
+// UnhideQuestion menampilkan kembali pertanyaan yang sebelumnya disembunyikan
+func (s *ModerationService) UnhideQuestion(id, adminID uint) (*models.Pertanyaan, error) {
+	pertanyaan, err := s.pertanyaanRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("pertanyaan tidak ditemukan")
+	}
+	if !pertanyaan.Disembunyikan {
+		return nil, errors.New("pertanyaan tidak disembunyikan")
+	}
+
+	if err := s.pertanyaanRepo.SetHidden(id, false, "", adminID); err != nil {
+		return nil, errors.New("gagal menyimpan hasil moderasi")
+	}
+	return s.pertanyaanRepo.GetByID(id)
+}
+
+// getProduct mengambil produk terbaru setelah hasil moderasi disimpan
+func (s *ModerationService) getProduct(productID uint) (*models.Produk, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+	fillPhotoURLs(product.FotoProduk)
+	return product, nil
+}
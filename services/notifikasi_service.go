@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"evernos-api2/models"
+	"evernos-api2/repositories"
+	"log"
+	"strconv"
+)
+
+type NotifikasiService struct {
+	notifikasiRepo *repositories.NotifikasiRepository
+}
+
+func NewNotifikasiService(notifikasiRepo *repositories.NotifikasiRepository) *NotifikasiService {
+	return &NotifikasiService{notifikasiRepo: notifikasiRepo}
+}
+
+// Notify membuat notifikasi untuk user. Kegagalan hanya di-log karena notifikasi tidak boleh
+// menggagalkan aksi utama yang memicunya.
+func (s *NotifikasiService) Notify(userID uint, jenis, judul, pesan, referensi string) {
+	err := s.notifikasiRepo.Create(&models.Notifikasi{
+		IdUser:    userID,
+		Jenis:     jenis,
+		Judul:     judul,
+		Pesan:     pesan,
+		Referensi: referensi,
+	})
+	if err != nil {
+		log.Printf("failed to create %s notification for user %d: %v", jenis, userID, err)
+	}
+}
+
+// GetNotifications mengambil notifikasi user dengan pagination beserta jumlah yang belum dibaca
+func (s *NotifikasiService) GetNotifications(userID uint, limitStr, pageStr, cursor, unreadStr string) ([]models.Notifikasi, map[string]interface{}, int64, error) {
+	unreadOnly := false
+	if unreadStr != "" {
+		parsed, err := strconv.ParseBool(unreadStr)
+		if err != nil {
+			return nil, nil, 0, errors.New("unread tidak valid")
+		}
+		unreadOnly = parsed
+	}
+
+	page := repositories.NewPageRequest(limitStr, pageStr, cursor)
+	notifikasi, result, err := s.notifikasiRepo.GetByUserID(userID, unreadOnly, page)
+	if err == repositories.ErrInvalidCursor {
+		return nil, nil, 0, err
+	}
+	if err != nil {
+		return nil, nil, 0, errors.New("gagal mengambil notifikasi")
+	}
+
+	unread, err := s.notifikasiRepo.CountUnread(userID)
+	if err != nil {
+		return nil, nil, 0, errors.New("gagal mengambil notifikasi")
+	}
+
+	return notifikasi, paginationInfo(page, result), unread, nil
+}
+
+// MarkRead menandai satu notifikasi (atau semua jika id 0) sudah dibaca
+func (s *NotifikasiService) MarkRead(userID uint, id uint) error {
+	if id != 0 {
+		exists, err := s.notifikasiRepo.CheckExists(id, userID)
+		if err != nil {
+			return errors.New("gagal mengecek notifikasi")
+		}
+		if !exists {
+			return errors.New("notifikasi tidak ditemukan")
+		}
+	}
+	if err := s.notifikasiRepo.MarkRead(id, userID); err != nil {
+		return errors.New("gagal menandai notifikasi")
+	}
+	return nil
+}
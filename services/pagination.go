@@ -0,0 +1,27 @@
+package services
+
+import "evernos-api2/repositories"
+
+// paginationInfo membentuk objek pagination untuk response listing. Mode offset tetap
+// mengembalikan field lama (current_page, total_pages, ...); keduanya menyertakan
+// next_cursor untuk mengambil halaman berikutnya dengan mode cursor.
+func paginationInfo(page repositories.PageRequest, result repositories.PageResult) map[string]interface{} {
+	if page.IsCursor() {
+		return map[string]interface{}{
+			"limit":       page.Limit,
+			"has_next":    result.HasNext,
+			"next_cursor": result.NextCursor,
+		}
+	}
+
+	totalPages := int((result.Total + int64(page.Limit) - 1) / int64(page.Limit))
+	return map[string]interface{}{
+		"current_page": page.Page,
+		"total_pages":  totalPages,
+		"total_items":  result.Total,
+		"limit":        page.Limit,
+		"has_next":     page.Page < totalPages,
+		"has_prev":     page.Page > 1,
+		"next_cursor":  result.NextCursor,
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Kebijakan password dikonfigurasi melalui environment:
+//   PASSWORD_MIN_LENGTH   - panjang minimum (default 8)
+//   PASSWORD_BREACH_CHECK - "false" untuk menonaktifkan pengecekan daftar password bocor (default aktif)
+
+// Batas bcrypt: byte setelah ke-72 diabaikan sehingga password lebih panjang ditolak
+const passwordMaxLength = 72
+
+//go:embed data/breached_passwords.txt
+var breachedPasswordsFile string
+
+var (
+	breachedPasswordsOnce sync.Once
+	breachedPasswords     map[string]struct{}
+)
+
+// ValidatePassword memeriksa password baru terhadap kebijakan password.
+// userInputs (misalnya email atau nama) tidak boleh dipakai sebagai password.
+func ValidatePassword(password string, userInputs ...string) error {
+	minLength := 8
+	if v, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && v > 0 {
+		minLength = v
+	}
+
+	if len([]rune(password)) < minLength {
+		return fmt.Errorf("password must be at least %d characters", minLength)
+	}
+	if len(password) > passwordMaxLength {
+		return fmt.Errorf("password must be at most %d bytes", passwordMaxLength)
+	}
+	if strings.TrimSpace(password) == "" {
+		return errors.New("password must not be blank")
+	}
+
+	normalized := strings.ToLower(password)
+	for _, input := range userInputs {
+		if input != "" && normalized == strings.ToLower(strings.TrimSpace(input)) {
+			return errors.New("password must not be the same as your personal data")
+		}
+	}
+
+	if os.Getenv("PASSWORD_BREACH_CHECK") != "false" && isBreachedPassword(normalized) {
+		return errors.New("password is too common and has appeared in data breaches, choose another one")
+	}
+
+	return nil
+}
+
+// isBreachedPassword mengecek password (lowercase) terhadap daftar password bocor yang dibundel
+func isBreachedPassword(normalized string) bool {
+	breachedPasswordsOnce.Do(func() {
+		breachedPasswords = map[string]struct{}{}
+		scanner := bufio.NewScanner(strings.NewReader(breachedPasswordsFile))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			breachedPasswords[strings.ToLower(line)] = struct{}{}
+		}
+	})
+
+	_, found := breachedPasswords[normalized]
+	return found
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"evernos-api2/models"
+	"evernos-api2/repositories"
+	"strconv"
+	"strings"
+)
+
+type PertanyaanService struct {
+	pertanyaanRepo    *repositories.PertanyaanRepository
+	productRepo       *repositories.ProductRepository
+	productService    *ProductService
+	notifikasiService *NotifikasiService
+}
+
+func NewPertanyaanService(pertanyaanRepo *repositories.PertanyaanRepository, productRepo *repositories.ProductRepository, productService *ProductService, notifikasiService *NotifikasiService) *PertanyaanService {
+	return &PertanyaanService{
+		pertanyaanRepo:    pertanyaanRepo,
+		productRepo:       productRepo,
+		productService:    productService,
+		notifikasiService: notifikasiService,
+	}
+}
+
+// GetQuestions mengambil pertanyaan produk yang tidak disembunyikan dengan pagination.
+// Untuk user yang login, upvoted menandai pertanyaan yang sudah di-upvote user tersebut.
+func (s *PertanyaanService) GetQuestions(productID uint, viewerID uint, filters map[string]string) ([]models.Pertanyaan, map[string]interface{}, error) {
+	if _, err := s.productService.GetProductByID(productID, viewerID); err != nil {
+		return nil, nil, err
+	}
+
+	sort, ok := repositories.PertanyaanSort(filters["sort"])
+	if !ok {
+		return nil, nil, errors.New("sort tidak valid")
+	}
+
+	page := repositories.NewPageRequest(filters["limit"], filters["page"], filters["cursor"])
+	pertanyaans, result, err := s.pertanyaanRepo.GetByProductID(productID, sort, page)
+	if err == repositories.ErrInvalidCursor {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, errors.New("gagal mengambil data pertanyaan")
+	}
+
+	if viewerID != 0 && len(pertanyaans) > 0 {
+		ids := make([]uint, len(pertanyaans))
+		for i, pertanyaan := range pertanyaans {
+			ids[i] = pertanyaan.ID
+		}
+		upvoted, err := s.pertanyaanRepo.GetUpvotedIDs(viewerID, ids)
+		if err != nil {
+			return nil, nil, errors.New("gagal mengambil data pertanyaan")
+		}
+		upvotedSet := make(map[uint]bool, len(upvoted))
+		for _, id := range upvoted {
+			upvotedSet[id] = true
+		}
+		for i := range pertanyaans {
+			pertanyaans[i].Diupvote = upvotedSet[pertanyaans[i].ID]
+		}
+	}
+
+	return pertanyaans, paginationInfo(page, result), nil
+}
+
+// AskQuestion membuat pertanyaan publik untuk produk dan memberi tahu pemilik toko
+func (s *PertanyaanService) AskQuestion(productID uint, userID uint, teks string) (*models.Pertanyaan, error) {
+	teks = strings.TrimSpace(teks)
+	if teks == "" {
+		return nil, errors.New("pertanyaan tidak boleh kosong")
+	}
+	if len(teks) > 1000 {
+		return nil, errors.New("pertanyaan maksimal 1000 karakter")
+	}
+
+	product, err := s.productService.GetProductByID(productID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pertanyaan := &models.Pertanyaan{
+		IdProduk:   productID,
+		IdUser:     userID,
+		Pertanyaan: teks,
+	}
+	if err := s.pertanyaanRepo.Create(pertanyaan); err != nil {
+		return nil, errors.New("gagal menyimpan pertanyaan")
+	}
+
+	if ownerID, err := s.productRepo.GetOwnerUserID(productID); err == nil && ownerID != userID {
+		s.notifikasiService.Notify(ownerID, models.NotifikasiPertanyaanBaru,
+			"Pertanyaan baru",
+			"Ada pertanyaan baru untuk produk \""+product.NamaProduk+"\": "+teks,
+			"question:"+strconv.FormatUint(uint64(pertanyaan.ID), 10))
+	}
+
+	return s.pertanyaanRepo.GetByID(pertanyaan.ID)
+}
+
+// AnswerQuestion menyimpan jawaban pemilik toko untuk pertanyaan produknya dan memberi tahu penanya
+func (s *PertanyaanService) AnswerQuestion(id uint, userID uint, jawaban string) (*models.Pertanyaan, error) {
+	jawaban = strings.TrimSpace(jawaban)
+	if jawaban == "" {
+		return nil, errors.New("jawaban tidak boleh kosong")
+	}
+	if len(jawaban) > 2000 {
+		return nil, errors.New("jawaban maksimal 2000 karakter")
+	}
+
+	pertanyaan, err := s.getVisible(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cek ownership (user harus pemilik toko yang memiliki produk)
+	isOwner, err := s.productRepo.CheckOwnership(pertanyaan.IdProduk, userID)
+	if err != nil {
+		return nil, errors.New("gagal mengecek kepemilikan produk")
+	}
+	if !isOwner {
+		return nil, errors.New("anda tidak memiliki akses untuk menjawab pertanyaan ini")
+	}
+
+	if err := s.pertanyaanRepo.SetAnswer(id, jawaban); err != nil {
+		return nil, errors.New("gagal menyimpan jawaban")
+	}
+
+	if pertanyaan.IdUser != userID {
+		s.notifikasiService.Notify(pertanyaan.IdUser, models.NotifikasiPertanyaanDijawab,
+			"Pertanyaan dijawab",
+			"Penjual menjawab pertanyaan anda: "+jawaban,
+			"question:"+strconv.FormatUint(uint64(id), 10))
+	}
+
+	return s.pertanyaanRepo.GetByID(id)
+}
+
+// Upvote memberi upvote pada pertanyaan; upvote ulang tidak mengubah jumlah
+func (s *PertanyaanService) Upvote(id uint, userID uint) (*models.Pertanyaan, error) {
+	if _, err := s.getVisible(id); err != nil {
+		return nil, err
+	}
+	if err := s.pertanyaanRepo.AddUpvote(id, userID); err != nil {
+		return nil, errors.New("gagal menyimpan upvote")
+	}
+	return s.withUpvoted(id, true)
+}
+
+// RemoveUpvote membatalkan upvote user pada pertanyaan
+func (s *PertanyaanService) RemoveUpvote(id uint, userID uint) (*models.Pertanyaan, error) {
+	if _, err := s.getVisible(id); err != nil {
+		return nil, err
+	}
+	if err := s.pertanyaanRepo.RemoveUpvote(id, userID); err != nil {
+		return nil, errors.New("gagal membatalkan upvote")
+	}
+	return s.withUpvoted(id, false)
+}
+
+// getVisible mengambil pertanyaan yang tidak disembunyikan admin
+func (s *PertanyaanService) getVisible(id uint) (*models.Pertanyaan, error) {
+	pertanyaan, err := s.pertanyaanRepo.GetByID(id)
+	if err != nil || pertanyaan.Disembunyikan {
+		return nil, errors.New("pertanyaan tidak ditemukan")
+	}
+	return pertanyaan, nil
+}
+
+func (s *PertanyaanService) withUpvoted(id uint, upvoted bool) (*models.Pertanyaan, error) {
+	pertanyaan, err := s.pertanyaanRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("pertanyaan tidak ditemukan")
+	}
+	pertanyaan.Diupvote = upvoted
+	return pertanyaan, nil
+}
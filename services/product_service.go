@@ -1,70 +1,202 @@
 package services
 
 import (
+	"context"
 	"evernos-api2/models"
 	"evernos-api2/repositories"
+	"evernos-api2/search"
 	"errors"
+	"log"
 	"strconv"
 	"strings"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type ProductService struct {
-	productRepo *repositories.ProductRepository
+	productRepo       *repositories.ProductRepository
+	mutasiStokRepo    *repositories.MutasiStokRepository
+	fotoProdukService *FotoProdukService
+	wishlistService   *WishlistService
+	searchEngine      search.Engine
 }
 
-func NewProductService(productRepo *repositories.ProductRepository) *ProductService {
-	return &ProductService{productRepo: productRepo}
+func NewProductService(productRepo *repositories.ProductRepository, mutasiStokRepo *repositories.MutasiStokRepository, fotoProdukService *FotoProdukService, wishlistService *WishlistService, searchEngine search.Engine) *ProductService {
+	return &ProductService{
+		productRepo:       productRepo,
+		mutasiStokRepo:    mutasiStokRepo,
+		fotoProdukService: fotoProdukService,
+		wishlistService:   wishlistService,
+		searchEngine:      searchEngine,
+	}
 }
 
-// GetAllProducts mengambil semua produk dengan filtering dan pagination
-func (s *ProductService) GetAllProducts(filters map[string]string) ([]models.Produk, map[string]interface{}, error) {
-	products, total, err := s.productRepo.GetAllWithFilters(filters)
-	if err != nil {
-		return nil, nil, errors.New("gagal mengambil data produk")
+// GetAllProducts mengambil semua produk dengan filtering, sorting, pagination, dan facet.
+// Jika q (atau nama_produk) diisi tanpa sort, produk diurutkan berdasarkan relevansi pencarian.
+// Hanya produk yang sudah terbit yang dimuat, kecuali viewer (user yang login, 0 jika anonim)
+// memfilter status untuk melihat produk tokonya sendiri.
+func (s *ProductService) GetAllProducts(filters map[string]string, viewerID uint) ([]models.Produk, map[string]interface{}, *models.ProductFacets, error) {
+	if status := filters["status"]; status != "" {
+		switch status {
+		case models.ProdukStatusDraft, models.ProdukStatusPublished, models.ProdukStatusArchived,
+			repositories.ProductStatusScheduled, repositories.ProductStatusAll:
+		default:
+			return nil, nil, nil, errors.New("status tidak valid")
+		}
+		tokoID, err := s.productRepo.GetTokoIDByUserID(viewerID)
+		if viewerID == 0 || err != nil {
+			return nil, nil, nil, errors.New("filter status hanya untuk pemilik toko")
+		}
+		filters["toko_id"] = strconv.FormatUint(uint64(tokoID), 10)
+	}
+
+	q := strings.TrimSpace(filters["q"])
+	if q == "" {
+		q = strings.TrimSpace(filters["nama_produk"])
+	}
+
+	// Query yang terlalu pendek untuk search engine (misalnya satu huruf) dicari dengan LIKE
+	useEngine := q != "" && search.Searchable(q)
+	if q != "" && !useEngine {
+		filters["q_like"] = q
 	}
 
-	// Parse pagination parameters
-	limit := 10
-	page := 1
+	sort, ok := repositories.ProductSort(filters["sort"])
+	if !ok {
+		return nil, nil, nil, errors.New("sort tidak valid")
+	}
+	if useEngine && filters["sort"] == "" {
+		sort = repositories.RelevanceSort
+	}
+	page := repositories.NewPageRequest(filters["limit"], filters["page"], filters["cursor"])
 
-	if limitStr := filters["limit"]; limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
+	// searchIDs nil berarti tanpa pencarian; slice kosong berarti pencarian tanpa hasil.
+	// Engine hanya mengembalikan SEARCH_MAX_HITS hasil teratas sehingga total dan facet
+	// dihitung dari hasil tersebut; pagination ditandai truncated jika batasnya tercapai.
+	var searchIDs []uint
+	truncated := false
+	if useEngine {
+		ids, err := s.searchIDs(q)
+		if err != nil {
+			return nil, nil, nil, errors.New("gagal mengambil data produk")
 		}
+		searchIDs = ids
+		truncated = len(ids) >= search.MaxHits()
+	}
+
+	var products []models.Produk
+	var result repositories.PageResult
+	var err error
+	if searchIDs != nil {
+		products, result, err = s.productRepo.GetByIDsWithFilters(searchIDs, filters, sort, page)
+	} else {
+		products, result, err = s.productRepo.GetAllWithFilters(filters, sort, page)
+	}
+	if err == repositories.ErrInvalidCursor {
+		return nil, nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, nil, errors.New("gagal mengambil data produk")
 	}
 
-	if pageStr := filters["page"]; pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
+	if q != "" {
+		for i := range products {
+			products[i].Highlight = map[string]string{
+				"nama_produk": search.Highlight(products[i].NamaProduk, q),
+				"deskripsi":   search.Snippet(products[i].Deskripsi, q, 160),
+			}
 		}
 	}
 
-	// Calculate pagination info
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-	hasNext := page < totalPages
-	hasPrev := page > 1
+	facets, err := s.productRepo.GetFacets(searchIDs, filters)
+	if err != nil {
+		return nil, nil, nil, errors.New("gagal menghitung facet produk")
+	}
 
-	pagination := map[string]interface{}{
-		"current_page": page,
-		"total_pages":  totalPages,
-		"total_items":  total,
-		"limit":        limit,
-		"has_next":     hasNext,
-		"has_prev":     hasPrev,
+	pagination := paginationInfo(page, result)
+	if truncated {
+		pagination["truncated"] = true
 	}
 
-	return products, pagination, nil
+	fillProductPhotoURLs(products)
+	return products, pagination, facets, nil
 }
 
-// GetProductByID mengambil produk berdasarkan ID
-func (s *ProductService) GetProductByID(id uint) (*models.Produk, error) {
+// searchIDs mencari ID produk lewat search engine, urut dari yang paling relevan.
+// Filter dan pagination diterapkan di database.
+func (s *ProductService) searchIDs(q string) ([]uint, error) {
+	hits, err := s.searchEngine.Search(context.Background(), search.Query{Text: q, Limit: search.MaxHits()})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return ids, nil
+}
+
+// RebuildSearchIndex membangun ulang index pencarian dari database (untuk engine in-memory)
+func (s *ProductService) RebuildSearchIndex() error {
+	return s.searchEngine.Rebuild(context.Background(), func() ([]search.Document, error) {
+		return s.productRepo.GetSearchDocuments()
+	})
+}
+
+// indexProduct memperbarui dokumen produk di index pencarian. Kegagalan hanya di-log karena
+// data produk sudah tersimpan dan index akan lengkap kembali saat rebuild.
+func (s *ProductService) indexProduct(id uint) {
+	docs, err := s.productRepo.GetSearchDocuments(id)
+	if err == nil {
+		err = s.searchEngine.Index(context.Background(), docs...)
+	}
+	if err != nil {
+		log.Printf("failed to index product %d: %v", id, err)
+	}
+}
+
+// GetProductByID mengambil produk berdasarkan ID. Produk yang belum terbit (draft, terjadwal,
+// atau diarsipkan) hanya bisa dilihat oleh pemilik tokonya (viewerID, 0 jika anonim).
+func (s *ProductService) GetProductByID(id uint, viewerID uint) (*models.Produk, error) {
 	product, err := s.productRepo.GetByID(id)
 	if err != nil {
 		return nil, errors.New("produk tidak ditemukan")
 	}
+
+	if !product.IsPublished(time.Now()) {
+		isOwner := false
+		if viewerID != 0 {
+			isOwner, _ = s.productRepo.CheckOwnership(id, viewerID)
+		}
+		if !isOwner {
+			return nil, errors.New("produk tidak ditemukan")
+		}
+	}
+	fillPhotoURLs(product.FotoProduk)
 	return product, nil
 }
 
+// GetProductBySlug mengambil produk toko berdasarkan slug. Jika slug adalah slug lama produk yang
+// sudah diganti nama, moved bernilai true dan pemanggil sebaiknya redirect ke product.Slug.
+func (s *ProductService) GetProductBySlug(tokoID uint, slug string, viewerID uint) (product *models.Produk, moved bool, err error) {
+	if current, err := s.productRepo.GetByTokoAndSlug(tokoID, slug); err == nil {
+		product, err := s.GetProductByID(current.ID, viewerID)
+		return product, false, err
+	}
+
+	history, err := s.productRepo.GetSlugHistory(tokoID, slug)
+	if err != nil {
+		return nil, false, errors.New("produk tidak ditemukan")
+	}
+	product, err = s.GetProductByID(history.IdProduk, viewerID)
+	if err != nil {
+		return nil, false, err
+	}
+	return product, true, nil
+}
+
 // CreateProduct membuat produk baru
 func (s *ProductService) CreateProduct(userID uint, productData map[string]interface{}) (*models.Produk, error) {
 	// Validasi input
@@ -74,12 +206,14 @@ func (s *ProductService) CreateProduct(userID uint, productData map[string]inter
 
 	// Ambil data dari map
 	namaProduk := productData["nama_produk"].(string)
-	hargaReseller := productData["harga_reseller"].(string)
-	hargaKonsumen := productData["harga_konsumen"].(string)
+	hargaReseller, _ := s.parseHarga(productData["harga_reseller"])
+	hargaKonsumen, _ := s.parseHarga(productData["harga_konsumen"])
 	stok := int(productData["stok"].(float64))
 	deskripsi := productData["deskripsi"].(string)
 	idCategory := uint(productData["id_category"].(float64))
 	idToko := uint(productData["id_toko"].(float64))
+	sku, _ := productData["sku"].(string)
+	sku = strings.TrimSpace(sku)
 
 	// Validasi kategori exists
 	categoryExists, err := s.productRepo.CheckCategoryExists(idCategory)
@@ -106,25 +240,46 @@ func (s *ProductService) CreateProduct(userID uint, productData map[string]inter
 		// This would need a direct DB query, for now we'll assume validation passes
 	}
 
-	// Generate slug
-	slug := s.productRepo.GenerateSlug(namaProduk)
+	// SKU opsional, tapi harus unik di dalam toko
+	if err := s.validateSku(idToko, sku, 0); err != nil {
+		return nil, err
+	}
+
+	// Generate slug (unik per toko)
+	slug, err := s.productRepo.GenerateSlug(idToko, namaProduk, 0)
+	if err != nil {
+		return nil, errors.New("gagal membuat slug produk")
+	}
 
 	// Buat produk baru
 	product := &models.Produk{
 		IdToko:        idToko,
 		NamaProduk:    namaProduk,
 		Slug:          slug,
+		Sku:           sku,
 		HargaReseller: hargaReseller,
 		HargaKonsumen: hargaKonsumen,
 		Stok:          stok,
 		Deskripsi:     deskripsi,
 		IdCategory:    idCategory,
+		Status:        models.ProdukStatusPublished,
+	}
+	if err := s.applyStatus(product, productData); err != nil {
+		return nil, err
+	}
+	if err := s.applyModeration(product); err != nil {
+		return nil, err
 	}
 
-	err = s.productRepo.Create(product)
+	err = s.productRepo.Create(product, models.MutasiStok{
+		Jenis:   models.MutasiAdjustment,
+		IdUser:  &userID,
+		Catatan: "stok awal",
+	})
 	if err != nil {
 		return nil, errors.New("gagal membuat produk")
 	}
+	s.indexProduct(product.ID)
 
 	return product, nil
 }
@@ -145,35 +300,52 @@ func (s *ProductService) UpdateProduct(id uint, userID uint, updateData map[stri
 	if !isOwner {
 		return nil, errors.New("anda tidak memiliki akses untuk mengupdate produk ini")
 	}
+	before := *product
 
 	// Update field yang diizinkan
 	if namaProduk, ok := updateData["nama_produk"].(string); ok {
 		if err := s.validateNamaProduk(namaProduk); err != nil {
 			return nil, err
 		}
+		// Slug hanya dibuat ulang jika nama berubah; slug lama di-redirect ke slug baru
+		if namaProduk != product.NamaProduk {
+			slug, err := s.productRepo.GenerateSlug(product.IdToko, namaProduk, product.ID)
+			if err != nil {
+				return nil, errors.New("gagal membuat slug produk")
+			}
+			product.Slug = slug
+		}
 		product.NamaProduk = namaProduk
-		product.Slug = s.productRepo.GenerateSlug(namaProduk)
 	}
 
-	if hargaReseller, ok := updateData["harga_reseller"].(string); ok {
-		if err := s.validateHarga(hargaReseller); err != nil {
+	// Stok dan harga produk dengan varian dihitung dari variannya
+	if len(product.VarianProduk) > 0 {
+		for _, field := range []string{"harga_reseller", "harga_konsumen", "stok"} {
+			if _, ok := updateData[field]; ok {
+				return nil, errors.New("stok dan harga produk dengan varian diatur per varian")
+			}
+		}
+	}
+
+	if harga, ok := updateData["harga_reseller"]; ok {
+		hargaReseller, err := s.parseHarga(harga)
+		if err != nil {
 			return nil, err
 		}
 		product.HargaReseller = hargaReseller
 	}
 
-	if hargaKonsumen, ok := updateData["harga_konsumen"].(string); ok {
-		if err := s.validateHarga(hargaKonsumen); err != nil {
+	if harga, ok := updateData["harga_konsumen"]; ok {
+		hargaKonsumen, err := s.parseHarga(harga)
+		if err != nil {
 			return nil, err
 		}
 		product.HargaKonsumen = hargaKonsumen
 	}
 
-	if stok, ok := updateData["stok"].(float64); ok {
-		if stok < 0 {
-			return nil, errors.New("stok tidak boleh negatif")
-		}
-		product.Stok = int(stok)
+	stok, stokChanged := updateData["stok"].(float64)
+	if stokChanged && stok < 0 {
+		return nil, errors.New("stok tidak boleh negatif")
 	}
 
 	if deskripsi, ok := updateData["deskripsi"].(string); ok {
@@ -183,6 +355,18 @@ func (s *ProductService) UpdateProduct(id uint, userID uint, updateData map[stri
 		product.Deskripsi = deskripsi
 	}
 
+	if sku, ok := updateData["sku"].(string); ok {
+		sku = strings.TrimSpace(sku)
+		if err := s.validateSku(product.IdToko, sku, product.ID); err != nil {
+			return nil, err
+		}
+		product.Sku = sku
+	}
+
+	if err := s.applyStatus(product, updateData); err != nil {
+		return nil, err
+	}
+
 	if idCategory, ok := updateData["id_category"].(float64); ok {
 		categoryExists, err := s.productRepo.CheckCategoryExists(uint(idCategory))
 		if err != nil {
@@ -194,15 +378,196 @@ func (s *ProductService) UpdateProduct(id uint, userID uint, updateData map[stri
 		product.IdCategory = uint(idCategory)
 	}
 
-	// Simpan perubahan
-	err = s.productRepo.Update(product)
-	if err != nil {
-		return nil, errors.New("gagal mengupdate produk")
+	moderated := materiallyChanged(&before, product)
+	if moderated {
+		if err := s.applyModeration(product); err != nil {
+			return nil, err
+		}
+	}
+
+	// Simpan perubahan. Perubahan stok manual disimpan di transaksi yang sama dan dicatat sebagai
+	// adjustment di ledger.
+	if !stokChanged {
+		if err := s.productRepo.Update(product, moderated); err != nil {
+			return nil, errors.New("gagal mengupdate produk")
+		}
+	} else {
+		mutasi, err := s.productRepo.UpdateWithStock(product, moderated, int(stok), models.MutasiStok{
+			Jenis:     models.MutasiAdjustment,
+			IdUser:    &userID,
+			Referensi: "product:" + strconv.FormatUint(uint64(product.ID), 10),
+			Catatan:   "ubah stok produk",
+		})
+		if err != nil {
+			return nil, errors.New("gagal mengupdate produk")
+		}
+		product.Stok = int(stok)
+
+		// Stok yang sebelumnya habis (0) diisi kembali: beri tahu pelanggan stok di background
+		if mutasi.StokSetelah > 0 && mutasi.Jumlah == mutasi.StokSetelah {
+			go s.wishlistService.NotifyBackInStock(product.ID)
+		}
+	}
+	// Produk yang baru terbit: pelanggan yang stoknya diisi ulang saat produk belum terbit diberi tahu sekarang
+	if now := time.Now(); !before.IsPublished(now) && product.IsPublished(now) {
+		go s.wishlistService.NotifyBackInStock(product.ID)
 	}
+	s.indexProduct(product.ID)
+	fillPhotoURLs(product.FotoProduk)
 
 	return product, nil
 }
 
+// Hasil ImportProduct untuk satu baris import
+const (
+	ImportActionCreated = "created"
+	ImportActionUpdated = "updated"
+)
+
+// ImportProduct membuat atau memperbarui satu produk dari baris file import. Produk dicocokkan
+// berdasarkan SKU, atau slug jika SKU kosong; jika tidak ditemukan, produk baru dibuat. Data divalidasi
+// dengan aturan yang sama seperti CreateProduct. Perubahan stok dicatat di ledger dengan jenis import.
+// Dengan dryRun, hanya validasi yang dijalankan dan tidak ada data yang disimpan.
+func (s *ProductService) ImportProduct(userID, tokoID uint, data map[string]interface{}, dryRun bool, referensi string) (string, error) {
+	data["id_toko"] = float64(tokoID)
+	if err := s.validateProductData(data); err != nil {
+		return "", err
+	}
+
+	namaProduk := strings.TrimSpace(data["nama_produk"].(string))
+	hargaReseller, _ := s.parseHarga(data["harga_reseller"])
+	hargaKonsumen, _ := s.parseHarga(data["harga_konsumen"])
+	stok := int(data["stok"].(float64))
+	idCategory := uint(data["id_category"].(float64))
+	sku, _ := data["sku"].(string)
+	sku = strings.TrimSpace(sku)
+	slugStr, _ := data["slug"].(string)
+	slug := models.Slugify(slugStr)
+	if strings.TrimSpace(slugStr) != "" && slug == "" {
+		return "", errors.New("slug tidak valid")
+	}
+
+	categoryExists, err := s.productRepo.CheckCategoryExists(idCategory)
+	if err != nil {
+		return "", errors.New("gagal mengecek kategori")
+	}
+	if !categoryExists {
+		return "", errors.New("kategori tidak ditemukan")
+	}
+
+	var product *models.Produk
+	switch {
+	case sku != "":
+		product, err = s.productRepo.GetByTokoAndSku(tokoID, sku)
+	case slug != "":
+		product, err = s.productRepo.GetByTokoAndSlug(tokoID, slug)
+	default:
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return "", errors.New("gagal mencari produk")
+	}
+
+	mutasi := models.MutasiStok{
+		Jenis:     models.MutasiImport,
+		IdUser:    &userID,
+		Referensi: referensi,
+	}
+
+	if product == nil {
+		if err := s.validateSku(tokoID, sku, 0); err != nil {
+			return "", err
+		}
+		if err := s.validateSlug(tokoID, slug, 0); err != nil {
+			return "", err
+		}
+		if dryRun {
+			return ImportActionCreated, nil
+		}
+		if slug == "" {
+			if slug, err = s.productRepo.GenerateSlug(tokoID, namaProduk, 0); err != nil {
+				return "", errors.New("gagal membuat slug produk")
+			}
+		}
+		product = &models.Produk{
+			IdToko:        tokoID,
+			NamaProduk:    namaProduk,
+			Slug:          slug,
+			Sku:           sku,
+			HargaReseller: hargaReseller,
+			HargaKonsumen: hargaKonsumen,
+			Stok:          stok,
+			Deskripsi:     data["deskripsi"].(string),
+			IdCategory:    idCategory,
+			Status:        models.ProdukStatusPublished,
+		}
+		if err := s.applyModeration(product); err != nil {
+			return "", err
+		}
+		mutasi.Catatan = "stok awal"
+		if err := s.productRepo.Create(product, mutasi); err != nil {
+			return "", errors.New("gagal membuat produk")
+		}
+		s.indexProduct(product.ID)
+		return ImportActionCreated, nil
+	}
+
+	// Stok dan harga produk dengan varian diatur per varian
+	if len(product.VarianProduk) > 0 {
+		return "", errors.New("produk dengan varian tidak bisa diubah lewat import")
+	}
+	if sku != "" {
+		if err := s.validateSku(tokoID, sku, product.ID); err != nil {
+			return "", err
+		}
+		product.Sku = sku
+	}
+	if err := s.validateSlug(tokoID, slug, product.ID); err != nil {
+		return "", err
+	}
+	if dryRun {
+		return ImportActionUpdated, nil
+	}
+
+	before := *product
+
+	// Tanpa kolom slug, slug dibuat ulang hanya jika nama berubah (sama seperti UpdateProduct)
+	if slug == "" && namaProduk != product.NamaProduk {
+		if slug, err = s.productRepo.GenerateSlug(tokoID, namaProduk, product.ID); err != nil {
+			return "", errors.New("gagal membuat slug produk")
+		}
+	}
+	product.NamaProduk = namaProduk
+	if slug != "" {
+		product.Slug = slug
+	}
+	product.HargaReseller = hargaReseller
+	product.HargaKonsumen = hargaKonsumen
+	product.Deskripsi = data["deskripsi"].(string)
+	product.IdCategory = idCategory
+	moderated := materiallyChanged(&before, product)
+	if moderated {
+		if err := s.applyModeration(product); err != nil {
+			return "", err
+		}
+	}
+	if _, err := s.productRepo.UpdateWithStock(product, moderated, stok, mutasi); err != nil {
+		return "", errors.New("gagal mengupdate produk")
+	}
+	s.indexProduct(product.ID)
+	return ImportActionUpdated, nil
+}
+
+// GetProductsByTokoID mengambil semua produk toko (dipakai untuk export)
+func (s *ProductService) GetProductsByTokoID(tokoID uint) ([]models.Produk, error) {
+	products, err := s.productRepo.GetByTokoID(tokoID)
+	if err != nil {
+		return nil, errors.New("gagal mengambil data produk")
+	}
+	fillProductPhotoURLs(products)
+	return products, nil
+}
+
 // DeleteProduct menghapus produk
 func (s *ProductService) DeleteProduct(id uint, userID uint) error {
 	// Cek apakah produk ada
@@ -223,12 +588,35 @@ func (s *ProductService) DeleteProduct(id uint, userID uint) error {
 		return errors.New("anda tidak memiliki akses untuk menghapus produk ini")
 	}
 
-	// Hapus produk
-	err = s.productRepo.Delete(id)
+	return s.purgeProduct(id)
+}
+
+// DeleteStoreProducts menghapus semua produk toko dengan alur yang sama seperti DeleteProduct,
+// dipakai saat akun pemilik toko dianonimkan
+func (s *ProductService) DeleteStoreProducts(tokoID uint) error {
+	products, err := s.productRepo.GetByTokoID(tokoID)
 	if err != nil {
-		return errors.New("gagal menghapus produk")
+		return errors.New("gagal mengambil produk toko")
 	}
+	for _, product := range products {
+		if err := s.purgeProduct(product.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+// purgeProduct menghapus produk beserta fotonya, lalu melepas file foto di storage dan
+// menghapus produk dari index pencarian
+func (s *ProductService) purgeProduct(id uint) error {
+	fotoProduks, err := s.productRepo.DeleteWithPhotos(id)
+	if err != nil {
+		return errors.New("gagal menghapus produk")
+	}
+	s.fotoProdukService.ReleasePhotos(fotoProduks)
+	if err := s.searchEngine.Delete(context.Background(), id); err != nil {
+		log.Printf("failed to remove product %d from search index: %v", id, err)
+	}
 	return nil
 }
 
@@ -244,20 +632,18 @@ func (s *ProductService) validateProductData(data map[string]interface{}) error
 	}
 
 	// Validasi harga reseller
-	hargaReseller, ok := data["harga_reseller"].(string)
-	if !ok || strings.TrimSpace(hargaReseller) == "" {
+	if hargaKosong(data["harga_reseller"]) {
 		return errors.New("harga reseller tidak boleh kosong")
 	}
-	if err := s.validateHarga(hargaReseller); err != nil {
+	if _, err := s.parseHarga(data["harga_reseller"]); err != nil {
 		return err
 	}
 
 	// Validasi harga konsumen
-	hargaKonsumen, ok := data["harga_konsumen"].(string)
-	if !ok || strings.TrimSpace(hargaKonsumen) == "" {
+	if hargaKosong(data["harga_konsumen"]) {
 		return errors.New("harga konsumen tidak boleh kosong")
 	}
-	if err := s.validateHarga(hargaKonsumen); err != nil {
+	if _, err := s.parseHarga(data["harga_konsumen"]); err != nil {
 		return err
 	}
 
@@ -303,15 +689,144 @@ func (s *ProductService) validateNamaProduk(namaProduk string) error {
 	return nil
 }
 
-// validateHarga memvalidasi format harga
-func (s *ProductService) validateHarga(harga string) error {
-	harga = strings.TrimSpace(harga)
-	if harga == "" {
-		return errors.New("harga tidak boleh kosong")
+// hargaKosong mengecek apakah harga tidak dikirim atau berupa string kosong
+func hargaKosong(harga interface{}) bool {
+	str, isString := harga.(string)
+	return harga == nil || (isString && strings.TrimSpace(str) == "")
+}
+
+// parseHarga memvalidasi harga rupiah (maksimal dua angka desimal) dan mengubahnya ke Money. Harga boleh
+// berupa string atau angka JSON, sama seperti harga varian.
+func (s *ProductService) parseHarga(harga interface{}) (models.Money, error) {
+	if hargaKosong(harga) {
+		return 0, errors.New("harga tidak boleh kosong")
+	}
+	if str, ok := harga.(string); ok {
+		harga = strings.TrimSpace(str)
+	}
+	price, err := models.MoneyFromJSON(harga)
+	if err != nil {
+		return 0, errors.New("format harga tidak valid")
+	}
+	return price, nil
+}
+
+// validateSku memvalidasi SKU produk: maksimal 100 karakter dan unik di dalam toko
+func (s *ProductService) validateSku(tokoID uint, sku string, excludeProductID uint) error {
+	if sku == "" {
+		return nil
+	}
+	if len(sku) > 100 {
+		return errors.New("SKU maksimal 100 karakter")
+	}
+	taken, err := s.productRepo.CheckSkuTaken(tokoID, sku, excludeProductID)
+	if err != nil {
+		return errors.New("gagal mengecek SKU")
+	}
+	if taken {
+		return errors.New("SKU " + sku + " sudah dipakai produk lain di toko ini")
+	}
+	return nil
+}
+
+// applyStatus mengubah status publikasi produk dari field status dan publish_at (RFC3339).
+// Status published tanpa publish_at berarti terbit sekarang; publish_at di masa depan menjadwalkan
+// produk terbit pada waktu tersebut.
+func (s *ProductService) applyStatus(product *models.Produk, data map[string]interface{}) error {
+	status, hasStatus := data["status"].(string)
+	publishAtStr, hasPublishAt := data["publish_at"].(string)
+
+	if hasStatus {
+		status = strings.ToLower(strings.TrimSpace(status))
+		switch status {
+		case models.ProdukStatusDraft, models.ProdukStatusPublished, models.ProdukStatusArchived:
+		default:
+			return errors.New("status harus draft, published, atau archived")
+		}
+		product.Status = status
+		if !hasPublishAt {
+			product.PublishAt = nil
+		}
+	}
+
+	if hasPublishAt {
+		publishAt, err := time.Parse(time.RFC3339, strings.TrimSpace(publishAtStr))
+		if err != nil {
+			return errors.New("publish_at harus berformat RFC3339, misalnya 2026-01-31T09:00:00+07:00")
+		}
+		product.PublishAt = &publishAt
+	}
+
+	if product.PublishAt != nil && product.Status != models.ProdukStatusPublished {
+		return errors.New("publish_at hanya bisa diisi untuk status published")
+	}
+	return nil
+}
+
+// applyModeration menentukan status moderasi produk baru atau yang diubah secara material: produk di
+// kategori yang wajib dimoderasi masuk antrian (pending), selain itu langsung disetujui. Produk yang
+// pernah ditolak admin selalu diperiksa ulang.
+func (s *ProductService) applyModeration(product *models.Produk) error {
+	return applyModeration(s.productRepo, product)
+}
+
+// resubmitModeration menerapkan applyModeration ke produk yang foto atau variannya berubah lalu
+// menyimpan status moderasinya saja
+func resubmitModeration(productRepo *repositories.ProductRepository, productID uint) error {
+	product, err := productRepo.GetByID(productID)
+	if err != nil {
+		return errors.New("gagal memperbarui status moderasi produk")
+	}
+	before := product.ModerationStatus
+	if err := applyModeration(productRepo, product); err != nil {
+		return err
+	}
+	if product.ModerationStatus == before {
+		return nil
+	}
+	if err := productRepo.ResetModeration(product); err != nil {
+		return errors.New("gagal memperbarui status moderasi produk")
+	}
+	return nil
+}
+
+func applyModeration(productRepo *repositories.ProductRepository, product *models.Produk) error {
+	requiresModeration, err := productRepo.CategoryRequiresModeration(product.IdCategory)
+	if err != nil {
+		return errors.New("gagal mengecek moderasi kategori")
+	}
+	before := product.ModerationStatus
+
+	product.ModerationStatus = models.ModerasiApproved
+	if requiresModeration || before == models.ModerasiRejected {
+		product.ModerationStatus = models.ModerasiPending
+	}
+	product.ModerationReason = ""
+	product.ModeratedAt = nil
+	product.ModeratedBy = nil
+	return nil
+}
+
+// materiallyChanged mengecek perubahan yang perlu dimoderasi ulang (nama, deskripsi, atau kategori).
+// Perubahan harga, stok, SKU, dan status publikasi tidak perlu dimoderasi. Foto/video baru dan
+// perubahan opsi varian dimoderasi ulang lewat resubmitModeration.
+func materiallyChanged(before, after *models.Produk) bool {
+	return before.NamaProduk != after.NamaProduk ||
+		before.Deskripsi != after.Deskripsi ||
+		before.IdCategory != after.IdCategory
+}
+
+// validateSlug memastikan slug yang diisi manual (sudah dinormalisasi) belum dipakai produk lain di toko
+func (s *ProductService) validateSlug(tokoID uint, slug string, excludeProductID uint) error {
+	if slug == "" {
+		return nil
+	}
+	taken, err := s.productRepo.CheckSlugTaken(tokoID, slug, excludeProductID)
+	if err != nil {
+		return errors.New("gagal mengecek slug")
 	}
-	// Bisa ditambahkan validasi format angka jika diperlukan
-	if _, err := strconv.ParseFloat(harga, 64); err != nil {
-		return errors.New("format harga tidak valid")
+	if taken {
+		return errors.New("slug " + slug + " sudah dipakai produk lain di toko ini")
 	}
 	return nil
 }
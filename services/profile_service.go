@@ -4,23 +4,54 @@ import (
 	"evernos-api2/models"
 	"evernos-api2/repositories"
 	"errors"
+	"os"
+	"strconv"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type ProfileService interface {
 	GetProfile(userID uint) (*models.User, error)
 	UpdateProfile(userID uint, updateData map[string]string) (*models.User, error)
+	ChangePassword(userID uint, sessionID uint, currentPassword, newPassword string) error
+	ExportPersonalData(userID uint) (*PersonalDataExport, error)
+	RequestAccountDeletion(userID uint, password string) (time.Time, error)
+	AnonymizeDueAccounts() (int, error)
+}
+
+// PersonalDataExport berisi seluruh data pribadi user untuk permintaan ekspor data
+type PersonalDataExport struct {
+	ExportedAt time.Time              `json:"exported_at"`
+	Profile    map[string]interface{} `json:"profile"`
+	Alamat     []models.Alamat        `json:"alamat"`
+	Toko       *models.Toko           `json:"toko"`
+	Produk     []models.Produk        `json:"produk"`
+	Transaksi  []models.Trx           `json:"transaksi"`
+}
+
+// AccountDeletionGracePeriod adalah masa tenggang sebelum data akun dianonimkan.
+// Dikonfigurasi lewat ACCOUNT_DELETION_GRACE_DAYS (default 30 hari).
+func AccountDeletionGracePeriod() time.Duration {
+	days := 30
+	if v, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_GRACE_DAYS")); err == nil && v >= 0 {
+		days = v
+	}
+	return time.Duration(days) * 24 * time.Hour
 }
 
 type profileService struct {
-	profileRepo repositories.ProfileRepository
+	profileRepo    repositories.ProfileRepository
+	sessionService *SessionService
+	productService *ProductService
 }
 
-func NewProfileService(profileRepo repositories.ProfileRepository) ProfileService {
+func NewProfileService(profileRepo repositories.ProfileRepository, sessionService *SessionService, productService *ProductService) ProfileService {
 	return &profileService{
-		profileRepo: profileRepo,
+		profileRepo:    profileRepo,
+		sessionService: sessionService,
+		productService: productService,
 	}
 }
 
@@ -33,6 +64,11 @@ func (s *profileService) GetProfile(userID uint) (*models.User, error) {
 }
 
 func (s *profileService) UpdateProfile(userID uint, updateData map[string]string) (*models.User, error) {
+	// Password hanya bisa diganti lewat endpoint khusus yang memverifikasi password lama
+	if _, ok := updateData["password"]; ok {
+		return nil, errors.New("password cannot be changed here, use PUT /api/profile/password")
+	}
+
 	// Ambil user yang akan diupdate
 	user, err := s.profileRepo.GetByID(userID)
 	if err != nil {
@@ -72,15 +108,6 @@ func (s *profileService) UpdateProfile(userID uint, updateData map[string]string
 		user.IdKota = updateData["idKota"]
 	}
 
-	// Update password jika diberikan
-	if updateData["password"] != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(updateData["password"]), bcrypt.DefaultCost)
-		if err != nil {
-			return nil, errors.New("failed to hash password")
-		}
-		user.KataSandi = string(hashedPassword)
-	}
-
 	// Simpan perubahan
 	err = s.profileRepo.Update(user)
 	if err != nil {
@@ -88,4 +115,144 @@ func (s *profileService) UpdateProfile(userID uint, updateData map[string]string
 	}
 
 	return user, nil
-}
\ No newline at end of file
+}
+
+func (s *profileService) ChangePassword(userID uint, sessionID uint, currentPassword, newPassword string) error {
+	user, err := s.profileRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	// Verifikasi password lama
+	if err := bcrypt.CompareHashAndPassword([]byte(user.KataSandi), []byte(currentPassword)); err != nil {
+		return errors.New("current password is incorrect")
+	}
+
+	if currentPassword == newPassword {
+		return errors.New("new password must be different from current password")
+	}
+
+	if err := ValidatePassword(newPassword, user.Email, user.Nama, user.NoTelp); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+	user.KataSandi = string(hashedPassword)
+
+	if err := s.profileRepo.Update(user); err != nil {
+		return errors.New("failed to update password")
+	}
+
+	// Logout dari semua perangkat lain setelah password diganti
+	if err := s.sessionService.RevokeOtherSessions(userID, sessionID); err != nil {
+		return errors.New("password updated but failed to revoke other sessions")
+	}
+
+	return nil
+}
+
+func (s *profileService) ExportPersonalData(userID uint) (*PersonalDataExport, error) {
+	user, err := s.profileRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	export := &PersonalDataExport{
+		ExportedAt: time.Now(),
+		Profile: map[string]interface{}{
+			"id":                  user.ID,
+			"nama":                user.Nama,
+			"email":               user.Email,
+			"noTelp":              user.NoTelp,
+			"tanggalLahir":        user.TanggalLahir.Format("2006-01-02"),
+			"jenisKelamin":        user.JenisKelamin,
+			"tentang":             user.Tentang,
+			"pekerjaan":           user.Pekerjaan,
+			"idProvinsi":          user.IdProvinsi,
+			"idKota":              user.IdKota,
+			"isAdmin":             user.IsAdmin,
+			"deletionRequestedAt": user.DeletionRequestedAt,
+			"createdAt":           user.CreatedAt,
+			"updatedAt":           user.UpdatedAt,
+		},
+	}
+
+	if export.Alamat, err = s.profileRepo.GetAlamats(userID); err != nil {
+		return nil, errors.New("failed to export addresses")
+	}
+
+	if toko, err := s.profileRepo.GetToko(userID); err == nil {
+		export.Toko = toko
+		if export.Produk, err = s.profileRepo.GetProductsByTokoID(toko.ID); err != nil {
+			return nil, errors.New("failed to export products")
+		}
+		fillProductPhotoURLs(export.Produk)
+	}
+
+	if export.Transaksi, err = s.profileRepo.GetTrxs(userID); err != nil {
+		return nil, errors.New("failed to export transactions")
+	}
+
+	return export, nil
+}
+
+func (s *profileService) RequestAccountDeletion(userID uint, password string) (time.Time, error) {
+	user, err := s.profileRepo.GetByID(userID)
+	if err != nil {
+		return time.Time{}, errors.New("user not found")
+	}
+	if user.IsAdmin {
+		return time.Time{}, errors.New("admin account cannot be deleted")
+	}
+
+	// Konfirmasi dengan password untuk mencegah penghapusan oleh token yang bocor
+	if err := bcrypt.CompareHashAndPassword([]byte(user.KataSandi), []byte(password)); err != nil {
+		return time.Time{}, errors.New("password is incorrect")
+	}
+
+	if user.DeletionRequestedAt == nil {
+		now := time.Now()
+		user.DeletionRequestedAt = &now
+		if err := s.profileRepo.Update(user); err != nil {
+			return time.Time{}, errors.New("failed to request account deletion")
+		}
+	}
+
+	// Logout dari semua perangkat, termasuk sesi saat ini
+	if err := s.sessionService.RevokeOtherSessions(userID, 0); err != nil {
+		return time.Time{}, errors.New("failed to revoke sessions")
+	}
+
+	return user.DeletionRequestedAt.Add(AccountDeletionGracePeriod()), nil
+}
+
+func (s *profileService) AnonymizeDueAccounts() (int, error) {
+	users, err := s.profileRepo.GetDueForAnonymization(time.Now().Add(-AccountDeletionGracePeriod()))
+	if err != nil {
+		return 0, errors.New("failed to fetch accounts pending deletion")
+	}
+
+	anonymized := 0
+	for _, user := range users {
+		// Produk toko dihapus lebih dulu (foto, blob, dan index pencarian ikut dilepas) agar
+		// akun yang gagal di tengah jalan diproses ulang pada run berikutnya
+		toko, err := s.profileRepo.GetToko(user.ID)
+		if err == nil {
+			if err := s.productService.DeleteStoreProducts(toko.ID); err != nil {
+				return anonymized, errors.New("failed to delete products of user " + strconv.Itoa(int(user.ID)))
+			}
+		} else if err != gorm.ErrRecordNotFound {
+			return anonymized, errors.New("failed to fetch store of user " + strconv.Itoa(int(user.ID)))
+		}
+
+		if err := s.profileRepo.Anonymize(user.ID); err != nil {
+			return anonymized, errors.New("failed to anonymize user " + strconv.Itoa(int(user.ID)))
+		}
+		anonymized++
+	}
+
+	return anonymized, nil
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"evernos-api2/models"
+	"evernos-api2/repositories"
+	"fmt"
+	"hash"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUploadSessionNotFound = errors.New("sesi upload tidak ditemukan atau sudah kedaluwarsa")
+	ErrUploadOffsetMismatch  = errors.New("Upload-Offset tidak sesuai dengan offset di server")
+	ErrUploadChecksum        = errors.New("checksum chunk tidak cocok")
+	ErrUploadChecksumAlgo    = errors.New("algoritma checksum tidak didukung")
+	ErrUploadExceedsLength   = errors.New("chunk melebihi Upload-Length")
+	ErrUploadAlreadyComplete = errors.New("upload sudah selesai")
+)
+
+// SupportedChecksumAlgorithms adalah algoritma untuk header Upload-Checksum (ekstensi checksum tus)
+var SupportedChecksumAlgorithms = []string{"sha1", "sha256", "md5"}
+
+// ResumableUploadService mengimplementasikan sisi server protokol tus 1.0.0 untuk file besar
+// (video dan foto resolusi tinggi). Chunk ditulis ke file staging; setelah lengkap file
+// divalidasi oleh UploadService lalu dilampirkan ke produk lewat FotoProdukService.
+// File staging dan kunci per sesi hanya ada di instance yang menerima upload, sehingga
+// service ini hanya mendukung satu instance API.
+type ResumableUploadService struct {
+	sessionRepo       *repositories.UploadSessionRepository
+	productRepo       *repositories.ProductRepository
+	fotoProdukService *FotoProdukService
+	uploadService     *UploadService
+	imageProcessor    *ImageProcessor
+	maxSize           int64
+	expiry            time.Duration
+	locks             sync.Map
+}
+
+func NewResumableUploadService(sessionRepo *repositories.UploadSessionRepository, productRepo *repositories.ProductRepository, fotoProdukService *FotoProdukService, uploadService *UploadService, imageProcessor *ImageProcessor) *ResumableUploadService {
+	return &ResumableUploadService{
+		sessionRepo:       sessionRepo,
+		productRepo:       productRepo,
+		fotoProdukService: fotoProdukService,
+		uploadService:     uploadService,
+		imageProcessor:    imageProcessor,
+		maxSize:           int64(envInt("RESUMABLE_MAX_SIZE", 200)) * 1024 * 1024,
+		expiry:            time.Duration(envInt("RESUMABLE_EXPIRY_HOURS", 24)) * time.Hour,
+	}
+}
+
+// MaxSize adalah ukuran file maksimum (header Tus-Max-Size)
+func (s *ResumableUploadService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// CreateSession membuat sesi upload baru untuk file sepanjang length byte
+func (s *ResumableUploadService) CreateSession(userID uint, length int64, metadata map[string]string) (*models.UploadSession, error) {
+	if length <= 0 {
+		return nil, errors.New("Upload-Length harus lebih dari 0")
+	}
+	if length > s.maxSize {
+		return nil, fmt.Errorf("ukuran file melebihi batas %d MB", s.maxSize/1024/1024)
+	}
+
+	fileName := filepath.Base(strings.TrimSpace(metadata["filename"]))
+	mediaType := MediaTypeForFile(fileName)
+	if mediaType == "" {
+		return nil, ErrMediaUnsupported
+	}
+	if mediaType == models.MediaTypeImage && length > MaxResumableImageSize {
+		return nil, ErrResumableImageSize
+	}
+
+	var productID uint
+	if _, err := fmt.Sscan(metadata["product_id"], &productID); err != nil || productID == 0 {
+		return nil, errors.New("metadata product_id harus disertakan")
+	}
+	isOwner, err := s.productRepo.CheckOwnership(productID, userID)
+	if err != nil {
+		return nil, errors.New("gagal mengecek kepemilikan produk")
+	}
+	if !isOwner {
+		return nil, errors.New("anda tidak memiliki akses untuk menambahkan foto ke produk ini")
+	}
+
+	session := &models.UploadSession{
+		ID:        uuid.New().String(),
+		IdUser:    userID,
+		IdProduk:  productID,
+		FileName:  fileName,
+		MediaType: mediaType,
+		Length:    length,
+		ExpiresAt: time.Now().Add(s.expiry),
+	}
+
+	// File kosong dibuat sekarang agar chunk pertama bisa langsung ditulis di offset 0
+	file, err := os.OpenFile(s.partPath(session.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, errors.New("gagal membuat sesi upload")
+	}
+	file.Close()
+
+	if err := s.sessionRepo.Create(session); err != nil {
+		os.Remove(s.partPath(session.ID))
+		return nil, errors.New("gagal membuat sesi upload")
+	}
+
+	return session, nil
+}
+
+// GetSession mengambil sesi upload milik user
+func (s *ResumableUploadService) GetSession(id string, userID uint) (*models.UploadSession, error) {
+	session, err := s.sessionRepo.GetByID(id, userID)
+	if err != nil {
+		return nil, ErrUploadSessionNotFound
+	}
+	return session, nil
+}
+
+// WriteChunk menulis chunk pada offset tertentu. checksum berformat "<algoritma> <base64>" sesuai
+// header Upload-Checksum (opsional). Jika file sudah lengkap, file langsung dilampirkan ke produk.
+func (s *ResumableUploadService) WriteChunk(id string, userID uint, offset int64, chunk []byte, checksum string) (*models.UploadSession, *models.FotoProduk, error) {
+	// Satu chunk per sesi dalam satu waktu agar dua request tidak menulis region yang sama
+	lock, _ := s.locks.LoadOrStore(id, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	session, err := s.GetSession(id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session.CompletedAt != nil {
+		return nil, nil, ErrUploadAlreadyComplete
+	}
+	if offset != session.Offset {
+		return nil, nil, ErrUploadOffsetMismatch
+	}
+	if offset+int64(len(chunk)) > session.Length {
+		return nil, nil, ErrUploadExceedsLength
+	}
+	if checksum != "" {
+		if err := verifyChecksum(chunk, checksum); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	file, err := os.OpenFile(s.partPath(id), os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, nil, errors.New("gagal menulis chunk")
+	}
+	_, err = file.WriteAt(chunk, offset)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, nil, errors.New("gagal menulis chunk")
+	}
+
+	newOffset := offset + int64(len(chunk))
+	advanced, err := s.sessionRepo.AdvanceOffset(id, offset, newOffset)
+	if err != nil {
+		return nil, nil, errors.New("gagal menyimpan progres upload")
+	}
+	if !advanced {
+		return nil, nil, ErrUploadOffsetMismatch
+	}
+	session.Offset = newOffset
+
+	if session.Offset < session.Length {
+		return session, nil, nil
+	}
+
+	fotoProduk, err := s.complete(session)
+	if err != nil {
+		return session, nil, err
+	}
+	return session, fotoProduk, nil
+}
+
+// Terminate membatalkan sesi upload dan menghapus data yang sudah diterima (ekstensi termination)
+func (s *ResumableUploadService) Terminate(id string, userID uint) error {
+	session, err := s.GetSession(id, userID)
+	if err != nil {
+		return err
+	}
+	s.discard(session.ID)
+	return nil
+}
+
+// CleanupExpired menghapus sesi dan file staging yang sudah kedaluwarsa
+func (s *ResumableUploadService) CleanupExpired() (int, error) {
+	sessions, err := s.sessionRepo.GetExpired(time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for _, session := range sessions {
+		s.discard(session.ID)
+	}
+	return len(sessions), nil
+}
+
+// StartJanitor menjalankan CleanupExpired secara berkala di background
+func (s *ResumableUploadService) StartJanitor(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			if removed, err := s.CleanupExpired(); err != nil {
+				log.Printf("failed to clean up expired upload sessions: %v", err)
+			} else if removed > 0 {
+				log.Printf("removed %d expired upload session(s)", removed)
+			}
+		}
+	}()
+}
+
+// complete memvalidasi file yang sudah lengkap lalu melampirkannya ke produk
+func (s *ResumableUploadService) complete(session *models.UploadSession) (*models.FotoProduk, error) {
+	partPath := s.partPath(session.ID)
+
+	contentType, err := s.uploadService.ValidateStagedFile(partPath, session.FileName)
+	if err != nil {
+		s.discard(session.ID)
+		return nil, err
+	}
+
+	// Beri nama final agar rendisi/key di storage mengikuti pola nama upload biasa
+	fileName := fmt.Sprintf("product_%d_%s_%s%s", session.IdUser, time.Now().Format("20060102_150405"),
+		session.ID[:8], strings.ToLower(filepath.Ext(session.FileName)))
+	finalPath := s.imageProcessor.StagingPath(fileName)
+	if err := os.Rename(partPath, finalPath); err != nil {
+		s.discard(session.ID)
+		return nil, ErrUploadSaveFailed
+	}
+
+	var fotoProduk *models.FotoProduk
+	if session.MediaType == models.MediaTypeVideo {
+		fotoProduk, err = s.fotoProdukService.AddVideoToProduct(session.IdProduk, session.IdUser, finalPath, fileName, contentType)
+		os.Remove(finalPath)
+	} else {
+		// Jika berhasil, file staging dihapus oleh ImageProcessor setelah diproses
+		fotoProduk, err = s.fotoProdukService.AddPhotoToProduct(session.IdProduk, session.IdUser, finalPath)
+	}
+	if err != nil {
+		os.Remove(finalPath)
+		s.sessionRepo.Delete(session.ID)
+		s.locks.Delete(session.ID)
+		return nil, err
+	}
+
+	if err := s.sessionRepo.MarkCompleted(session.ID, fotoProduk.ID); err != nil {
+		log.Printf("failed to mark upload session %s as completed: %v", session.ID, err)
+	}
+	now := time.Now()
+	session.CompletedAt = &now
+	session.IdFotoProduk = &fotoProduk.ID
+	return fotoProduk, nil
+}
+
+// discard menghapus sesi beserta file staging-nya
+func (s *ResumableUploadService) discard(id string) {
+	os.Remove(s.partPath(id))
+	s.sessionRepo.Delete(id)
+	s.locks.Delete(id)
+}
+
+func (s *ResumableUploadService) partPath(id string) string {
+	return s.imageProcessor.StagingPath("tus_" + id + ".part")
+}
+
+// verifyChecksum memeriksa header Upload-Checksum "<algoritma> <base64 digest>"
+func verifyChecksum(chunk []byte, header string) error {
+	parts := strings.SplitN(strings.TrimSpace(header), " ", 2)
+	if len(parts) != 2 {
+		return ErrUploadChecksumAlgo
+	}
+
+	var h hash.Hash
+	switch strings.ToLower(parts[0]) {
+	case "sha1":
+		h = sha1.New()
+	case "sha256":
+		h = sha256.New()
+	case "md5":
+		h = md5.New()
+	default:
+		return ErrUploadChecksumAlgo
+	}
+
+	expected, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ErrUploadChecksum
+	}
+	h.Write(chunk)
+	if string(h.Sum(nil)) != string(expected) {
+		return ErrUploadChecksum
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"evernos-api2/models"
+	"evernos-api2/repositories"
+	"time"
+	"unicode/utf8"
+)
+
+// Interval minimum antar update last_seen_at agar tidak menulis ke database di setiap request
+const sessionTouchInterval = time.Minute
+
+// Masa berlaku token login dan sesinya
+const SessionTTL = 72 * time.Hour
+
+// Panjang maksimum user agent yang disimpan dalam byte. Kolom varchar(512) menghitung karakter,
+// jadi batas byte ini selalu muat.
+const maxUserAgentLength = 512
+
+type SessionService struct {
+	sessionRepo *repositories.SessionRepository
+}
+
+func NewSessionService(sessionRepo *repositories.SessionRepository) *SessionService {
+	return &SessionService{sessionRepo: sessionRepo}
+}
+
+// CreateSession mencatat sesi login baru untuk user
+func (s *SessionService) CreateSession(userID uint, userAgent, ipAddress string, expiresAt time.Time) (*models.Session, error) {
+	if len(userAgent) > maxUserAgentLength {
+		// Potong di batas rune agar karakter UTF-8 tidak terbelah
+		cut := maxUserAgentLength
+		for cut > 0 && !utf8.RuneStart(userAgent[cut]) {
+			cut--
+		}
+		userAgent = userAgent[:cut]
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		IdUser:     userID,
+		UserAgent:  userAgent,
+		IpAddress:  ipAddress,
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+	}
+
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, errors.New("gagal membuat sesi")
+	}
+	return session, nil
+}
+
+// ValidateSession memastikan sesi masih aktif dan memperbarui waktu terakhir digunakan
+func (s *SessionService) ValidateSession(sessionID uint, userID uint) (*models.Session, error) {
+	session, err := s.sessionRepo.GetByID(sessionID, userID)
+	if err != nil {
+		return nil, errors.New("sesi tidak ditemukan")
+	}
+	if session.RevokedAt != nil {
+		return nil, errors.New("sesi sudah dicabut")
+	}
+
+	now := time.Now()
+	if now.After(session.ExpiresAt) {
+		return nil, errors.New("sesi sudah kedaluwarsa")
+	}
+
+	if now.Sub(session.LastSeenAt) > sessionTouchInterval {
+		if err := s.sessionRepo.TouchLastSeen(session.ID, now); err == nil {
+			session.LastSeenAt = now
+		}
+	}
+
+	return session, nil
+}
+
+// GetUserSessions mengambil semua sesi aktif milik user
+func (s *SessionService) GetUserSessions(userID uint) ([]models.Session, error) {
+	sessions, err := s.sessionRepo.GetActiveByUserID(userID)
+	if err != nil {
+		return nil, errors.New("gagal mengambil data sesi")
+	}
+	return sessions, nil
+}
+
+// RevokeSession mencabut sesi milik user
+func (s *SessionService) RevokeSession(sessionID uint, userID uint) error {
+	session, err := s.sessionRepo.GetByID(sessionID, userID)
+	if err != nil {
+		return errors.New("sesi tidak ditemukan")
+	}
+	if session.RevokedAt != nil {
+		return errors.New("sesi sudah dicabut")
+	}
+
+	if err := s.sessionRepo.Revoke(sessionID, userID); err != nil {
+		return errors.New("gagal mencabut sesi")
+	}
+	return nil
+}
+
+// RevokeOtherSessions mencabut semua sesi user selain sesi yang sedang dipakai
+func (s *SessionService) RevokeOtherSessions(userID uint, currentSessionID uint) error {
+	if err := s.sessionRepo.RevokeAllExcept(userID, currentSessionID); err != nil {
+		return errors.New("gagal mencabut sesi lain")
+	}
+	return nil
+}
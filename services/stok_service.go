@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"evernos-api2/models"
+	"evernos-api2/repositories"
+	"strconv"
+)
+
+var jenisMutasi = []string{models.MutasiSale, models.MutasiCancel, models.MutasiAdjustment, models.MutasiImport, models.MutasiReturn}
+
+type StokService struct {
+	mutasiStokRepo *repositories.MutasiStokRepository
+	productRepo    *repositories.ProductRepository
+}
+
+func NewStokService(mutasiStokRepo *repositories.MutasiStokRepository, productRepo *repositories.ProductRepository) *StokService {
+	return &StokService{
+		mutasiStokRepo: mutasiStokRepo,
+		productRepo:    productRepo,
+	}
+}
+
+// GetStockHistory mengambil riwayat mutasi stok produk milik user dengan pagination.
+// variantIDStr dan jenis opsional untuk memfilter riwayat per varian atau jenis mutasi.
+func (s *StokService) GetStockHistory(productID, userID uint, limitStr, pageStr, cursor, variantIDStr, jenis string) ([]models.MutasiStok, map[string]interface{}, error) {
+	exists, err := s.productRepo.CheckExists(productID)
+	if err != nil {
+		return nil, nil, errors.New("gagal mengecek produk")
+	}
+	if !exists {
+		return nil, nil, errors.New("produk tidak ditemukan")
+	}
+
+	isOwner, err := s.productRepo.CheckOwnership(productID, userID)
+	if err != nil {
+		return nil, nil, errors.New("gagal mengecek kepemilikan produk")
+	}
+	if !isOwner {
+		return nil, nil, errors.New("anda tidak memiliki akses untuk melihat riwayat stok produk ini")
+	}
+
+	var variantID *uint
+	if variantIDStr != "" {
+		id, err := strconv.ParseUint(variantIDStr, 10, 32)
+		if err != nil {
+			return nil, nil, errors.New("variant_id tidak valid")
+		}
+		v := uint(id)
+		variantID = &v
+	}
+	if jenis != "" && !containsString(jenisMutasi, jenis) {
+		return nil, nil, errors.New("jenis mutasi tidak valid")
+	}
+
+	page := repositories.NewPageRequest(limitStr, pageStr, cursor)
+	mutasi, result, err := s.mutasiStokRepo.GetByProductID(productID, variantID, jenis, page)
+	if err == repositories.ErrInvalidCursor {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, errors.New("gagal mengambil riwayat stok")
+	}
+
+	return mutasi, paginationInfo(page, result), nil
+}
+
+// Reconcile mencari produk dan varian yang stoknya tidak sama dengan ledger. Dengan fix, stok
+// disamakan dengan jumlah ledger.
+func (s *StokService) Reconcile(fix bool) ([]repositories.StockDiscrepancy, error) {
+	discrepancies, err := s.mutasiStokRepo.FindDiscrepancies()
+	if err != nil {
+		return nil, err
+	}
+	if !fix {
+		return discrepancies, nil
+	}
+	for _, d := range discrepancies {
+		if err := s.mutasiStokRepo.FixDiscrepancy(d); err != nil {
+			return discrepancies, err
+		}
+	}
+	return discrepancies, nil
+}
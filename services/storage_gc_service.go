@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"evernos-api2/repositories"
+	"evernos-api2/storage"
+	"time"
+)
+
+// StorageGCResult adalah ringkasan satu kali proses garbage collection storage
+type StorageGCResult struct {
+	Scanned      int
+	Orphans      int
+	Deleted      int
+	BlobsRemoved int64
+}
+
+// StorageGCService membersihkan file foto di storage yang tidak lagi dirujuk oleh foto_produks
+// (misalnya sisa upload yang gagal, foto dari produk yang terhapus, atau penghapusan file yang gagal).
+// Blob yang tidak lagi dirujuk foto dari produk aktif ikut dihapus agar file-nya bisa dibersihkan.
+type StorageGCService struct {
+	fotoProdukRepo *repositories.FotoProdukRepository
+	blobRepo       *repositories.BlobRepository
+	storage        storage.Storage
+	grace          time.Duration
+}
+
+func NewStorageGCService(fotoProdukRepo *repositories.FotoProdukRepository, blobRepo *repositories.BlobRepository, store storage.Storage) *StorageGCService {
+	return &StorageGCService{
+		fotoProdukRepo: fotoProdukRepo,
+		blobRepo:       blobRepo,
+		storage:        store,
+		grace:          time.Duration(envInt("STORAGE_GC_GRACE_HOURS", 24)) * time.Hour,
+	}
+}
+
+// CollectOrphans menghapus file di prefix products/ yang tidak dirujuk dan lebih tua dari grace period.
+// Dengan dryRun, file yatim hanya dihitung tanpa dihapus.
+func (s *StorageGCService) CollectOrphans(ctx context.Context, dryRun bool) (StorageGCResult, error) {
+	var result StorageGCResult
+	cutoff := time.Now().Add(-s.grace)
+
+	// Blob tanpa foto aktif dihapus lebih dulu sehingga file-nya dianggap yatim di bawah
+	if !dryRun {
+		removed, err := s.blobRepo.DeleteUnreferenced(cutoff)
+		if err != nil {
+			return result, err
+		}
+		result.BlobsRemoved = removed
+	}
+
+	// Ambil referensi sebelum listing: file yang dibuat setelahnya pasti masih dalam grace period
+	fotoProduks, err := s.fotoProdukRepo.GetReferenced()
+	if err != nil {
+		return result, err
+	}
+	referenced := make(map[string]bool)
+	for _, fotoProduk := range fotoProduks {
+		for _, key := range fotoProduk.StorageKeys() {
+			referenced[key] = true
+		}
+	}
+	blobs, err := s.blobRepo.GetAll()
+	if err != nil {
+		return result, err
+	}
+	for _, blob := range blobs {
+		for _, key := range blob.StorageKeys() {
+			referenced[key] = true
+		}
+	}
+
+	objects, err := s.storage.List(ctx, "products/")
+	if err != nil {
+		return result, err
+	}
+
+	for _, object := range objects {
+		result.Scanned++
+		if referenced[object.Key] || object.LastModified.After(cutoff) {
+			continue
+		}
+
+		result.Orphans++
+		if dryRun {
+			continue
+		}
+		if err := s.storage.Delete(ctx, object.Key); err != nil {
+			return result, err
+		}
+		result.Deleted++
+	}
+
+	return result, nil
+}
@@ -4,7 +4,6 @@ import (
 	"evernos-api2/models"
 	"evernos-api2/repositories"
 	"errors"
-	"strconv"
 	"strings"
 )
 
@@ -34,6 +33,15 @@ func (s *TokoService) GetTokoByID(id uint) (*models.Toko, error) {
 	return toko, nil
 }
 
+// GetTokoByUrl mengambil toko berdasarkan URL toko
+func (s *TokoService) GetTokoByUrl(urlToko string) (*models.Toko, error) {
+	toko, err := s.tokoRepo.GetByUrl(urlToko)
+	if err != nil {
+		return nil, errors.New("toko tidak ditemukan")
+	}
+	return toko, nil
+}
+
 // UpdateToko memperbarui toko (hanya pemilik yang bisa update)
 func (s *TokoService) UpdateToko(id uint, userID uint, updateData map[string]interface{}) (*models.Toko, error) {
 	// Cek ownership
@@ -76,47 +84,19 @@ func (s *TokoService) UpdateToko(id uint, userID uint, updateData map[string]int
 }
 
 // GetAllTokos mengambil semua toko dengan pagination dan filter
-func (s *TokoService) GetAllTokos(limitStr, pageStr, namaToko string) ([]models.Toko, map[string]interface{}, error) {
-	// Parse limit dan page
-	limit := 10 // default
-	page := 1   // default
-
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
-	}
-
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
-	}
-
-	// Hitung offset
-	offset := (page - 1) * limit
+func (s *TokoService) GetAllTokos(limitStr, pageStr, cursor, namaToko string) ([]models.Toko, map[string]interface{}, error) {
+	page := repositories.NewPageRequest(limitStr, pageStr, cursor)
 
 	// Ambil data dari repository
-	tokos, total, err := s.tokoRepo.GetAllWithPagination(limit, offset, namaToko)
+	tokos, result, err := s.tokoRepo.GetAllWithPagination(page, namaToko)
+	if err == repositories.ErrInvalidCursor {
+		return nil, nil, err
+	}
 	if err != nil {
 		return nil, nil, errors.New("gagal mengambil data toko")
 	}
 
-	// Hitung pagination info
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-	hasNext := page < totalPages
-	hasPrev := page > 1
-
-	pagination := map[string]interface{}{
-		"current_page": page,
-		"total_pages":  totalPages,
-		"total_items":  total,
-		"limit":        limit,
-		"has_next":     hasNext,
-		"has_prev":     hasPrev,
-	}
-
-	return tokos, pagination, nil
+	return tokos, paginationInfo(page, result), nil
 }
 
 // validateNamaToko memvalidasi nama toko
@@ -6,63 +6,59 @@ import (
 	"errors"
 	"strconv"
 	"strings"
+	"time"
 	"gorm.io/gorm"
 )
 
 type TrxService struct {
 	trxRepo        *repositories.TrxRepository
 	logProdukService *LogProdukService
+	varianRepo     *repositories.VarianRepository
 }
 
-func NewTrxService(trxRepo *repositories.TrxRepository, logProdukService *LogProdukService) *TrxService {
+func NewTrxService(trxRepo *repositories.TrxRepository, logProdukService *LogProdukService, varianRepo *repositories.VarianRepository) *TrxService {
 	return &TrxService{
 		trxRepo:        trxRepo,
 		logProdukService: logProdukService,
+		varianRepo:     varianRepo,
 	}
 }
 
 // GetAllTrx mengambil semua transaksi user dengan pagination
-func (s *TrxService) GetAllTrx(userID uint, limitStr, pageStr string) ([]models.Trx, map[string]interface{}, error) {
-	// Parse limit dan page
-	limit := 10 // default
-	page := 1   // default
-
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
-	}
+func (s *TrxService) GetAllTrx(userID uint, limitStr, pageStr, cursor string) ([]models.Trx, map[string]interface{}, error) {
+	page := repositories.NewPageRequest(limitStr, pageStr, cursor)
 
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
+	// Ambil data dari repository
+	trxs, result, err := s.trxRepo.GetByUserID(userID, page)
+	if err == repositories.ErrInvalidCursor {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, errors.New("gagal mengambil data transaksi")
 	}
 
-	// Hitung offset
-	offset := (page - 1) * limit
+	fillTrxPhotoURLs(trxs)
+	return trxs, paginationInfo(page, result), nil
+}
 
-	// Ambil data dari repository
-	trxs, total, err := s.trxRepo.GetByUserID(userID, limit, offset)
+// GetTokoOrders mengambil pesanan yang masuk ke toko milik user dengan pagination
+func (s *TrxService) GetTokoOrders(userID uint, limitStr, pageStr, cursor string) ([]models.Trx, map[string]interface{}, error) {
+	page := repositories.NewPageRequest(limitStr, pageStr, cursor)
+
+	tokoID, err := s.trxRepo.GetTokoIDByUserID(userID)
 	if err != nil {
-		return nil, nil, errors.New("gagal mengambil data transaksi")
+		return nil, nil, errors.New("user belum memiliki toko")
 	}
 
-	// Hitung pagination info
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
-	hasNext := page < totalPages
-	hasPrev := page > 1
-
-	pagination := map[string]interface{}{
-		"current_page": page,
-		"total_pages":  totalPages,
-		"total_items":  total,
-		"limit":        limit,
-		"has_next":     hasNext,
-		"has_prev":     hasPrev,
+	trxs, result, err := s.trxRepo.GetByTokoID(tokoID, page)
+	if err == repositories.ErrInvalidCursor {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, errors.New("gagal mengambil data pesanan toko")
 	}
 
-	return trxs, pagination, nil
+	return trxs, paginationInfo(page, result), nil
 }
 
 // GetTrxByID mengambil transaksi berdasarkan ID
@@ -71,6 +67,9 @@ func (s *TrxService) GetTrxByID(id uint, userID uint) (*models.Trx, error) {
 	if err != nil {
 		return nil, errors.New("transaksi tidak ditemukan")
 	}
+	for i := range trx.DetailTrx {
+		fillPhotoURLs(trx.DetailTrx[i].Produk.FotoProduk)
+	}
 	return trx, nil
 }
 
@@ -97,7 +96,7 @@ func (s *TrxService) CreateTrx(userID uint, trxData map[string]interface{}) (*mo
 
 	// Validasi dan hitung total harga
 	var detailTrxs []models.DetailTrx
-	var totalHarga int
+	var totalHarga models.Money
 
 	for _, detail := range detailTrxData {
 		detailMap := detail.(map[string]interface{})
@@ -119,23 +118,47 @@ func (s *TrxService) CreateTrx(userID uint, trxData map[string]interface{}) (*mo
 			return nil, errors.New("gagal mengambil data produk")
 		}
 
-		// Validasi stok
-		if produk.Stok < kuantitas {
-			return nil, errors.New("stok produk " + produk.NamaProduk + " tidak mencukupi")
+		// Produk draft, terjadwal, atau diarsipkan tidak bisa dibeli (tetap tampil di transaksi lama)
+		if !produk.IsPublished(time.Now()) {
+			return nil, errors.New("produk " + produk.NamaProduk + " tidak tersedia untuk dibeli")
 		}
 
-		// Hitung harga (menggunakan harga konsumen)
-		hargaKonsumen, err := strconv.Atoi(produk.HargaKonsumen)
+		// Produk dengan varian wajib memilih varian; stok dan harga diambil dari varian
+		stok, harga, namaItem := produk.Stok, produk.HargaKonsumen, produk.NamaProduk
+		variantCount, err := s.varianRepo.CountByProductID(productID)
 		if err != nil {
-			return nil, errors.New("harga produk tidak valid")
+			return nil, errors.New("gagal mengecek varian produk")
+		}
+		var idVarian *uint
+		variantID, hasVariant := detailMap["variant_id"].(float64)
+		switch {
+		case variantCount > 0 && !hasVariant:
+			return nil, errors.New("variant_id wajib untuk produk " + produk.NamaProduk + " yang memiliki varian")
+		case variantCount == 0 && hasVariant:
+			return nil, errors.New("produk " + produk.NamaProduk + " tidak memiliki varian")
+		case hasVariant:
+			varian, err := s.varianRepo.GetByID(uint(variantID), productID)
+			if err != nil {
+				return nil, errors.New("varian dengan ID " + strconv.Itoa(int(variantID)) + " tidak ditemukan pada produk " + produk.NamaProduk)
+			}
+			id := varian.ID
+			idVarian = &id
+			stok, harga, namaItem = varian.Stok, varian.HargaKonsumen, produk.NamaProduk+" ("+varian.Sku+")"
+		}
+
+		// Validasi stok
+		if stok < kuantitas {
+			return nil, errors.New("stok produk " + namaItem + " tidak mencukupi")
 		}
 
-		hargaDetail := hargaKonsumen * kuantitas
+		// Hitung harga (menggunakan harga konsumen)
+		hargaDetail := harga.Mul(kuantitas)
 		totalHarga += hargaDetail
 
 		// Tambahkan ke detail transaksi
 		detailTrxs = append(detailTrxs, models.DetailTrx{
 			IdProduk:   productID,
+			IdVarian:   idVarian,
 			Kuantitas:  kuantitas,
 			HargaTotal: hargaDetail,
 		})
@@ -159,6 +182,9 @@ func (s *TrxService) CreateTrx(userID uint, trxData map[string]interface{}) (*mo
 		if err == gorm.ErrInvalidData {
 			return nil, errors.New("stok produk tidak mencukupi")
 		}
+		if err == repositories.ErrVariantChanged {
+			return nil, errors.New("varian produk sudah berubah, silakan pilih ulang varian")
+		}
 		return nil, errors.New("gagal membuat transaksi")
 	}
 
@@ -174,6 +200,41 @@ func (s *TrxService) CreateTrx(userID uint, trxData map[string]interface{}) (*mo
 	return trx, nil
 }
 
+// CancelTrx membatalkan transaksi milik user dan mengembalikan stoknya
+func (s *TrxService) CancelTrx(id uint, userID uint) (*models.Trx, error) {
+	if err := s.trxRepo.Cancel(id, userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("transaksi tidak ditemukan")
+		}
+		if err == repositories.ErrTrxCancelled {
+			return nil, err
+		}
+		return nil, errors.New("gagal membatalkan transaksi")
+	}
+	return s.GetTrxByID(id, userID)
+}
+
+// ReturnItem mencatat retur item pesanan toko milik user dan mengembalikan stoknya
+func (s *TrxService) ReturnItem(detailID uint, userID uint, kuantitas int) error {
+	if kuantitas <= 0 {
+		return errors.New("kuantitas retur harus lebih dari 0")
+	}
+	tokoID, err := s.trxRepo.GetTokoIDByUserID(userID)
+	if err != nil {
+		return errors.New("user belum memiliki toko")
+	}
+	if err := s.trxRepo.Return(detailID, tokoID, kuantitas, userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("item pesanan tidak ditemukan")
+		}
+		if err == repositories.ErrTrxCancelled || err == repositories.ErrReturnExceeded {
+			return err
+		}
+		return errors.New("gagal mencatat retur")
+	}
+	return nil
+}
+
 // CreateTrxWithResponse membuat transaksi baru dan mengembalikan response tanpa detail produk
 func (s *TrxService) CreateTrxWithResponse(userID uint, trxData map[string]interface{}) (*models.TrxCreateResponse, error) {
 	trx, err := s.CreateTrx(userID, trxData)
@@ -195,6 +256,7 @@ func (s *TrxService) convertToCreateResponse(trx *models.Trx) *models.TrxCreateR
 			UpdatedAt:  detail.UpdatedAt,
 			IdTrx:      detail.IdTrx,
 			IdProduk:   detail.IdProduk,
+			IdVarian:   detail.IdVarian,
 			Kuantitas:  detail.Kuantitas,
 			HargaTotal: detail.HargaTotal,
 		})
@@ -251,6 +313,13 @@ func (s *TrxService) validateTrxData(data map[string]interface{}) error {
 		if !ok || kuantitas <= 0 {
 			return errors.New("kuantitas pada detail ke-" + strconv.Itoa(i+1) + " tidak valid")
 		}
+
+		// Validasi variant_id (opsional, wajib untuk produk yang memiliki varian)
+		if variantID, exists := detailMap["variant_id"]; exists {
+			if id, ok := variantID.(float64); !ok || id <= 0 {
+				return errors.New("variant_id pada detail ke-" + strconv.Itoa(i+1) + " tidak valid")
+			}
+		}
 	}
 
 	return nil
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+	"evernos-api2/models"
+	"evernos-api2/repositories"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MaxFotoUlasan adalah jumlah maksimal foto per ulasan
+const MaxFotoUlasan = 5
+
+type UlasanService struct {
+	ulasanRepo        *repositories.UlasanRepository
+	productRepo       *repositories.ProductRepository
+	productService    *ProductService
+	fotoProdukService *FotoProdukService
+	notifikasiService *NotifikasiService
+}
+
+func NewUlasanService(ulasanRepo *repositories.UlasanRepository, productRepo *repositories.ProductRepository, productService *ProductService, fotoProdukService *FotoProdukService, notifikasiService *NotifikasiService) *UlasanService {
+	return &UlasanService{
+		ulasanRepo:        ulasanRepo,
+		productRepo:       productRepo,
+		productService:    productService,
+		fotoProdukService: fotoProdukService,
+		notifikasiService: notifikasiService,
+	}
+}
+
+// GetReviews mengambil ulasan produk dengan pagination beserta ringkasan rating produk.
+// Ulasan produk yang tidak tampil untuk publik hanya bisa dilihat pemilik toko.
+func (s *UlasanService) GetReviews(productID uint, viewerID uint, filters map[string]string) ([]models.Ulasan, map[string]interface{}, *models.RingkasanUlasan, error) {
+	product, err := s.productService.GetProductByID(productID, viewerID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	sort, ok := repositories.UlasanSort(filters["sort"])
+	if !ok {
+		return nil, nil, nil, errors.New("sort tidak valid")
+	}
+
+	rating := 0
+	if ratingStr := filters["rating"]; ratingStr != "" {
+		rating, err = strconv.Atoi(ratingStr)
+		if err != nil || rating < 1 || rating > 5 {
+			return nil, nil, nil, errors.New("rating tidak valid")
+		}
+	}
+
+	page := repositories.NewPageRequest(filters["limit"], filters["page"], filters["cursor"])
+	ulasans, result, err := s.ulasanRepo.GetByProductID(productID, rating, sort, page)
+	if err == repositories.ErrInvalidCursor {
+		return nil, nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, nil, errors.New("gagal mengambil data ulasan")
+	}
+
+	sebaran, err := s.ulasanRepo.GetSummary(productID)
+	if err != nil {
+		return nil, nil, nil, errors.New("gagal mengambil data ulasan")
+	}
+	ringkasan := &models.RingkasanUlasan{
+		Rating:       product.Rating,
+		JumlahUlasan: product.JumlahUlasan,
+		Sebaran:      sebaran,
+	}
+
+	fillReviewPhotoURLs(ulasans)
+	return ulasans, paginationInfo(page, result), ringkasan, nil
+}
+
+// CreateReview membuat ulasan untuk satu baris pembelian produk oleh user. Tanpa detail_trx_id,
+// baris pembelian paling lama yang belum diulas dipakai. photoPaths adalah file foto di staging
+// yang selalu dipindahkan atau dihapus oleh fungsi ini.
+func (s *UlasanService) CreateReview(userID uint, productID uint, data map[string]interface{}, photoPaths []string) (*models.Ulasan, error) {
+	ulasan, err := s.prepareReview(userID, productID, data, len(photoPaths))
+	if err != nil {
+		for _, path := range photoPaths {
+			os.Remove(path)
+		}
+		return nil, err
+	}
+
+	images, err := s.fotoProdukService.AcquireImages(photoPaths)
+	if err != nil {
+		return nil, err
+	}
+	for i, image := range images {
+		ulasan.Foto = append(ulasan.Foto, models.FotoUlasan{IdBlob: image.Blob.ID, Urutan: i})
+	}
+
+	if err := s.ulasanRepo.Create(ulasan); err != nil {
+		s.fotoProdukService.DiscardImages(images)
+		// Unique index id_detail_trx menolak ulasan ganda yang dikirim bersamaan
+		if reviewed, checkErr := s.ulasanRepo.CheckDetailReviewed(ulasan.IdDetailTrx); checkErr == nil && reviewed {
+			return nil, errors.New("pembelian ini sudah diulas")
+		}
+		return nil, errors.New("gagal menyimpan ulasan")
+	}
+	s.fotoProdukService.ProcessImages(images)
+
+	return s.getReview(ulasan.ID)
+}
+
+// prepareReview memvalidasi data ulasan dan memastikan user membeli produk pada baris pembelian tersebut
+func (s *UlasanService) prepareReview(userID uint, productID uint, data map[string]interface{}, photoCount int) (*models.Ulasan, error) {
+	rating, ok := data["rating"].(float64)
+	if !ok || rating != float64(int(rating)) || rating < 1 || rating > 5 {
+		return nil, errors.New("rating harus berupa bilangan bulat 1 sampai 5")
+	}
+	komentar, _ := data["komentar"].(string)
+	komentar = strings.TrimSpace(komentar)
+	if len(komentar) > 2000 {
+		return nil, errors.New("komentar maksimal 2000 karakter")
+	}
+	if photoCount > MaxFotoUlasan {
+		return nil, errors.New("maksimal " + strconv.Itoa(MaxFotoUlasan) + " foto per ulasan")
+	}
+
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, errors.New("produk tidak ditemukan")
+	}
+
+	var detail *models.DetailTrx
+	if detailTrxID, exists := data["detail_trx_id"]; exists {
+		id, ok := detailTrxID.(float64)
+		if !ok || id <= 0 {
+			return nil, errors.New("detail_trx_id tidak valid")
+		}
+		detail, err = s.ulasanRepo.GetPurchasedDetail(uint(id), userID)
+		if err != nil || detail.IdProduk != productID {
+			return nil, errors.New("pembelian produk tidak ditemukan")
+		}
+		reviewed, err := s.ulasanRepo.CheckDetailReviewed(detail.ID)
+		if err != nil {
+			return nil, errors.New("gagal mengecek ulasan")
+		}
+		if reviewed {
+			return nil, errors.New("pembelian ini sudah diulas")
+		}
+	} else {
+		detail, err = s.ulasanRepo.FindUnreviewedDetail(userID, productID)
+		if err != nil {
+			purchased, checkErr := s.ulasanRepo.HasPurchased(userID, productID)
+			if checkErr != nil {
+				return nil, errors.New("gagal mengecek pembelian")
+			}
+			if purchased {
+				return nil, errors.New("semua pembelian produk ini sudah diulas")
+			}
+			return nil, errors.New("anda belum pernah membeli produk ini")
+		}
+	}
+
+	return &models.Ulasan{
+		IdDetailTrx: detail.ID,
+		IdProduk:    productID,
+		IdVarian:    detail.IdVarian,
+		IdToko:      product.IdToko,
+		IdUser:      userID,
+		Rating:      int(rating),
+		Komentar:    komentar,
+	}, nil
+}
+
+// ReplyReview menyimpan balasan penjual untuk ulasan produk tokonya dan memberi tahu pembeli
+func (s *UlasanService) ReplyReview(ulasanID uint, userID uint, balasan string) (*models.Ulasan, error) {
+	balasan = strings.TrimSpace(balasan)
+	if balasan == "" {
+		return nil, errors.New("balasan tidak boleh kosong")
+	}
+	if len(balasan) > 2000 {
+		return nil, errors.New("balasan maksimal 2000 karakter")
+	}
+
+	ulasan, err := s.ulasanRepo.GetByID(ulasanID)
+	if err != nil {
+		return nil, errors.New("ulasan tidak ditemukan")
+	}
+
+	tokoID, err := s.productRepo.GetTokoIDByUserID(userID)
+	if err != nil || tokoID != ulasan.IdToko {
+		return nil, errors.New("anda tidak memiliki akses untuk membalas ulasan ini")
+	}
+
+	if err := s.ulasanRepo.SetReply(ulasanID, balasan); err != nil {
+		return nil, errors.New("gagal menyimpan balasan")
+	}
+
+	s.notifikasiService.Notify(ulasan.IdUser, models.NotifikasiUlasanDibalas,
+		"Ulasan dibalas",
+		"Penjual membalas ulasan anda: "+balasan,
+		"review:"+strconv.FormatUint(uint64(ulasanID), 10))
+
+	return s.getReview(ulasanID)
+}
+
+// getReview mengambil ulasan terbaru beserta URL fotonya
+func (s *UlasanService) getReview(id uint) (*models.Ulasan, error) {
+	ulasan, err := s.ulasanRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	fillReviewPhotoURL(ulasan)
+	return ulasan, nil
+}
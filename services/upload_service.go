@@ -0,0 +1,211 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"evernos-api2/models"
+	"fmt"
+	"image"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Batas upload foto produk. Resumable upload boleh lebih besar karena tidak dikirim dalam satu request.
+const (
+	MaxImageUploadSize    = 5 * 1024 * 1024
+	MaxResumableImageSize = 25 * 1024 * 1024
+)
+
+var (
+	ErrUploadTooLarge      = errors.New("Ukuran file terlalu besar. Maksimal 5MB")
+	ErrUploadUnsupported   = errors.New("Tipe file tidak didukung. Gunakan JPG, JPEG, PNG, atau WEBP")
+	ErrUploadNotImage      = errors.New("Isi file bukan gambar yang valid")
+	ErrUploadTypeMismatch  = errors.New("Isi file tidak sesuai dengan ekstensi file")
+	ErrUploadDimensions    = errors.New("Dimensi gambar terlalu besar")
+	ErrUploadSaveFailed    = errors.New("Gagal menyimpan file")
+	ErrMediaUnsupported    = errors.New("Tipe file tidak didukung. Gunakan JPG, JPEG, PNG, WEBP, MP4, MOV, atau WEBM")
+	ErrUploadNotVideo      = errors.New("Isi file bukan video yang valid")
+	ErrResumableImageSize  = errors.New("Ukuran gambar terlalu besar. Maksimal 25MB")
+	allowedImageExtensions = map[string]string{".jpg": "jpeg", ".jpeg": "jpeg", ".png": "png", ".webp": "webp"}
+	allowedVideoExtensions = map[string]string{".mp4": "video/mp4", ".mov": "video/quicktime", ".webm": "video/webm"}
+)
+
+// UploadService memusatkan validasi dan penyimpanan sementara file foto yang diupload.
+// Validasi tidak hanya berdasarkan ekstensi: magic bytes, decode penuh, dan batas dimensi
+// (mencegah decompression bomb). File mentah tidak pernah disajikan; yang dipublikasikan hanya
+// rendisi hasil encode ulang ImageProcessor, sehingga data tambahan di dalam file (polyglot) ikut terbuang.
+type UploadService struct {
+	imageProcessor *ImageProcessor
+	maxDimension   int
+	maxPixels      int
+}
+
+func NewUploadService(imageProcessor *ImageProcessor) *UploadService {
+	return &UploadService{
+		imageProcessor: imageProcessor,
+		maxDimension:   envInt("IMAGE_MAX_INPUT_DIMENSION", 8000),
+		maxPixels:      envInt("IMAGE_MAX_PIXELS", 40000000),
+	}
+}
+
+// StageProductImage memvalidasi file foto lalu menyimpannya ke staging untuk diproses.
+// index > 0 ditambahkan ke nama file untuk upload multiple.
+func (s *UploadService) StageProductImage(file *multipart.FileHeader, userID uint, index int) (string, string, error) {
+	if file.Size > MaxImageUploadSize {
+		return "", "", ErrUploadTooLarge
+	}
+
+	fileExt := strings.ToLower(filepath.Ext(file.Filename))
+	expectedFormat, ok := allowedImageExtensions[fileExt]
+	if !ok {
+		return "", "", ErrUploadUnsupported
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", "", ErrUploadSaveFailed
+	}
+	defer src.Close()
+
+	// Baca maksimal 1 byte lebih dari batas untuk mendeteksi Size header yang tidak jujur
+	data, err := io.ReadAll(io.LimitReader(src, MaxImageUploadSize+1))
+	if err != nil {
+		return "", "", ErrUploadSaveFailed
+	}
+	if len(data) > MaxImageUploadSize {
+		return "", "", ErrUploadTooLarge
+	}
+
+	if err := s.ValidateImage(data, expectedFormat); err != nil {
+		return "", "", err
+	}
+
+	// Generate nama file unik
+	timestamp := time.Now().Format("20060102_150405")
+	uniqueID := uuid.New().String()[:8]
+	fileName := fmt.Sprintf("product_%d_%s_%s%s", userID, timestamp, uniqueID, fileExt)
+	if index > 0 {
+		fileName = fmt.Sprintf("product_%d_%s_%s_%d%s", userID, timestamp, uniqueID, index, fileExt)
+	}
+
+	stagingPath := s.imageProcessor.StagingPath(fileName)
+	if err := os.WriteFile(stagingPath, data, 0o600); err != nil {
+		return "", "", ErrUploadSaveFailed
+	}
+
+	return fileName, stagingPath, nil
+}
+
+// MediaTypeForFile menentukan jenis media dari ekstensi file. Mengembalikan string kosong jika tidak didukung.
+func MediaTypeForFile(fileName string) string {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if _, ok := allowedImageExtensions[ext]; ok {
+		return models.MediaTypeImage
+	}
+	if _, ok := allowedVideoExtensions[ext]; ok {
+		return models.MediaTypeVideo
+	}
+	return ""
+}
+
+// ValidateStagedFile memvalidasi file hasil resumable upload yang sudah lengkap di staging.
+// Mengembalikan content type untuk video; gambar divalidasi penuh seperti upload biasa.
+func (s *UploadService) ValidateStagedFile(path string, fileName string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+
+	if expectedFormat, ok := allowedImageExtensions[ext]; ok {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", ErrUploadSaveFailed
+		}
+		if info.Size() > MaxResumableImageSize {
+			return "", ErrResumableImageSize
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", ErrUploadSaveFailed
+		}
+		return "", s.ValidateImage(data, expectedFormat)
+	}
+
+	contentType, ok := allowedVideoExtensions[ext]
+	if !ok {
+		return "", ErrMediaUnsupported
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", ErrUploadSaveFailed
+	}
+	defer file.Close()
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return "", ErrUploadNotVideo
+	}
+	if sniffVideoType(header) != contentType {
+		return "", ErrUploadNotVideo
+	}
+
+	return contentType, nil
+}
+
+// sniffVideoType mendeteksi container video dari magic bytes
+func sniffVideoType(header []byte) string {
+	switch {
+	case string(header[4:8]) == "ftyp" && string(header[8:10]) == "qt":
+		return "video/quicktime"
+	case string(header[4:8]) == "ftyp":
+		return "video/mp4"
+	case bytes.Equal(header[:4], []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return "video/webm"
+	}
+	return ""
+}
+
+// ValidateImage memastikan isi file benar-benar gambar dengan format yang diharapkan
+func (s *UploadService) ValidateImage(data []byte, expectedFormat string) error {
+	sniffed := sniffImageFormat(data)
+	if sniffed == "" {
+		return ErrUploadNotImage
+	}
+	if sniffed != expectedFormat {
+		return ErrUploadTypeMismatch
+	}
+
+	// Cek dimensi dari header sebelum decode penuh agar gambar raksasa tidak dialokasikan
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || format != sniffed {
+		return ErrUploadNotImage
+	}
+	if config.Width <= 0 || config.Height <= 0 ||
+		config.Width > s.maxDimension || config.Height > s.maxDimension ||
+		config.Width*config.Height > s.maxPixels {
+		return ErrUploadDimensions
+	}
+
+	if _, _, err := image.Decode(bytes.NewReader(data)); err != nil {
+		return ErrUploadNotImage
+	}
+
+	return nil
+}
+
+// sniffImageFormat mendeteksi format gambar dari magic bytes
+func sniffImageFormat(data []byte) string {
+	switch {
+	case len(data) >= 3 && bytes.Equal(data[:3], []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg"
+	case len(data) >= 8 && bytes.Equal(data[:8], []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "webp"
+	}
+	return ""
+}
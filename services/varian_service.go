@@ -0,0 +1,281 @@
+package services
+
+import (
+	"errors"
+	"evernos-api2/models"
+	"evernos-api2/repositories"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Batas jumlah varian agar kombinasi opsi tetap wajar untuk satu produk
+const (
+	maxOpsiVarian   = 3
+	maxVarianProduk = 100
+)
+
+type VarianService struct {
+	varianRepo     *repositories.VarianRepository
+	productRepo    *repositories.ProductRepository
+	productService *ProductService
+}
+
+func NewVarianService(varianRepo *repositories.VarianRepository, productRepo *repositories.ProductRepository, productService *ProductService) *VarianService {
+	return &VarianService{
+		varianRepo:     varianRepo,
+		productRepo:    productRepo,
+		productService: productService,
+	}
+}
+
+// GetVariants mengambil opsi dan varian produk. Varian produk yang belum tampil untuk publik
+// (draft, terjadwal, diarsipkan, atau menunggu moderasi) hanya bisa dilihat pemilik toko.
+func (s *VarianService) GetVariants(productID uint, viewerID uint) ([]models.OpsiVarian, []models.VarianProduk, error) {
+	product, err := s.productService.GetProductByID(productID, viewerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return product.OpsiVarian, product.VarianProduk, nil
+}
+
+// SetVariants mengganti seluruh opsi dan varian produk. Format data:
+//
+//	{"opsi": [{"nama": "Ukuran", "nilai": ["S", "M"]}],
+//	 "varian": [{"id": 1, "sku": "KAOS-S", "opsi": {"Ukuran": "S"}, "harga_reseller": 40000,
+//	             "harga_konsumen": 50000, "stok": 10, "id_foto_produk": 3}]}
+//
+// Varian dengan id diperbarui, tanpa id dibuat baru, dan yang tidak disertakan dihapus.
+// Mengirim opsi dan varian kosong menjadikan produk tanpa varian.
+func (s *VarianService) SetVariants(productID uint, userID uint, data map[string]interface{}) ([]models.OpsiVarian, []models.VarianProduk, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, nil, errors.New("produk tidak ditemukan")
+	}
+
+	isOwner, err := s.productRepo.CheckOwnership(productID, userID)
+	if err != nil {
+		return nil, nil, errors.New("gagal mengecek kepemilikan produk")
+	}
+	if !isOwner {
+		return nil, nil, errors.New("anda tidak memiliki akses untuk mengubah varian produk ini")
+	}
+
+	opsi, err := parseOpsiVarian(data["opsi"])
+	if err != nil {
+		return nil, nil, err
+	}
+	varian, err := parseVarianProduk(data["varian"], opsi)
+	if err != nil {
+		return nil, nil, err
+	}
+	if (len(opsi) == 0) != (len(varian) == 0) {
+		return nil, nil, errors.New("opsi dan varian harus diisi bersamaan")
+	}
+
+	photoIDs := make(map[uint]bool, len(product.FotoProduk))
+	for _, foto := range product.FotoProduk {
+		photoIDs[foto.ID] = true
+	}
+	for _, v := range varian {
+		if v.IdFotoProduk != nil && !photoIDs[*v.IdFotoProduk] {
+			return nil, nil, fmt.Errorf("foto %d bukan foto produk ini", *v.IdFotoProduk)
+		}
+		taken, err := s.varianRepo.CheckSkuTaken(product.IdToko, v.Sku, productID)
+		if err != nil {
+			return nil, nil, errors.New("gagal mengecek SKU")
+		}
+		if taken {
+			return nil, nil, fmt.Errorf("SKU %s sudah dipakai produk lain di toko ini", v.Sku)
+		}
+	}
+
+	if err := s.varianRepo.Replace(productID, opsi, varian, userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, errors.New("varian dengan id tersebut bukan milik produk ini")
+		}
+		if err == repositories.ErrSkuTaken {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("gagal menyimpan varian produk")
+	}
+
+	if variantsChanged(product, opsi, varian) {
+		if err := resubmitModeration(s.productRepo, productID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return s.varianRepo.GetByProductID(productID)
+}
+
+// variantsChanged mengecek perubahan varian yang perlu dimoderasi ulang: nama dan nilai opsi,
+// kombinasi opsi varian, dan foto varian. Perubahan harga, stok, dan SKU tidak perlu dimoderasi.
+func variantsChanged(product *models.Produk, opsi []models.OpsiVarian, varian []models.VarianProduk) bool {
+	return variantSignature(product.OpsiVarian, product.VarianProduk) != variantSignature(opsi, varian)
+}
+
+func variantSignature(opsi []models.OpsiVarian, varian []models.VarianProduk) string {
+	var parts []string
+	for _, o := range opsi {
+		parts = append(parts, o.Nama+"="+strings.Join(o.Nilai, ","))
+	}
+	var combos []string
+	for _, v := range varian {
+		var combo []string
+		for _, o := range opsi {
+			combo = append(combo, o.Nama+"="+v.Opsi[o.Nama])
+		}
+		if v.IdFotoProduk != nil {
+			combo = append(combo, "foto="+strconv.FormatUint(uint64(*v.IdFotoProduk), 10))
+		}
+		combos = append(combos, strings.Join(combo, ","))
+	}
+	sort.Strings(combos)
+	return strings.Join(parts, "|") + "#" + strings.Join(combos, "|")
+}
+
+// parseOpsiVarian memvalidasi daftar opsi: nama unik dan nilai tidak kosong atau duplikat
+func parseOpsiVarian(raw interface{}) ([]models.OpsiVarian, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, errors.New("format opsi tidak valid")
+	}
+	if len(items) > maxOpsiVarian {
+		return nil, fmt.Errorf("maksimal %d opsi varian", maxOpsiVarian)
+	}
+
+	var opsi []models.OpsiVarian
+	names := make(map[string]bool)
+	for i, item := range items {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("format opsi tidak valid")
+		}
+		nama, _ := itemMap["nama"].(string)
+		nama = strings.TrimSpace(nama)
+		if nama == "" || len(nama) > 50 {
+			return nil, errors.New("nama opsi ke-" + strconv.Itoa(i+1) + " tidak valid")
+		}
+		if names[strings.ToLower(nama)] {
+			return nil, errors.New("nama opsi " + nama + " duplikat")
+		}
+		names[strings.ToLower(nama)] = true
+
+		rawNilai, _ := itemMap["nilai"].([]interface{})
+		var nilai []string
+		seen := make(map[string]bool)
+		for _, v := range rawNilai {
+			str, _ := v.(string)
+			str = strings.TrimSpace(str)
+			if str == "" || seen[strings.ToLower(str)] {
+				return nil, errors.New("nilai opsi " + nama + " kosong atau duplikat")
+			}
+			seen[strings.ToLower(str)] = true
+			nilai = append(nilai, str)
+		}
+		if len(nilai) == 0 {
+			return nil, errors.New("opsi " + nama + " harus memiliki minimal satu nilai")
+		}
+
+		opsi = append(opsi, models.OpsiVarian{Nama: nama, Nilai: nilai, Urutan: i})
+	}
+	return opsi, nil
+}
+
+// parseVarianProduk memvalidasi varian: setiap varian memilih tepat satu nilai untuk setiap opsi,
+// kombinasi dan SKU tidak boleh duplikat
+func parseVarianProduk(raw interface{}, opsi []models.OpsiVarian) ([]models.VarianProduk, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, errors.New("format varian tidak valid")
+	}
+	if len(items) > maxVarianProduk {
+		return nil, fmt.Errorf("maksimal %d varian per produk", maxVarianProduk)
+	}
+
+	var varian []models.VarianProduk
+	skus := make(map[string]bool)
+	combos := make(map[string]bool)
+	for i, item := range items {
+		label := "varian ke-" + strconv.Itoa(i+1)
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("format " + label + " tidak valid")
+		}
+
+		v := models.VarianProduk{Opsi: make(map[string]string)}
+		if id, ok := itemMap["id"].(float64); ok && id > 0 {
+			v.ID = uint(id)
+		}
+
+		v.Sku, _ = itemMap["sku"].(string)
+		v.Sku = strings.TrimSpace(v.Sku)
+		if v.Sku == "" || len(v.Sku) > 100 {
+			return nil, errors.New("sku " + label + " tidak valid")
+		}
+		if skus[strings.ToLower(v.Sku)] {
+			return nil, errors.New("SKU " + v.Sku + " duplikat")
+		}
+		skus[strings.ToLower(v.Sku)] = true
+
+		rawOpsi, _ := itemMap["opsi"].(map[string]interface{})
+		if len(rawOpsi) != len(opsi) {
+			return nil, errors.New(label + " harus memilih satu nilai untuk setiap opsi")
+		}
+		var combo []string
+		for _, o := range opsi {
+			nilai, _ := rawOpsi[o.Nama].(string)
+			if !containsString(o.Nilai, nilai) {
+				return nil, errors.New(label + " memiliki nilai opsi " + o.Nama + " yang tidak valid")
+			}
+			v.Opsi[o.Nama] = nilai
+			combo = append(combo, o.Nama+"="+nilai)
+		}
+		sort.Strings(combo)
+		key := strings.Join(combo, "|")
+		if combos[key] {
+			return nil, errors.New("kombinasi opsi " + label + " duplikat")
+		}
+		combos[key] = true
+
+		var errReseller, errKonsumen error
+		v.HargaReseller, errReseller = models.MoneyFromJSON(itemMap["harga_reseller"])
+		v.HargaKonsumen, errKonsumen = models.MoneyFromJSON(itemMap["harga_konsumen"])
+		if errReseller != nil || errKonsumen != nil {
+			return nil, errors.New("harga " + label + " tidak valid")
+		}
+
+		stok, ok := itemMap["stok"].(float64)
+		if !ok || stok < 0 {
+			return nil, errors.New("stok " + label + " tidak valid")
+		}
+		v.Stok = int(stok)
+
+		if fotoID, ok := itemMap["id_foto_produk"].(float64); ok && fotoID > 0 {
+			id := uint(fotoID)
+			v.IdFotoProduk = &id
+		}
+
+		varian = append(varian, v)
+	}
+	return varian, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"evernos-api2/models"
+	"evernos-api2/repositories"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type WishlistService struct {
+	wishlistRepo      *repositories.WishlistRepository
+	productRepo       *repositories.ProductRepository
+	notifikasiService *NotifikasiService
+	emailService      *EmailService
+}
+
+func NewWishlistService(wishlistRepo *repositories.WishlistRepository, productRepo *repositories.ProductRepository, notifikasiService *NotifikasiService, emailService *EmailService) *WishlistService {
+	return &WishlistService{
+		wishlistRepo:      wishlistRepo,
+		productRepo:       productRepo,
+		notifikasiService: notifikasiService,
+		emailService:      emailService,
+	}
+}
+
+// Interval pengecekan langganan stok untuk produk terjadwal yang sudah terbit
+const backInStockCheckInterval = time.Minute
+
+// Start menjalankan pengecekan berkala langganan stok di background. Produk terjadwal terbit tanpa
+// ada request yang mengubahnya, sehingga pelanggannya diberi tahu dari pengecekan ini.
+func (s *WishlistService) Start() {
+	go func() {
+		for {
+			time.Sleep(backInStockCheckInterval)
+			ids, err := s.wishlistRepo.GetNotifiableProductIDs()
+			if err != nil {
+				log.Printf("failed to fetch back-in-stock products: %v", err)
+				continue
+			}
+			for _, id := range ids {
+				s.NotifyBackInStock(id)
+			}
+		}
+	}()
+}
+
+// GetWishlist mengambil wishlist user beserta produknya dengan pagination. Produk yang sedang tidak
+// terbit tidak dimuat sehingga item wishlist-nya hanya berisi id_produk.
+func (s *WishlistService) GetWishlist(userID uint, limitStr, pageStr, cursor string) ([]models.Wishlist, map[string]interface{}, error) {
+	page := repositories.NewPageRequest(limitStr, pageStr, cursor)
+
+	wishlists, result, err := s.wishlistRepo.GetByUserID(userID, page)
+	if err == repositories.ErrInvalidCursor {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, errors.New("gagal mengambil data wishlist")
+	}
+
+	for i := range wishlists {
+		if wishlists[i].Produk != nil {
+			fillPhotoURLs(wishlists[i].Produk.FotoProduk)
+		}
+	}
+	return wishlists, paginationInfo(page, result), nil
+}
+
+// AddToWishlist menambahkan produk yang sudah terbit ke wishlist user
+func (s *WishlistService) AddToWishlist(userID uint, productID uint) error {
+	if _, err := s.getPublished(productID); err != nil {
+		return err
+	}
+	if err := s.wishlistRepo.Add(userID, productID); err != nil {
+		return errors.New("gagal menambahkan produk ke wishlist")
+	}
+	return nil
+}
+
+// RemoveFromWishlist menghapus produk dari wishlist user
+func (s *WishlistService) RemoveFromWishlist(userID uint, productID uint) error {
+	removed, err := s.wishlistRepo.Remove(userID, productID)
+	if err != nil {
+		return errors.New("gagal menghapus produk dari wishlist")
+	}
+	if !removed {
+		return errors.New("produk tidak ada di wishlist")
+	}
+	return nil
+}
+
+// SubscribeBackInStock mendaftarkan user untuk diberi tahu saat produk yang habis tersedia kembali
+func (s *WishlistService) SubscribeBackInStock(userID uint, productID uint) error {
+	product, err := s.getPublished(productID)
+	if err != nil {
+		return err
+	}
+	if product.Stok > 0 {
+		return errors.New("stok produk masih tersedia")
+	}
+	if err := s.wishlistRepo.Subscribe(userID, productID); err != nil {
+		return errors.New("gagal menyimpan langganan stok")
+	}
+	return nil
+}
+
+// UnsubscribeBackInStock membatalkan langganan notifikasi stok produk
+func (s *WishlistService) UnsubscribeBackInStock(userID uint, productID uint) error {
+	removed, err := s.wishlistRepo.Unsubscribe(userID, productID)
+	if err != nil {
+		return errors.New("gagal membatalkan langganan stok")
+	}
+	if !removed {
+		return errors.New("anda tidak berlangganan stok produk ini")
+	}
+	return nil
+}
+
+// NotifyBackInStock memberi tahu pelanggan stok produk lewat notifikasi in-app dan email (jika SMTP
+// dikonfigurasi), lalu menghapus langganannya. Produk yang belum terbit tidak diberitahukan dan
+// langganannya tetap disimpan sampai produk terbit. Kegagalan hanya di-log karena dipanggil di background.
+func (s *WishlistService) NotifyBackInStock(productID uint) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil || !product.IsPublished(time.Now()) || product.Stok <= 0 {
+		return
+	}
+
+	subscribers, err := s.wishlistRepo.ClaimSubscribers(productID)
+	if err != nil {
+		log.Printf("failed to claim back-in-stock subscribers for product %d: %v", productID, err)
+		return
+	}
+
+	judul := "Produk tersedia kembali"
+	pesan := "Produk \"" + product.NamaProduk + "\" yang anda tunggu sudah tersedia kembali."
+	for _, subscriber := range subscribers {
+		s.notifikasiService.Notify(subscriber.IdUser, models.NotifikasiStokTersedia, judul, pesan,
+			"product:"+strconv.FormatUint(uint64(productID), 10))
+
+		// Akun yang dianonimkan memakai email .invalid yang tidak bisa menerima email
+		if !s.emailService.Enabled() || subscriber.Email == "" || strings.HasSuffix(subscriber.Email, ".invalid") {
+			continue
+		}
+		if err := s.emailService.Send(subscriber.Email, judul+": "+product.NamaProduk, pesan); err != nil {
+			log.Printf("failed to send back-in-stock email to user %d: %v", subscriber.IdUser, err)
+		}
+	}
+}
+
+// getPublished mengambil produk yang sudah terbit; produk lain dianggap tidak ditemukan
+func (s *WishlistService) getPublished(productID uint) (*models.Produk, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil || !product.IsPublished(time.Now()) {
+		return nil, errors.New("produk tidak ditemukan")
+	}
+	return product, nil
+}
@@ -0,0 +1,116 @@
+// file: storage/local.go
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage menyimpan file di disk lokal. Hanya cocok untuk satu instance API
+// (atau direktori bersama seperti NFS).
+type LocalStorage struct {
+	Root    string
+	BaseURL string
+}
+
+func NewLocalStorage(root, baseURL string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("gagal membuat direktori storage: %v", err)
+	}
+	return &LocalStorage{Root: root, BaseURL: baseURL}, nil
+}
+
+// Put menulis ke file sementara lalu di-rename agar tidak pernah ada file setengah jadi yang tersaji
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(ctx context.Context, key string) (string, error) {
+	return joinURL(s.BaseURL, key), nil
+}
+
+// List menelusuri direktori Root/prefix secara rekursif. Dotfile (misalnya .gitkeep) dan file
+// .tmp yang sedang ditulis oleh Put bukan object storage sehingga tidak ikut dikembalikan.
+func (s *LocalStorage) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(filepath.Join(s.Root, filepath.FromSlash(prefix)), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return ctx.Err()
+		}
+		if strings.HasPrefix(d.Name(), ".") || strings.HasSuffix(d.Name(), ".tmp") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.Root, path)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{
+			Key:          filepath.ToSlash(rel),
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	return objects, err
+}
+
+// path memetakan key ke path di disk. Key di-clean sebagai path absolut sehingga
+// "../" tidak bisa keluar dari Root.
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + filepath.FromSlash(key))
+	if cleaned == string(filepath.Separator) {
+		return "", fmt.Errorf("key storage tidak valid: %s", key)
+	}
+	return filepath.Join(s.Root, cleaned), nil
+}
@@ -0,0 +1,105 @@
+// file: storage/s3.go
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Storage menyimpan file di object storage S3-compatible (AWS S3, MinIO, R2, dll).
+// Bucket publik memakai PublicURL (misalnya CDN); bucket private memakai presigned URL.
+type S3Storage struct {
+	client     *minio.Client
+	bucket     string
+	publicURL  string
+	presign    bool
+	presignTTL time.Duration
+}
+
+// NewS3StorageFromEnv membuat S3Storage dari variabel S3_* (lihat Init)
+func NewS3StorageFromEnv() (*S3Storage, error) {
+	endpoint := os.Getenv("S3_ENDPOINT")
+	bucket := os.Getenv("S3_BUCKET")
+	if endpoint == "" || bucket == "" {
+		return nil, errors.New("S3_ENDPOINT dan S3_BUCKET wajib diisi untuk STORAGE_DRIVER=s3")
+	}
+
+	useSSL := os.Getenv("S3_USE_SSL") != "false"
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(os.Getenv("S3_ACCESS_KEY"), os.Getenv("S3_SECRET_KEY"), ""),
+		Secure: useSSL,
+		Region: os.Getenv("S3_REGION"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat client S3: %v", err)
+	}
+
+	// Default URL publik mengikuti gaya path-style: <endpoint>/<bucket>
+	scheme := "https"
+	if !useSSL {
+		scheme = "http"
+	}
+	publicURL := envOr("STORAGE_PUBLIC_URL", fmt.Sprintf("%s://%s/%s", scheme, endpoint, bucket))
+
+	ttl := time.Hour
+	if v, err := strconv.Atoi(os.Getenv("S3_PRESIGN_TTL")); err == nil && v > 0 {
+		ttl = time.Duration(v) * time.Second
+	}
+
+	return &S3Storage{
+		client:     client,
+		bucket:     bucket,
+		publicURL:  publicURL,
+		presign:    strings.EqualFold(os.Getenv("S3_PRESIGN"), "true"),
+		presignTTL: ttl,
+	}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	return err
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	// S3 tidak mengembalikan error untuk key yang tidak ada
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3Storage) URL(ctx context.Context, key string) (string, error) {
+	if !s.presign {
+		return joinURL(s.publicURL, key), nil
+	}
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, s.presignTTL, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	for info := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if info.Err != nil {
+			return nil, info.Err
+		}
+		objects = append(objects, Object{
+			Key:          info.Key,
+			Size:         info.Size,
+			LastModified: info.LastModified,
+		})
+	}
+	return objects, nil
+}
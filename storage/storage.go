@@ -0,0 +1,74 @@
+// file: storage/storage.go
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Storage adalah backend penyimpanan file upload. Key selalu berupa path relatif
+// dengan pemisah "/" (contoh: "products/product_1_xxx_lg.jpg").
+type Storage interface {
+	// Put menyimpan isi reader ke key. File lama dengan key yang sama ditimpa.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Delete menghapus file. Key yang tidak ada tidak dianggap error.
+	Delete(ctx context.Context, key string) error
+	// URL mengembalikan URL yang bisa diakses client (publik atau presigned)
+	URL(ctx context.Context, key string) (string, error)
+	// List mengembalikan semua file dengan prefix tertentu
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// Object adalah informasi file hasil List
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Default adalah storage yang dipakai aplikasi, diisi oleh Init
+var Default Storage
+
+// Init membuat storage sesuai STORAGE_DRIVER ("local" atau "s3") dan menyimpannya di Default
+func Init() error {
+	var err error
+	switch driver := strings.ToLower(os.Getenv("STORAGE_DRIVER")); driver {
+	case "", "local":
+		Default, err = NewLocalStorage(envOr("STORAGE_LOCAL_DIR", "uploads"), envOr("STORAGE_PUBLIC_URL", "/uploads"))
+	case "s3":
+		Default, err = NewS3StorageFromEnv()
+	default:
+		return fmt.Errorf("STORAGE_DRIVER tidak dikenal: %s", driver)
+	}
+	return err
+}
+
+// PublicURL mengembalikan URL untuk key menggunakan Default storage.
+// Jika storage belum diinisialisasi atau gagal, key dikembalikan apa adanya.
+func PublicURL(ctx context.Context, key string) string {
+	if Default == nil || key == "" {
+		return key
+	}
+	url, err := Default.URL(ctx, key)
+	if err != nil {
+		return key
+	}
+	return url
+}
+
+// joinURL menggabungkan base URL dan key tanpa double slash
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(key, "/")
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}